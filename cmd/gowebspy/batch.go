@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/ArjunSharda/gowebspy/pkg/gowebspy"
+	"github.com/fatih/color"
+)
+
+func readTargets(path string) ([]string, error) {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		r = file
+	}
+	return gowebspy.ReadTargets(r)
+}
+
+func runBatch(filterOpts *gowebspy.FilterOptions, opts gowebspy.Options) {
+	targets, err := readTargets(inputFile)
+	if err != nil {
+		fmt.Printf("Error reading targets: %v\n", err)
+		os.Exit(1)
+	}
+	scanTargets(targets, filterOpts, opts)
+}
+
+// scanTargets scans every target on the batch worker pool and prints each
+// result as it arrives, followed by a summary.
+func scanTargets(targets []string, filterOpts *gowebspy.FilterOptions, opts gowebspy.Options) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	start := time.Now()
+	results := gowebspy.ScanBatch(ctx, targets, gowebspy.BatchOptions{
+		Workers:      batchWorkers,
+		HostInterval: hostInterval,
+		Options:      opts,
+		Filter:       filterOpts,
+	})
+
+	encoder := json.NewEncoder(os.Stdout)
+	summary := gowebspy.BatchSummary{}
+
+	for result := range results {
+		summary.Add(result)
+
+		if result.Err == nil && !result.Matched {
+			continue
+		}
+
+		if formatJSON {
+			if err := encoder.Encode(newReport(result.Target, result.Info, result.Err)); err != nil {
+				fmt.Fprintf(os.Stderr, "Error writing JSON: %v\n", err)
+				os.Exit(1)
+			}
+			continue
+		}
+
+		printBatchResult(result)
+	}
+	summary.Elapsed = time.Since(start)
+
+	if formatJSON {
+		printBatchSummary(os.Stderr, summary, len(targets))
+	} else {
+		fmt.Println()
+		printBatchSummary(os.Stdout, summary, len(targets))
+	}
+
+	if ctx.Err() != nil {
+		os.Exit(130)
+	}
+}
+
+func printBatchResult(result gowebspy.BatchResult) {
+	if result.Err != nil {
+		color.New(color.FgHiRed).Printf("✗ %s: %v\n", result.Target, result.Err)
+		return
+	}
+
+	info := result.Info
+	color.New(color.FgHiGreen).Printf("✓ [%d] %s", info.StatusCode, info.URL)
+	fmt.Printf("  %s", info.ResponseTime.Round(time.Millisecond))
+	if info.ServerInfo != "" {
+		fmt.Printf("  %s", info.ServerInfo)
+	}
+	if title := strings.TrimSpace(info.Title); title != "" {
+		fmt.Printf("  %q", title)
+	}
+	fmt.Println()
+}
+
+func printBatchSummary(w io.Writer, summary gowebspy.BatchSummary, requested int) {
+	titleColor := color.New(color.FgHiCyan, color.Bold)
+	keyColor := color.New(color.FgHiYellow)
+
+	titleColor.Fprintln(w, "BATCH SUMMARY")
+	fmt.Fprintln(w, strings.Repeat("=", 50))
+
+	keyColor.Fprint(w, "Targets:        ")
+	fmt.Fprintf(w, "%d of %d scanned\n", summary.Total, requested)
+
+	keyColor.Fprint(w, "Succeeded:      ")
+	fmt.Fprintln(w, summary.Succeeded)
+
+	keyColor.Fprint(w, "Failed:         ")
+	fmt.Fprintln(w, summary.Failed)
+
+	keyColor.Fprint(w, "Matched Filter: ")
+	fmt.Fprintln(w, summary.Matched)
+
+	keyColor.Fprint(w, "Elapsed:        ")
+	fmt.Fprintln(w, summary.Elapsed.Round(time.Millisecond))
+}
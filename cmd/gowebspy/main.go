@@ -2,7 +2,10 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
+	"net"
 	"os"
 	"strconv"
 	"strings"
@@ -28,29 +31,135 @@ var (
 	filterServer string
 	filterHeader string
 	filterTime   string
+	filterPhase  []string
 	filterSSL    string
 	filterIP     string
 	filterRegex  string
+	timeout      time.Duration
+	inputFile    string
+	batchWorkers int
+	hostInterval time.Duration
+	portList     string
+	portTimeout  time.Duration
+	portWorkers  int
+	detectSvc    bool
+	traceUDP     bool
+	traceTCP     bool
+	tracePort    int
+	caBundle     string
+	tlsAudit     bool
+	startTLS     string
+	checkRevoke  bool
+	filterRevoke string
+	pslFile      string
+	noRDAP       bool
+	rdapFile     string
+	showOwners   bool
+	asnDBPath    string
+	geoIPFiles   []string
+	showRDNS     bool
+	dnsServer    string
+	dnsTypes     []string
+	dnsTCP       bool
+	dnsAuth      bool
+	showDNSSEC   bool
+	showEmail    bool
+	dkimSelector []string
+	propagation  bool
+	resolverList []string
+	subdomains   bool
+	wordlist     string
+	noAXFR       bool
+	noScan       bool
+	showRedirect bool
+	followRedir  bool
+	maxRedirects int
+	secHeaders   bool
+	showCookies  bool
+	filterCookie []string
+	cookieIssue  []string
+	cleanCookies bool
 )
 
+// probeOpts are the options of the main request, which the probes run
+// outside it, such as traceroute hop owners and --redirects without
+// --follow, derive theirs from.
+var probeOpts gowebspy.Options
+
+// geoDB holds the --geoip databases, or is nil when none were given.
+var geoDB *gowebspy.GeoDatabase
+
+// rdnsCache holds the PTR names looked up for --rdns, shared by every probe
+// and target, or is nil when --rdns is not given.
+var rdnsCache *gowebspy.ReverseDNSCache
+
+var commonPorts = []int{21, 22, 23, 25, 53, 80, 110, 143, 443, 465, 587, 993, 995, 3306, 5432, 8080, 8443}
+
 func init() {
 	rootCmd.Flags().BoolVarP(&showSSL, "ssl", "s", false, "Show SSL certificate information")
+	rootCmd.Flags().BoolVar(&tlsAudit, "tls-audit", false, "Enumerate TLS versions, cipher suites, curves and ALPN and flag weak settings")
+	rootCmd.Flags().StringVar(&startTLS, "starttls", "", "Only inspect TLS, upgrading with STARTTLS first (smtp, imap, pop3, ftp, ldap, postgres)")
+	rootCmd.Flags().BoolVar(&checkRevoke, "check-revocation", false, "Query the certificate's OCSP responder and CRLs (a stapled OCSP response is always checked)")
+	rootCmd.Flags().StringVar(&caBundle, "ca-bundle", "", "PEM file of CA certificates to verify against instead of the system roots")
 	rootCmd.Flags().BoolVarP(&showHeaders, "headers", "H", false, "Show HTTP headers")
+	rootCmd.Flags().BoolVar(&showCookies, "cookies", false, "Show each cookie's Secure, HttpOnly, SameSite, Domain, Path, expiry and size, and flag insecure settings")
+	rootCmd.Flags().BoolVar(&secHeaders, "security-headers", false, "Grade HSTS, CSP, X-Frame-Options, Referrer-Policy, Permissions-Policy and other security headers")
+	rootCmd.Flags().BoolVar(&showRedirect, "redirects", false, "Show the redirect chain with each hop's status, Location, timing and TLS")
+	rootCmd.Flags().BoolVarP(&followRedir, "follow", "L", false, "Follow redirects and report the final destination instead of the first response")
+	rootCmd.Flags().IntVar(&maxRedirects, "max-redirects", 10, "Number of redirects followed before giving up")
 	rootCmd.Flags().BoolVarP(&showWhois, "whois", "w", false, "Show WHOIS information")
+	rootCmd.Flags().BoolVar(&noRDAP, "no-rdap", false, "Query WHOIS directly instead of trying RDAP first")
+	rootCmd.Flags().StringVar(&rdapFile, "rdap-bootstrap", "", "IANA RDAP bootstrap file (dns.json) to use instead of the bundled partial registry and IANA download")
+	rootCmd.Flags().StringVar(&pslFile, "psl-file", "", "Public Suffix List file to use instead of the built-in copy when finding the registrable domain")
 	rootCmd.Flags().BoolVarP(&showDNS, "dns", "d", false, "Show DNS records")
+	rootCmd.Flags().BoolVar(&showDNSSEC, "dnssec", false, "Validate the DNSSEC chain of trust from the root and show signature expiry")
+	rootCmd.Flags().BoolVar(&showEmail, "email", false, "Grade the domain's SPF, DMARC, DKIM, MTA-STS, TLS-RPT and BIMI records")
+	rootCmd.Flags().StringSliceVar(&dkimSelector, "dkim-selector", nil, "DKIM selectors to look up instead of the common defaults (implies --email)")
+	rootCmd.Flags().BoolVar(&propagation, "propagation", false, "Only compare the domain's DNS records across public resolvers; exits 2 if they differ")
+	rootCmd.Flags().StringSliceVar(&resolverList, "resolver", nil, "Extra resolver for --propagation, as address or name=address; may be repeated")
+	rootCmd.Flags().StringVar(&dnsServer, "dns-server", "", "DNS resolver (host or host:port) to use instead of the system's, for every lookup")
+	rootCmd.Flags().StringSliceVar(&dnsTypes, "dns-type", nil, "Record types to query with TTLs, e.g. SOA,CAA,SRV,DS,DNSKEY,TLSA,HTTPS,NAPTR (implies --dns)")
+	rootCmd.Flags().BoolVar(&dnsTCP, "dns-tcp", false, "Send DNS queries over TCP (implies --dns)")
+	rootCmd.Flags().BoolVar(&dnsAuth, "dns-authoritative", false, "Ask the zone's authoritative name servers instead of a resolver (implies --dns)")
+	rootCmd.Flags().BoolVar(&showOwners, "owner", false, "Show the ASN, prefix, registry, country and abuse contact of each IP and traceroute hop")
+	rootCmd.Flags().StringSliceVar(&geoIPFiles, "geoip", nil, "MaxMind-format .mmdb file (e.g. GeoLite2-City, GeoLite2-ASN) to locate each IP and traceroute hop with; may be repeated")
+	rootCmd.Flags().BoolVar(&showRDNS, "rdns", false, "Look up the PTR name of each IP and traceroute hop and check it resolves back (FCrDNS)")
+	rootCmd.Flags().StringVar(&asnDBPath, "asn-db", "", "Offline IP-to-ASN database (iptoasn.com TSV or MRT RIB dump) to use for --owner instead of online lookups")
 	rootCmd.Flags().BoolVarP(&scanPorts, "ports", "p", false, "Scan common ports")
+	rootCmd.Flags().StringVar(&portList, "port-list", "", "Ports to scan, e.g. \"1-1024,8080,8443\" (implies --ports)")
+	rootCmd.Flags().DurationVar(&portTimeout, "port-timeout", 2*time.Second, "Connect timeout for each scanned port")
+	rootCmd.Flags().IntVar(&portWorkers, "port-concurrency", 100, "Number of ports scanned concurrently")
+	rootCmd.Flags().BoolVar(&detectSvc, "detect-services", false, "Identify the service and version behind each open port (implies --ports)")
 	rootCmd.Flags().BoolVarP(&formatJSON, "json", "j", false, "Output in JSON format")
 	rootCmd.Flags().BoolVarP(&traceRoute, "trace", "t", false, "Perform traceroute")
+	rootCmd.Flags().BoolVar(&traceUDP, "trace-udp", false, "Use UDP probes instead of ICMP echo for traceroute")
+	rootCmd.Flags().BoolVar(&traceTCP, "trace-tcp-fallback", false, "If traceroute fails, estimate the path with TTL-limited TCP connects instead")
+	rootCmd.Flags().IntVar(&tracePort, "trace-port", 443, "Destination port for the TCP path estimate")
 	rootCmd.Flags().BoolVarP(&allInfo, "all", "a", false, "Show all information")
 	
+	rootCmd.Flags().DurationVar(&timeout, "timeout", 10*time.Second, "Timeout for the HTTP request")
+	
+	rootCmd.Flags().BoolVar(&subdomains, "subdomains", false, "Find subdomains of the target by wordlist and zone transfer, then scan each like --input")
+	rootCmd.Flags().StringVar(&wordlist, "wordlist", "", "File of subdomain labels to try, one per line ('-' for stdin); implies --subdomains")
+	rootCmd.Flags().BoolVar(&noAXFR, "no-axfr", false, "Don't attempt zone transfers when finding subdomains")
+	rootCmd.Flags().BoolVar(&noScan, "no-scan", false, "Only list the subdomains found, without scanning them")
+	rootCmd.Flags().StringVarP(&inputFile, "input", "i", "", "Scan every target listed in a file, one per line ('-' for stdin)")
+	rootCmd.Flags().IntVar(&batchWorkers, "workers", 10, "Number of targets scanned concurrently in batch mode")
+	rootCmd.Flags().DurationVar(&hostInterval, "host-interval", time.Second, "Minimum delay between requests to the same host in batch mode")
+	
 	rootCmd.Flags().BoolVar(&useIPv6, "ipv6", false, "Prefer IPv6 for all operations")
 	rootCmd.Flags().BoolVar(&dualStack, "dual-stack", false, "Check both IPv4 and IPv6 support")
 	
 	rootCmd.Flags().StringVar(&filterStatus, "status", "", "Filter by status code (e.g. 200, 200-299)")
 	rootCmd.Flags().StringVar(&filterServer, "server", "", "Filter by server name (contains)")
 	rootCmd.Flags().StringVar(&filterHeader, "has-header", "", "Filter by header existence (e.g. 'Content-Security-Policy')")
+	rootCmd.Flags().StringSliceVar(&filterCookie, "has-cookie", nil, "Filter by cookie name being set")
+	rootCmd.Flags().StringSliceVar(&cookieIssue, "cookie-issue", nil, "Filter by a cookie having an issue, e.g. samesite-none-without-secure, or 'any'")
+	rootCmd.Flags().BoolVar(&cleanCookies, "secure-cookies", false, "Filter to sites where no cookie has an issue")
 	rootCmd.Flags().StringVar(&filterTime, "response-time", "", "Filter by response time (e.g. <500ms, >100ms)")
+	rootCmd.Flags().StringSliceVar(&filterPhase, "timing", nil, "Filter by request phase: dns, connect, tls, ttfb, transfer or total (e.g. ttfb<200ms,tls<100ms)")
 	rootCmd.Flags().StringVar(&filterSSL, "ssl-days", "", "Filter by SSL days remaining (e.g. >30)")
+	rootCmd.Flags().StringVar(&filterRevoke, "revocation", "", "Filter by certificate revocation status (good, revoked, unknown; implies --check-revocation)")
 	rootCmd.Flags().StringVar(&filterIP, "ip-contains", "", "Filter by IP address (contains)")
 	rootCmd.Flags().StringVar(&filterRegex, "regex", "", "Filter content by regex pattern")
 }
@@ -60,18 +169,49 @@ var rootCmd = &cobra.Command{
 	Short: "Get information about a website",
 	Long: `A CLI tool to retrieve comprehensive information about websites
 including DNS records, HTTP headers, SSL certificates, and more.`,
-	Args: cobra.ExactArgs(1),
+	Args: func(cmd *cobra.Command, args []string) error {
+		if inputFile != "" {
+			return cobra.NoArgs(cmd, args)
+		}
+		return cobra.ExactArgs(1)(cmd, args)
+	},
 	Run: func(cmd *cobra.Command, args []string) {
-		url := args[0]
-		
 		if allInfo {
 			showSSL = true
+			tlsAudit = true
 			showHeaders = true
+			showRedirect = true
+			secHeaders = true
+			showCookies = true
 			showWhois = true
 			showDNS = true
+			showDNSSEC = true
+			showEmail = true
 			scanPorts = true
 			traceRoute = true
 			dualStack = true
+			showOwners = true
+			showRDNS = true
+		}
+		
+		if portList != "" || detectSvc {
+			scanPorts = true
+		}
+		
+		if filterRevoke != "" {
+			checkRevoke = true
+		}
+		
+		if useRawDNS() {
+			showDNS = true
+		}
+		
+		if len(dkimSelector) > 0 {
+			showEmail = true
+		}
+		
+		if wordlist != "" {
+			subdomains = true
 		}
 		
 		filterOpts := gowebspy.NewFilterOptions()
@@ -92,10 +232,33 @@ including DNS records, HTTP headers, SSL certificates, and more.`,
 			parseTimeFilter(filterTime, filterOpts)
 		}
 		
+		filterOpts.CookieMustExist = filterCookie
+		filterOpts.CookiesMustBeClean = cleanCookies
+		if err := parseCookieIssues(cookieIssue, filterOpts); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		
+		for _, filter := range filterPhase {
+			if err := parsePhaseFilter(filter, filterOpts); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		
 		if filterSSL != "" {
 			parseSSLFilter(filterSSL, filterOpts)
 		}
 		
+		if filterRevoke != "" {
+			status := gowebspy.RevocationStatus(strings.ToLower(filterRevoke))
+			if status != gowebspy.RevocationGood && status != gowebspy.RevocationRevoked && status != gowebspy.RevocationUnknown {
+				fmt.Fprintf(os.Stderr, "Error: --revocation must be good, revoked or unknown\n")
+				os.Exit(1)
+			}
+			filterOpts.SSLRevocationStatus = status
+		}
+		
 		if filterIP != "" {
 			filterOpts.IPMustMatch = filterIP
 		}
@@ -108,9 +271,116 @@ including DNS records, HTTP headers, SSL certificates, and more.`,
 			filterOpts.RequireIPv6 = true
 		}
 		
-		info, err := gowebspy.GetWebsiteInfo(url)
+		opts := gowebspy.DefaultOptions()
+		opts.HTTPTimeout = timeout
+		opts.FollowRedirects = followRedir
+		opts.MaxRedirects = maxRedirects
+		opts.SkipSSL = !showSSL && filterSSL == "" && filterRevoke == ""
+		opts.CheckRevocation = checkRevoke
+		opts.SkipWhois = !showWhois
+		opts.SkipRDAP = noRDAP
+		opts.IPOwners = showOwners
+		if dnsServer != "" {
+			opts.Resolver = gowebspy.NewDNSResolver(dnsServer)
+		}
+		
+		if pslFile != "" {
+			list, err := gowebspy.LoadSuffixList(pslFile)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			opts.SuffixList = list
+		}
+		
+		if rdapFile != "" {
+			bootstrap, err := gowebspy.LoadRDAPBootstrap(rdapFile)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			opts.RDAPBootstrap = bootstrap
+		}
+		
+		if asnDBPath != "" {
+			db, err := gowebspy.LoadASNDatabase(asnDBPath)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			opts.ASNDatabase = db
+		}
+		
+		if len(geoIPFiles) > 0 {
+			db, err := gowebspy.LoadGeoDatabase(geoIPFiles...)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			defer db.Close()
+			opts.GeoDatabase = db
+			geoDB = db
+		}
+		
+		if showRDNS {
+			rdnsCache = gowebspy.NewReverseDNSCache(opts.Resolver)
+			opts.ReverseDNS = rdnsCache
+		}
+		
+		if caBundle != "" {
+			roots, err := gowebspy.LoadCABundle(caBundle)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			opts.RootCAs = roots
+		}
+		probeOpts = opts
+		
+		if propagation {
+			if inputFile != "" {
+				fmt.Fprintf(os.Stderr, "Error: --propagation cannot be combined with --input\n")
+				os.Exit(1)
+			}
+			runPropagation(args[0])
+			return
+		}
+		
+		if startTLS != "" {
+			protocol, err := gowebspy.ParseStartTLSProtocol(startTLS)
+			if err == nil && inputFile != "" {
+				err = fmt.Errorf("--starttls cannot be combined with --input")
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			runTLSOnly(args[0], protocol, opts)
+			return
+		}
+		
+		if subdomains {
+			if inputFile != "" {
+				fmt.Fprintf(os.Stderr, "Error: --subdomains cannot be combined with --input\n")
+				os.Exit(1)
+			}
+			runSubdomains(args[0], filterOpts, opts)
+			return
+		}
+		
+		if inputFile != "" {
+			runBatch(filterOpts, opts)
+			return
+		}
+		
+		url := args[0]
+		info, err := gowebspy.GetWebsiteInfoWithOptions(context.Background(), url, opts)
 		if err != nil {
-			fmt.Printf("Error: %v\n", err)
+			if formatJSON {
+				outputJSON(buildReport(url, info, err))
+			} else {
+				fmt.Printf("Error: %v\n", err)
+			}
 			os.Exit(1)
 		}
 		
@@ -120,20 +390,38 @@ including DNS records, HTTP headers, SSL certificates, and more.`,
 		}
 		
 		if formatJSON {
-			outputJSON(info)
+			outputJSON(buildReport(url, info, nil))
 			return
 		}
 		
+		printWarnings(info.Warnings)
 		printBasicInfo(info)
 		
+		if showRedirect {
+			chain, err := runRedirects(url, info)
+			printRedirects(chain, err)
+		}
+		
 		if showSSL && info.SSLInfo != nil {
 			printSSLInfo(info.SSLInfo)
 		}
 		
+		if tlsAudit {
+			printTLSAudit(url)
+		}
+		
 		if showHeaders {
 			printHeaders(info.Headers)
 		}
 		
+		if showCookies {
+			printCookies(info.Cookies)
+		}
+		
+		if secHeaders {
+			printSecurityHeaders(auditSecurityHeaders(info))
+		}
+		
 		if showWhois && info.WhoisInfo != nil {
 			printWhoisInfo(info.WhoisInfo)
 		}
@@ -142,20 +430,20 @@ including DNS records, HTTP headers, SSL certificates, and more.`,
 			printDNSRecords(url)
 		}
 		
+		if showDNSSEC {
+			printDNSSEC(url)
+		}
+		
+		if showEmail {
+			printEmailSecurity(url)
+		}
+		
 		if scanPorts {
-			if useIPv6 {
-				printPortScanIPv6(url)
-			} else {
-				printPortScan(url)
-			}
+			printPortScan(url, useIPv6)
 		}
 		
 		if traceRoute {
-			if useIPv6 {
-				printTracerouteIPv6(url)
-			} else {
-				printTraceroute(url)
-			}
+			printTraceroute(url, useIPv6)
 		}
 		
 		if dualStack {
@@ -208,6 +496,55 @@ func parseTimeFilter(filter string, opts *gowebspy.FilterOptions) {
 	}
 }
 
+// parsePhaseFilter reads a --timing filter such as "ttfb<200ms".
+func parsePhaseFilter(filter string, opts *gowebspy.FilterOptions) error {
+	index := strings.IndexAny(filter, "<>")
+	if index < 0 {
+		return fmt.Errorf("--timing %q: want phase<duration or phase>duration", filter)
+	}
+	phase, err := gowebspy.ParseTimingPhase(filter[:index])
+	if err != nil {
+		return err
+	}
+	duration, err := time.ParseDuration(strings.TrimSpace(filter[index+1:]))
+	if err != nil {
+		return fmt.Errorf("--timing %q: %w", filter, err)
+	}
+	
+	limits := &opts.MaxPhaseTime
+	if filter[index] == '>' {
+		limits = &opts.MinPhaseTime
+	}
+	if *limits == nil {
+		*limits = map[gowebspy.TimingPhase]time.Duration{}
+	}
+	(*limits)[phase] = duration
+	return nil
+}
+
+// parseCookieIssues reads --cookie-issue values, where "any" stands for
+// every issue.
+func parseCookieIssues(values []string, opts *gowebspy.FilterOptions) error {
+	for _, value := range values {
+		value = strings.ToLower(strings.TrimSpace(value))
+		if value == "any" {
+			opts.CookieIssues = append(opts.CookieIssues, gowebspy.CookieIssues...)
+			continue
+		}
+		known := false
+		for _, issue := range gowebspy.CookieIssues {
+			if string(issue) == value {
+				opts.CookieIssues = append(opts.CookieIssues, issue)
+				known = true
+			}
+		}
+		if !known {
+			return fmt.Errorf("unknown cookie issue %q", value)
+		}
+	}
+	return nil
+}
+
 func parseSSLFilter(filter string, opts *gowebspy.FilterOptions) {
 	if filter == "valid" {
 		opts.SSLMustBeValid = true
@@ -219,8 +556,111 @@ func parseSSLFilter(filter string, opts *gowebspy.FilterOptions) {
 	}
 }
 
-func outputJSON(info *gowebspy.WebsiteInfo) {
-	fmt.Println("JSON output would appear here")
+func newReport(url string, info *gowebspy.WebsiteInfo, err error) *gowebspy.Report {
+	report := gowebspy.NewReport(url, info, err)
+	
+	if !showSSL {
+		report.SSL = nil
+	}
+	
+	if !showWhois {
+		report.Whois = nil
+	}
+	
+	if secHeaders && info != nil && info.Headers != nil {
+		report.SetSecurityHeaders(auditSecurityHeaders(info))
+	}
+	
+	if !showHeaders && report.Website != nil {
+		report.Website.Headers = nil
+	}
+	
+	if !showCookies && report.Website != nil {
+		report.Website.Cookies = nil
+	}
+	
+	return report
+}
+
+func buildReport(url string, info *gowebspy.WebsiteInfo, err error) *gowebspy.Report {
+	report := newReport(url, info, err)
+	if err != nil {
+		return report
+	}
+	
+	host := extractDomain(url)
+	
+	if showRedirect && report.Redirects == nil {
+		report.SetRedirects(runRedirects(url, info))
+	}
+	
+	if showDNS && useRawDNS() {
+		responses, err := runDNSQueries(host)
+		report.SetDNSQueries(responses, err)
+	} else if showDNS {
+		records, err := gowebspy.GetDNSRecords(host)
+		if err == nil && useIPv6 {
+			ipv6Records, _ := gowebspy.GetIPv6DNSRecords(host)
+			for recordType, values := range ipv6Records {
+				records[recordType] = values
+			}
+		}
+		report.SetDNS(records, err)
+	}
+	
+	if showDNSSEC {
+		report.SetDNSSEC(runDNSSECCheck(host))
+	}
+	
+	if showEmail {
+		report.SetEmailSecurity(runEmailCheck(host))
+	}
+	
+	if tlsAudit {
+		audit, err := runTLSAudit(url)
+		report.SetTLSAudit(audit, err)
+	}
+	
+	if scanPorts {
+		results, err := runPortScan(host, useIPv6)
+		report.SetPortResults(results, scanTargetReverseDNS(host, useIPv6), useIPv6, err)
+	}
+	
+	if traceRoute {
+		hops, err := runTraceroute(host, useIPv6)
+		if showOwners {
+			if ownerErr := lookupHopOwners(hops); ownerErr != nil {
+				report.Warnings = append(report.Warnings, gowebspy.WarningEntry{Probe: gowebspy.ProbeOwner, Error: ownerErr.Error()})
+			}
+		}
+		geoDB.LocateHops(hops)
+		rdnsCache.ResolveHops(context.Background(), hops)
+		report.SetTraceroute(hops, useIPv6, err)
+	}
+	
+	if dualStack {
+		ipInfo, err := gowebspy.GetIPAddresses(host)
+		isDualStack := err == nil && len(ipInfo.IPv4Addresses) > 0 && len(ipInfo.IPv6Addresses) > 0
+		geoDB.LocateAddresses(ipInfo)
+		rdnsCache.ResolveAddresses(context.Background(), ipInfo)
+		report.SetDualStack(ipInfo, isDualStack, err)
+	}
+	
+	return report
+}
+
+func outputJSON(report *gowebspy.Report) {
+	if err := report.WriteJSON(os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing JSON: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func printWarnings(warnings []error) {
+	warningColor := color.New(color.FgYellow)
+	for _, warning := range warnings {
+		warningColor.Fprintf(os.Stderr, "Warning: %v\n", warning)
+	}
 }
 
 func printBasicInfo(info *gowebspy.WebsiteInfo) {
@@ -254,12 +694,157 @@ func printBasicInfo(info *gowebspy.WebsiteInfo) {
 	keyColor("Response Time:  ")
 	valueColor(info.ResponseTime)
 	
+	if info.Timing != nil {
+		printTiming(info.Timing)
+	}
+	
+	if chain := info.Redirects; chain != nil && chain.Redirects() > 0 {
+		keyColor("Redirected:     ")
+		valueColor(fmt.Sprintf("from %s (%d redirects)", chain.Hops[0].URL, chain.Redirects()))
+	}
+	
 	keyColor("IP Addresses:   ")
 	valueColor(strings.Join(info.IP, ", "))
 	
+	for _, address := range info.Addresses {
+		var details []string
+		if showOwners {
+			details = append(details, describeOwner(address.Owner))
+		}
+		if geoDB != nil {
+			details = append(details, describeGeo(address.Geo))
+		}
+		if rdnsCache != nil {
+			details = append(details, describeReverseDNS(address.ReverseDNS))
+		}
+		keyColor(fmt.Sprintf("  %-14s", address.IP))
+		valueColor(" " + strings.Join(details, "; "))
+	}
+	
 	fmt.Println()
 }
 
+// printTiming shows each phase of the request with a bar scaled to the total,
+// so the slow phase stands out.
+func printTiming(timing *gowebspy.HTTPTiming) {
+	keyColor := color.New(color.FgHiYellow).PrintFunc()
+	barColor := color.New(color.FgHiCyan).SprintFunc()
+	
+	phases := []struct {
+		name     string
+		duration time.Duration
+	}{
+		{"DNS lookup", timing.DNSLookup},
+		{"TCP connect", timing.TCPConnect},
+		{"TLS handshake", timing.TLSHandshake},
+		{"First byte", timing.TimeToFirstByte},
+		{"Transfer", timing.ContentTransfer},
+	}
+	for _, phase := range phases {
+		bar := 0
+		if timing.Total > 0 {
+			bar = int(30 * phase.duration / timing.Total)
+		}
+		keyColor(fmt.Sprintf("  %-14s", phase.name))
+		fmt.Printf("%10s  %s\n", phase.duration.Round(time.Microsecond), barColor(strings.Repeat("█", bar)))
+	}
+	keyColor(fmt.Sprintf("  %-14s", "Total"))
+	fmt.Printf("%10s  (%d bytes", timing.Total.Round(time.Microsecond), timing.BodySize)
+	if timing.Truncated {
+		fmt.Print(", truncated")
+	}
+	if timing.ReusedConnection {
+		fmt.Print(", reused connection")
+	}
+	fmt.Println(")")
+}
+
+// describeReverseDNS formats a PTR lookup, for example
+// "edge1.example.net (forward-confirmed)".
+func describeReverseDNS(result *gowebspy.ReverseDNS) string {
+	switch {
+	case result == nil:
+		return "no reverse DNS"
+	case result.Error != "":
+		return "reverse DNS failed: " + result.Error
+	case len(result.Names) == 0:
+		return "no PTR record"
+	case result.ForwardConfirmed:
+		return result.Name + " (forward-confirmed)"
+	default:
+		return result.Name + " (does not resolve back)"
+	}
+}
+
+// describeGeo formats a location on one line, for example
+// "London, England, United Kingdom (GB) at 51.5142, -0.0931 (±10 km), AS20712 Andrews & Arnold Ltd".
+func describeGeo(geo *gowebspy.GeoLocation) string {
+	if geo == nil {
+		return "location unknown"
+	}
+	
+	var place []string
+	for _, name := range []string{geo.City, geo.Region, geo.Country} {
+		if name != "" {
+			place = append(place, name)
+		}
+	}
+	description := strings.Join(place, ", ")
+	if geo.CountryCode != "" {
+		description = strings.TrimSpace(description + " (" + geo.CountryCode + ")")
+	}
+	if c := geo.Coordinates; c != nil {
+		description += fmt.Sprintf(" at %.4f, %.4f", c.Latitude, c.Longitude)
+		if c.AccuracyRadius > 0 {
+			description += fmt.Sprintf(" (±%d km)", c.AccuracyRadius)
+		}
+	}
+	if geo.ASN != 0 {
+		if description != "" {
+			description += ", "
+		}
+		description += fmt.Sprintf("AS%d %s", geo.ASN, geo.ASOrganization)
+	}
+	return strings.TrimSpace(description)
+}
+
+// describeOwner formats a network owner on one line, for example
+// "AS15169 GOOGLE, US - 8.8.8.0/24 (GOGL), ARIN, US, abuse: network-abuse@google.com".
+func describeOwner(owner *gowebspy.NetworkOwner) string {
+	if owner == nil {
+		return "unknown owner"
+	}
+	
+	description := "AS unknown"
+	if owner.ASN != 0 {
+		description = fmt.Sprintf("AS%d", owner.ASN)
+	}
+	if owner.ASName != "" {
+		description += " " + owner.ASName
+	}
+	
+	var details []string
+	if owner.Prefix != "" {
+		prefix := owner.Prefix
+		if owner.Network != "" {
+			prefix += " (" + owner.Network + ")"
+		}
+		details = append(details, prefix)
+	}
+	for _, detail := range []string{owner.RIR, owner.Country} {
+		if detail != "" {
+			details = append(details, detail)
+		}
+	}
+	if owner.AbuseEmail != "" {
+		details = append(details, "abuse: "+owner.AbuseEmail)
+	}
+	if len(details) > 0 {
+		description += " - " + strings.Join(details, ", ")
+	}
+	return description
+}
+
 func printSSLInfo(sslInfo *gowebspy.SSLInfo) {
 	titleColor := color.New(color.FgHiGreen, color.Bold).PrintlnFunc()
 	keyColor := color.New(color.FgHiYellow).PrintFunc()
@@ -268,6 +853,14 @@ func printSSLInfo(sslInfo *gowebspy.SSLInfo) {
 	titleColor("SSL CERTIFICATE INFORMATION")
 	fmt.Println(strings.Repeat("=", 50))
 	
+	if sslInfo.StartTLS != "" {
+		keyColor("Port:           ")
+		valueColor(fmt.Sprintf("%d (STARTTLS %s)", sslInfo.Port, sslInfo.StartTLS))
+	} else if sslInfo.Port != 0 && sslInfo.Port != 443 {
+		keyColor("Port:           ")
+		valueColor(strconv.Itoa(sslInfo.Port))
+	}
+	
 	keyColor("Common Name:    ")
 	valueColor(sslInfo.CommonName)
 	
@@ -277,10 +870,44 @@ func printSSLInfo(sslInfo *gowebspy.SSLInfo) {
 	keyColor("Valid:          ")
 	if sslInfo.Valid {
 		color.New(color.FgHiGreen).Println("Yes")
+	} else if v := sslInfo.Verification; v != nil && v.Reason != "" {
+		if !v.HostnameMatch && v.Reason != gowebspy.VerifyHostnameMismatch {
+			color.New(color.FgHiRed).Printf("No (%s, %s)\n", v.Reason, gowebspy.VerifyHostnameMismatch)
+		} else {
+			color.New(color.FgHiRed).Printf("No (%s)\n", v.Reason)
+		}
 	} else {
 		color.New(color.FgHiRed).Println("No")
 	}
 	
+	if rev := sslInfo.Revocation; rev != nil && (rev.Stapled || len(rev.Checks) > 0) {
+		keyColor("Revocation:     ")
+		switch rev.Status {
+		case gowebspy.RevocationGood:
+			color.New(color.FgHiGreen).Print(rev.Status)
+		case gowebspy.RevocationRevoked:
+			color.New(color.FgHiRed).Print(rev.Status)
+		default:
+			color.New(color.FgHiYellow).Print(rev.Status)
+		}
+		if rev.Stapled {
+			fmt.Println(" (OCSP stapled)")
+		} else {
+			fmt.Println(" (not stapled)")
+		}
+		for _, check := range rev.Checks {
+			source := check.Source
+			if check.URL != "" {
+				source += " " + check.URL
+			}
+			if check.Err != nil {
+				fmt.Printf("  %s: %s (%v)\n", source, check.Status, check.Err)
+			} else {
+				fmt.Printf("  %s: %s\n", source, check.Status)
+			}
+		}
+	}
+	
 	keyColor("Issued Date:    ")
 	valueColor(sslInfo.Issued.Format(time.RFC3339))
 	
@@ -309,37 +936,458 @@ func printSSLInfo(sslInfo *gowebspy.SSLInfo) {
 		valueColor("None")
 	}
 	
+	if len(sslInfo.Chain) > 0 {
+		fmt.Println()
+		keyColor("Certificate Chain:\n")
+		for i, cert := range sslInfo.Chain {
+			fmt.Printf("  [%d] %s\n", i, cert.Subject)
+			fmt.Printf("      Issuer:    %s\n", cert.Issuer)
+			fmt.Printf("      Serial:    %s\n", cert.SerialNumber)
+			fmt.Printf("      Key:       %s %d bits, signed with %s\n", cert.KeyType, cert.KeySize, cert.SignatureAlgorithm)
+			fmt.Printf("      Validity:  %s to %s\n", cert.NotBefore.Format(time.RFC3339), cert.NotAfter.Format(time.RFC3339))
+			fmt.Printf("      SHA-256:   %s\n", cert.SHA256Fingerprint)
+		}
+	}
+	
 	fmt.Println()
 }
 
-func printHeaders(headers map[string][]string) {
-	titleColor := color.New(color.FgHiMagenta, color.Bold).PrintlnFunc()
+func printTLSAudit(url string) {
+	titleColor := color.New(color.FgHiGreen, color.Bold).PrintlnFunc()
 	keyColor := color.New(color.FgHiYellow).PrintFunc()
-	valueColor := color.New(color.FgHiWhite).PrintlnFunc()
 	
-	titleColor("HTTP HEADERS")
+	titleColor("TLS CONFIGURATION AUDIT")
 	fmt.Println(strings.Repeat("=", 50))
 	
-	for name, values := range headers {
-		keyColor(name + ": ")
-		valueColor(strings.Join(values, ", "))
+	audit, err := runTLSAudit(url)
+	if err != nil {
+		fmt.Printf("Error auditing TLS: %v\n", err)
+		fmt.Println()
+		return
+	}
+	
+	for _, version := range audit.Versions {
+		keyColor(fmt.Sprintf("%-8s ", version.Name))
+		if !version.Supported {
+			fmt.Println("not supported")
+			continue
+		}
+		
+		switch version.Version {
+		case tls.VersionTLS10, tls.VersionTLS11:
+			color.New(color.FgHiRed).Print("supported (deprecated)")
+		default:
+			color.New(color.FgHiGreen).Print("supported")
+		}
+		if len(version.Ciphers) > 1 {
+			if version.ServerPreference {
+				fmt.Print(", server cipher order")
+			} else {
+				fmt.Print(", client cipher order")
+			}
+		}
+		fmt.Println()
+		
+		for _, cipher := range version.Ciphers {
+			note := ""
+			if !cipher.ForwardSecrecy {
+				note = " (no forward secrecy)"
+			}
+			if cipher.Weak {
+				color.New(color.FgHiRed).Printf("  %s%s\n", cipher.Name, note)
+			} else {
+				fmt.Printf("  %s%s\n", cipher.Name, note)
+			}
+		}
+		if len(version.Curves) > 0 {
+			fmt.Printf("  Curves: %s\n", strings.Join(version.Curves, ", "))
+		}
+	}
+	
+	keyColor("ALPN:     ")
+	if len(audit.ALPN) > 0 {
+		fmt.Println(strings.Join(audit.ALPN, ", "))
+	} else {
+		fmt.Println("none")
+	}
+	
+	keyColor("Weaknesses: ")
+	if len(audit.Weaknesses) == 0 {
+		color.New(color.FgHiGreen).Println("none found")
+	} else {
+		var names []string
+		for _, weakness := range audit.Weaknesses {
+			names = append(names, string(weakness))
+		}
+		color.New(color.FgHiRed).Println(strings.Join(names, ", "))
 	}
 	
 	fmt.Println()
 }
 
-func printWhoisInfo(whoisInfo *gowebspy.WhoisInfo) {
-	titleColor := color.New(color.FgHiBlue, color.Bold).PrintlnFunc()
-	keyColor := color.New(color.FgHiYellow).PrintFunc()
-	valueColor := color.New(color.FgHiWhite).PrintlnFunc()
-	
-	titleColor("WHOIS INFORMATION")
-	fmt.Println(strings.Repeat("=", 50))
+func runTLSAudit(url string) (*gowebspy.TLSAudit, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
 	
-	keyColor("Registrar:      ")
-	valueColor(whoisInfo.Registrar)
+	protocol, _ := gowebspy.ParseStartTLSProtocol(startTLS)
+	return gowebspy.AuditTLS(ctx, extractDomain(url), targetPort(url, protocol.DefaultPort()), gowebspy.TLSAuditOptions{
+		StartTLS: protocol,
+	})
+}
+
+// runTLSOnly inspects the certificate on a non-HTTP service such as a mail
+// server or database, skipping the HTTP request entirely.
+func runTLSOnly(target string, protocol gowebspy.StartTLSProtocol, opts gowebspy.Options) {
+	host := extractDomain(target)
+	port := targetPort(target, protocol.DefaultPort())
+	
+	sslInfo, err := gowebspy.GetTLSInfo(context.Background(), host, port, protocol, opts)
+	
+	if formatJSON {
+		report := gowebspy.NewReport(target, nil, nil)
+		report.SetSSL(sslInfo, err)
+		if tlsAudit {
+			audit, auditErr := runTLSAudit(target)
+			report.SetTLSAudit(audit, auditErr)
+		}
+		outputJSON(report)
+		if err != nil {
+			os.Exit(1)
+		}
+		return
+	}
 	
-	keyColor("Created Date:   ")
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	
+	printSSLInfo(sslInfo)
+	if tlsAudit {
+		printTLSAudit(target)
+	}
+}
+
+// runPropagation compares the target's records across resolvers and exits
+// with 0 when they all agree, 2 when they differ and 1 on error.
+func runPropagation(target string) {
+	domain := extractDomain(target)
+	
+	resolvers := append([]gowebspy.PropagationResolver{}, gowebspy.DefaultPropagationResolvers...)
+	for _, value := range resolverList {
+		resolver, err := gowebspy.ParsePropagationResolver(value)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		resolvers = append(resolvers, resolver)
+	}
+	
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	report, err := gowebspy.CheckPropagation(ctx, domain, gowebspy.PropagationOptions{
+		Resolvers: resolvers,
+		Types:     dnsTypes,
+		DNS:       gowebspy.DNSQueryOptions{TCP: dnsTCP},
+	})
+	cancel()
+	
+	if formatJSON {
+		jsonReport := gowebspy.NewReport(target, nil, nil)
+		jsonReport.SetPropagation(report, err)
+		outputJSON(jsonReport)
+	} else if err != nil {
+		fmt.Printf("Error: %v\n", err)
+	} else {
+		printPropagation(report)
+	}
+	
+	if err != nil {
+		os.Exit(1)
+	}
+	if !report.Consistent {
+		os.Exit(2)
+	}
+}
+
+func printPropagation(report *gowebspy.PropagationReport) {
+	titleColor := color.New(color.FgHiCyan, color.Bold).PrintlnFunc()
+	keyColor := color.New(color.FgHiYellow).PrintFunc()
+	valueColor := color.New(color.FgHiWhite).PrintlnFunc()
+	removed := color.New(color.FgHiRed).PrintfFunc()
+	added := color.New(color.FgHiGreen).PrintfFunc()
+	
+	titleColor("DNS PROPAGATION")
+	fmt.Println(strings.Repeat("=", 50))
+	
+	keyColor("Domain:    ")
+	valueColor(fmt.Sprintf("%s (%d resolvers)", report.Domain, len(report.Resolvers)))
+	fmt.Println()
+	
+	var differing []string
+	for _, record := range report.Records {
+		keyColor(fmt.Sprintf("%-6s", record.Type))
+		ttl := fmt.Sprintf("TTL %d", record.MinTTL)
+		if record.MaxTTL != record.MinTTL {
+			ttl = fmt.Sprintf("TTL %d-%d", record.MinTTL, record.MaxTTL)
+		}
+		if record.Consistent {
+			color.New(color.FgHiGreen).Printf("consistent")
+		} else {
+			color.New(color.FgHiRed).Printf("differs")
+			differing = append(differing, record.Type)
+		}
+		if len(record.Consensus) > 0 {
+			fmt.Printf(" (%s)", ttl)
+		}
+		fmt.Println()
+		
+		for _, value := range record.Consensus {
+			fmt.Printf("    %s\n", value)
+		}
+		if len(record.Consensus) == 0 {
+			fmt.Println("    (no records)")
+		}
+		
+		var agree []string
+		for _, answer := range record.Answers {
+			if answer.Matches() {
+				agree = append(agree, answer.Resolver.Name)
+			}
+		}
+		if !record.Consistent && len(agree) > 0 {
+			fmt.Printf("  = %s\n", strings.Join(agree, ", "))
+		}
+		
+		for _, answer := range record.Answers {
+			if answer.Matches() {
+				continue
+			}
+			fmt.Printf("  %s (%s):", answer.Resolver.Name, answer.Resolver.Address)
+			if answer.Error != "" {
+				color.New(color.FgRed).Printf(" %s\n", answer.Error)
+				continue
+			}
+			fmt.Printf(" TTL %d\n", answer.TTL)
+			for _, value := range answer.Missing {
+				removed("    - %s\n", value)
+			}
+			for _, value := range answer.Added {
+				added("    + %s\n", value)
+			}
+		}
+	}
+	
+	fmt.Println()
+	keyColor("Summary:   ")
+	if report.Consistent {
+		color.New(color.FgHiGreen).Println("All resolvers agree")
+	} else {
+		color.New(color.FgHiRed).Printf("Resolvers disagree on %s\n", strings.Join(differing, ", "))
+	}
+}
+
+// runRedirects returns the chain already followed for --follow, or follows
+// it now.
+func runRedirects(url string, info *gowebspy.WebsiteInfo) (*gowebspy.RedirectChain, error) {
+	if info != nil && info.Redirects != nil {
+		return info.Redirects, nil
+	}
+	return gowebspy.FollowRedirects(context.Background(), url, probeOpts.RedirectOptions())
+}
+
+func printRedirects(chain *gowebspy.RedirectChain, err error) {
+	titleColor := color.New(color.FgHiMagenta, color.Bold).PrintlnFunc()
+	keyColor := color.New(color.FgHiYellow).PrintFunc()
+	warnColor := color.New(color.FgHiRed).PrintlnFunc()
+	
+	titleColor("REDIRECT CHAIN")
+	fmt.Println(strings.Repeat("=", 50))
+	
+	if chain != nil {
+		for i, hop := range chain.Hops {
+			keyColor(fmt.Sprintf("[%d] %d ", i, hop.StatusCode))
+			fmt.Println(hop.URL)
+			if hop.Timing != nil {
+				fmt.Printf("    Time:     %s (first byte after %s)\n", hop.Timing.Total.Round(time.Microsecond), hop.Timing.TimeToFirstByte.Round(time.Microsecond))
+			}
+			if hop.TLS != nil {
+				fmt.Printf("    TLS:      %s, %s, %s\n", hop.TLS.Version, hop.TLS.CipherSuite, hop.TLS.Certificate.Subject)
+			}
+			if hop.Location != "" {
+				fmt.Printf("    Location: %s\n", hop.Location)
+			}
+			if hop.Downgrade {
+				warnColor("    Downgrades from HTTPS to HTTP")
+			}
+			if hop.CrossDomain {
+				fmt.Println("    Leaves the domain")
+			}
+			if showHeaders {
+				for name, values := range hop.Headers {
+					fmt.Printf("    %s: %s\n", name, strings.Join(values, ", "))
+				}
+			}
+		}
+	}
+	
+	if err != nil {
+		warnColor(fmt.Sprintf("Error: %v", err))
+	} else {
+		keyColor("Final URL: ")
+		fmt.Printf("%s (%d redirects)\n", chain.FinalURL, chain.Redirects())
+	}
+	
+	fmt.Println()
+}
+
+func printHeaders(headers map[string][]string) {
+	titleColor := color.New(color.FgHiMagenta, color.Bold).PrintlnFunc()
+	keyColor := color.New(color.FgHiYellow).PrintFunc()
+	valueColor := color.New(color.FgHiWhite).PrintlnFunc()
+	
+	titleColor("HTTP HEADERS")
+	fmt.Println(strings.Repeat("=", 50))
+	
+	for name, values := range headers {
+		keyColor(name + ": ")
+		valueColor(strings.Join(values, ", "))
+	}
+	
+	fmt.Println()
+}
+
+func printCookies(cookies []gowebspy.CookieInfo) {
+	titleColor := color.New(color.FgHiMagenta, color.Bold).PrintlnFunc()
+	keyColor := color.New(color.FgHiYellow).PrintFunc()
+	
+	titleColor("COOKIES")
+	fmt.Println(strings.Repeat("=", 50))
+	
+	if len(cookies) == 0 {
+		fmt.Println("No cookies set")
+		fmt.Println()
+		return
+	}
+	
+	for _, cookie := range cookies {
+		keyColor(cookie.Name)
+		fmt.Printf(" (%d bytes)\n", cookie.Size)
+		
+		var flags []string
+		if cookie.Secure {
+			flags = append(flags, "Secure")
+		}
+		if cookie.HttpOnly {
+			flags = append(flags, "HttpOnly")
+		}
+		if cookie.SameSite != "" {
+			flags = append(flags, "SameSite="+cookie.SameSite)
+		} else {
+			flags = append(flags, "SameSite unset (Lax)")
+		}
+		fmt.Printf("  Flags:   %s\n", strings.Join(flags, ", "))
+		
+		scope := cookie.Domain
+		if scope == "" {
+			scope = "host-only"
+		}
+		if cookie.Path != "" {
+			scope += ", path " + cookie.Path
+		}
+		fmt.Printf("  Scope:   %s\n", scope)
+		
+		if cookie.Session {
+			fmt.Println("  Expires: end of session")
+		} else {
+			fmt.Printf("  Expires: %s\n", cookie.Expires.Format(time.RFC3339))
+		}
+		
+		for _, issue := range cookie.Issues {
+			color.New(color.FgHiRed).Printf("  Issue:   %s\n", issue)
+		}
+	}
+	
+	fmt.Println()
+}
+
+func auditSecurityHeaders(info *gowebspy.WebsiteInfo) *gowebspy.SecurityHeaderReport {
+	return gowebspy.AuditSecurityHeaders(info.Headers, strings.HasPrefix(info.URL, "https://"))
+}
+
+func printSecurityHeaders(audit *gowebspy.SecurityHeaderReport) {
+	titleColor := color.New(color.FgHiMagenta, color.Bold).PrintlnFunc()
+	keyColor := color.New(color.FgHiYellow).PrintFunc()
+	valueColor := color.New(color.FgHiWhite).PrintlnFunc()
+	
+	titleColor("SECURITY HEADERS")
+	fmt.Println(strings.Repeat("=", 50))
+	
+	keyColor("Grade:          ")
+	gradeColor := color.New(color.FgHiGreen)
+	switch audit.Grade {
+	case "C", "D":
+		gradeColor = color.New(color.FgYellow)
+	case "F":
+		gradeColor = color.New(color.FgHiRed)
+	}
+	gradeColor.Printf("%s (%d/100)\n", audit.Grade, audit.Score)
+	
+	for _, result := range audit.Headers {
+		keyColor(fmt.Sprintf("%-30s %2d/%-2d ", result.Name+":", result.Score, result.MaxScore))
+		switch {
+		case result.Note != "" && result.Value == "":
+			valueColor(result.Note)
+		case result.Value == "":
+			color.New(color.FgYellow).Println("not set")
+		case result.Note != "":
+			valueColor(fmt.Sprintf("%s (%s)", result.Value, result.Note))
+		default:
+			valueColor(result.Value)
+		}
+		for _, e := range result.Errors {
+			color.New(color.FgHiRed).Printf("  Error: %s\n", e)
+		}
+		for _, warning := range result.Warnings {
+			color.New(color.FgYellow).Printf("  Warning: %s\n", warning)
+		}
+	}
+	
+	if audit.HSTS != nil && audit.HSTS.PreloadEligible {
+		color.New(color.FgHiGreen).Println("HSTS policy is eligible for the preload list")
+	}
+	
+	fmt.Println()
+}
+
+func printWhoisInfo(whoisInfo *gowebspy.WhoisInfo) {
+	titleColor := color.New(color.FgHiBlue, color.Bold).PrintlnFunc()
+	keyColor := color.New(color.FgHiYellow).PrintFunc()
+	valueColor := color.New(color.FgHiWhite).PrintlnFunc()
+	
+	titleColor("WHOIS INFORMATION")
+	fmt.Println(strings.Repeat("=", 50))
+	
+	if whoisInfo.Domain != "" {
+		keyColor("Domain:         ")
+		if display := gowebspy.DisplayHost(whoisInfo.Domain); display != whoisInfo.Domain {
+			valueColor(fmt.Sprintf("%s (%s)", display, whoisInfo.Domain))
+		} else {
+			valueColor(whoisInfo.Domain)
+		}
+	}
+	
+	if whoisInfo.Source == gowebspy.WhoisSourceRDAP {
+		keyColor("Source:         ")
+		valueColor("RDAP (" + whoisInfo.RDAP.URL + ")")
+	} else if whoisInfo.Source == gowebspy.WhoisSourceWhois {
+		keyColor("Source:         ")
+		valueColor("WHOIS")
+	}
+	
+	keyColor("Registrar:      ")
+	valueColor(whoisInfo.Registrar)
+	
+	keyColor("Created Date:   ")
 	valueColor(whoisInfo.CreatedDate)
 	
 	keyColor("Updated Date:   ")
@@ -354,9 +1402,45 @@ func printWhoisInfo(whoisInfo *gowebspy.WhoisInfo) {
 	keyColor("Domain Status:  ")
 	valueColor(strings.Join(whoisInfo.DomainStatus, ", "))
 	
+	if rdap := whoisInfo.RDAP; rdap != nil {
+		if rdap.DNSSEC != nil {
+			keyColor("DNSSEC:         ")
+			if rdap.DNSSEC.DelegationSigned {
+				valueColor(fmt.Sprintf("signed (%d DS record(s))", len(rdap.DNSSEC.DS)))
+			} else {
+				valueColor("unsigned")
+			}
+		}
+		
+		for _, entity := range rdap.Entities {
+			printRDAPEntity(entity, keyColor, valueColor, "")
+		}
+	}
+	
 	fmt.Println()
 }
 
+// printRDAPEntity prints an RDAP contact and the contacts nested in it, such
+// as the abuse contact of a registrar.
+func printRDAPEntity(entity gowebspy.RDAPEntity, keyColor func(a ...interface{}), valueColor func(a ...interface{}), indent string) {
+	var details []string
+	for _, detail := range []string{entity.Name, entity.Organization, entity.Email, entity.Phone} {
+		if detail != "" {
+			details = append(details, detail)
+		}
+	}
+	if len(details) == 0 {
+		details = append(details, entity.Handle)
+	}
+	
+	keyColor(fmt.Sprintf("%s%-15s ", indent, "Contact ("+strings.Join(entity.Roles, ", ")+"):"))
+	valueColor(strings.Join(details, ", "))
+	
+	for _, child := range entity.Entities {
+		printRDAPEntity(child, keyColor, valueColor, indent+"  ")
+	}
+}
+
 func printDNSRecords(domain string) {
 	domain = extractDomain(domain)
 	
@@ -367,6 +1451,11 @@ func printDNSRecords(domain string) {
 	titleColor("DNS RECORDS")
 	fmt.Println(strings.Repeat("=", 50))
 	
+	if useRawDNS() {
+		printDNSQueries(domain)
+		return
+	}
+	
 	records, err := gowebspy.GetDNSRecords(domain)
 	if err != nil {
 		fmt.Printf("Error retrieving DNS records: %v\n", err)
@@ -389,108 +1478,448 @@ func printDNSRecords(domain string) {
 	fmt.Println()
 }
 
-func printPortScan(host string) {
-	host = extractDomain(host)
+// useRawDNS reports whether any flag asks for the DNS client that shows TTLs
+// and the answering server, rather than the system resolver.
+func useRawDNS() bool {
+	return dnsServer != "" || len(dnsTypes) > 0 || dnsTCP || dnsAuth
+}
+
+func runDNSQueries(domain string) ([]*gowebspy.DNSResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
 	
-	titleColor := color.New(color.FgHiRed, color.Bold).PrintlnFunc()
+	opts := dnsQueryOptions()
+	opts.Authoritative = dnsAuth
+	return gowebspy.LookupDNS(ctx, domain, dnsTypes, opts)
+}
+
+func dnsQueryOptions() gowebspy.DNSQueryOptions {
+	opts := gowebspy.DNSQueryOptions{TCP: dnsTCP}
+	if dnsServer != "" {
+		opts.Servers = []string{dnsServer}
+	}
+	return opts
+}
+
+func runDNSSECCheck(domain string) (*gowebspy.DNSSECReport, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	return gowebspy.CheckDNSSEC(ctx, domain, gowebspy.DNSSECOptions{DNS: dnsQueryOptions()})
+}
+
+func printDNSSEC(domain string) {
+	domain = extractDomain(domain)
+	
+	titleColor := color.New(color.FgHiCyan, color.Bold).PrintlnFunc()
+	keyColor := color.New(color.FgHiYellow).PrintFunc()
+	valueColor := color.New(color.FgHiWhite).PrintlnFunc()
 	
-	titleColor("PORT SCAN (IPv4)")
+	titleColor("DNSSEC")
 	fmt.Println(strings.Repeat("=", 50))
 	
-	commonPorts := []int{21, 22, 23, 25, 53, 80, 110, 143, 443, 465, 587, 993, 995, 3306, 5432, 8080, 8443}
-	results := gowebspy.PortScan(host, commonPorts)
+	report, err := runDNSSECCheck(domain)
+	if err != nil {
+		color.New(color.FgRed).Printf("Error checking DNSSEC: %v\n", err)
+		fmt.Println()
+		return
+	}
 	
-	for port, open := range results {
-		portName := getPortName(port)
-		if open {
-			color.New(color.FgHiGreen).Printf("✓ Port %d (%s): Open\n", port, portName)
-		} else {
-			color.New(color.FgHiRed).Printf("✗ Port %d (%s): Closed\n", port, portName)
+	keyColor("Status:         ")
+	switch report.Status {
+	case gowebspy.DNSSECSecure:
+		color.New(color.FgHiGreen).Println("Secure (validated from the root)")
+	case gowebspy.DNSSECInsecure:
+		color.New(color.FgYellow).Println("Insecure (not signed)")
+	default:
+		color.New(color.FgHiRed).Println("Bogus (validation fails; validating resolvers return SERVFAIL)")
+	}
+	
+	if len(report.Algorithms) > 0 {
+		keyColor("Algorithms:     ")
+		valueColor(strings.Join(report.Algorithms, ", "))
+	}
+	if !report.EarliestExpiry.IsZero() {
+		keyColor("Next Expiry:    ")
+		valueColor(fmt.Sprintf("%s (in %s)", report.EarliestExpiry.Format(time.RFC3339),
+			time.Until(report.EarliestExpiry).Round(time.Hour)))
+	}
+	
+	for _, zone := range report.Zones {
+		keyColor(fmt.Sprintf("  %-14s", zone.Name))
+		var keys []string
+		for _, key := range zone.Keys {
+			kind := "ZSK"
+			if key.SecureEntryPoint {
+				kind = "KSK"
+			}
+			keys = append(keys, fmt.Sprintf("%s %d %s", kind, key.KeyTag, key.Algorithm))
+		}
+		if len(keys) == 0 {
+			keys = append(keys, "no keys")
 		}
+		valueColor(fmt.Sprintf(" %s: %s", zone.Status, strings.Join(keys, ", ")))
+		for _, sig := range zone.Signatures {
+			if !sig.Valid {
+				color.New(color.FgHiRed).Printf("    RRSIG %s %s (key %d): %s\n", sig.Owner, sig.Covers, sig.KeyTag, sig.Error)
+			}
+		}
+	}
+	
+	for _, problem := range report.Problems {
+		color.New(color.FgHiRed).Printf("Problem: %s\n", problem)
+	}
+	for _, warning := range report.Warnings {
+		color.New(color.FgYellow).Printf("Warning: %s\n", warning)
 	}
 	
 	fmt.Println()
 }
 
-func printPortScanIPv6(host string) {
-	host = extractDomain(host)
+func runEmailCheck(domain string) (*gowebspy.EmailSecurityReport, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
 	
-	titleColor := color.New(color.FgHiRed, color.Bold).PrintlnFunc()
+	opts := gowebspy.EmailSecurityOptions{
+		DNS:           dnsQueryOptions(),
+		DKIMSelectors: dkimSelector,
+		Timeout:       30 * time.Second,
+	}
+	return gowebspy.CheckEmailSecurity(ctx, domain, opts)
+}
+
+func printEmailSecurity(domain string) {
+	domain = extractDomain(domain)
 	
-	titleColor("PORT SCAN (IPv6)")
+	titleColor := color.New(color.FgHiCyan, color.Bold).PrintlnFunc()
+	keyColor := color.New(color.FgHiYellow).PrintFunc()
+	valueColor := color.New(color.FgHiWhite).PrintlnFunc()
+	
+	titleColor("EMAIL SECURITY")
 	fmt.Println(strings.Repeat("=", 50))
 	
-	commonPorts := []int{21, 22, 23, 25, 53, 80, 110, 143, 443, 465, 587, 993, 995, 3306, 5432, 8080, 8443}
-	results := gowebspy.PortScanIPv6(host, commonPorts)
+	report, err := runEmailCheck(domain)
+	if err != nil {
+		color.New(color.FgRed).Printf("Error checking email security: %v\n", err)
+		fmt.Println()
+		return
+	}
 	
-	for port, open := range results {
-		portName := getPortName(port)
-		if open {
-			color.New(color.FgHiGreen).Printf("✓ Port %d (%s): Open\n", port, portName)
-		} else {
-			color.New(color.FgHiRed).Printf("✗ Port %d (%s): Closed\n", port, portName)
+	keyColor("Grade:          ")
+	gradeColor := color.New(color.FgHiGreen)
+	switch report.Grade {
+	case "C", "D":
+		gradeColor = color.New(color.FgYellow)
+	case "F":
+		gradeColor = color.New(color.FgHiRed)
+	}
+	gradeColor.Printf("%s (%d/100)\n", report.Grade, report.Score)
+	
+	printRecord := func(name, record, summary string, errs, warnings []string) {
+		keyColor(fmt.Sprintf("%-16s", name+":"))
+		switch {
+		case record == "" && len(errs) == 0:
+			color.New(color.FgYellow).Println("not published")
+		case record == "":
+			color.New(color.FgHiRed).Println("missing")
+		case summary != "":
+			valueColor(summary)
+		default:
+			valueColor(record)
+		}
+		for _, e := range errs {
+			color.New(color.FgHiRed).Printf("  Error: %s\n", e)
+		}
+		for _, warning := range warnings {
+			color.New(color.FgYellow).Printf("  Warning: %s\n", warning)
 		}
 	}
 	
+	spf := report.SPF
+	printRecord("SPF", spf.Record, fmt.Sprintf("%s (%d DNS lookups)", spf.Record, spf.Lookups), spf.Errors, spf.Warnings)
+	
+	dmarc := report.DMARC
+	dmarcSummary := ""
+	if dmarc.Record != "" {
+		dmarcSummary = fmt.Sprintf("p=%s pct=%d at %s", dmarc.Policy, dmarc.Percent, dmarc.Domain)
+	}
+	printRecord("DMARC", dmarc.Record, dmarcSummary, dmarc.Errors, dmarc.Warnings)
+	
+	for _, dkim := range report.DKIM {
+		summary := fmt.Sprintf("%s %d-bit", dkim.KeyType, dkim.KeyBits)
+		switch {
+		case dkim.Record == "":
+			summary = ""
+		case dkim.Revoked:
+			summary = "revoked"
+		}
+		printRecord("DKIM "+dkim.Selector, dkim.Record, summary, dkim.Errors, dkim.Warnings)
+	}
+	
+	mtaSTS := report.MTASTS
+	mtaSTSSummary := ""
+	if mtaSTS.Policy != nil {
+		mtaSTSSummary = fmt.Sprintf("mode %s, mx %s, max_age %ds", mtaSTS.Policy.Mode,
+			strings.Join(mtaSTS.Policy.MX, ", "), mtaSTS.Policy.MaxAge)
+	}
+	printRecord("MTA-STS", mtaSTS.Record, mtaSTSSummary, mtaSTS.Errors, mtaSTS.Warnings)
+	printRecord("TLS-RPT", report.TLSRPT.Record, "", report.TLSRPT.Errors, report.TLSRPT.Warnings)
+	printRecord("BIMI", report.BIMI.Record, report.BIMI.Logo, report.BIMI.Errors, report.BIMI.Warnings)
+	
+	for _, warning := range report.Warnings {
+		color.New(color.FgYellow).Printf("Warning: %s\n", warning)
+	}
+	
 	fmt.Println()
 }
 
-func printTraceroute(host string) {
+func printDNSQueries(domain string) {
+	keyColor := color.New(color.FgHiYellow).PrintFunc()
+	valueColor := color.New(color.FgHiWhite).PrintlnFunc()
+	
+	responses, err := runDNSQueries(domain)
+	for _, response := range responses {
+		flags := []string{response.RCode}
+		if response.Authoritative {
+			flags = append(flags, "authoritative")
+		}
+		if response.AuthenticatedData {
+			flags = append(flags, "DNSSEC validated")
+		}
+		keyColor(response.Type + " Records: ")
+		valueColor(fmt.Sprintf("(from %s over %s in %s, %s)", response.Server, response.Protocol,
+			response.RTT.Round(time.Millisecond), strings.Join(flags, ", ")))
+		
+		for _, record := range response.Answer {
+			fmt.Printf("  %-30s %7d  %-6s %s\n", record.Name, record.TTL, record.Type, record.Value)
+		}
+		if len(response.Answer) == 0 {
+			fmt.Println("  (no records)")
+		}
+	}
+	
+	if err != nil {
+		color.New(color.FgRed).Printf("Error: %v\n", err)
+	}
+	
+	fmt.Println()
+}
+
+func printPortScan(host string, ipv6 bool) {
 	host = extractDomain(host)
 	
-	titleColor := color.New(color.FgHiCyan, color.Bold).PrintlnFunc()
+	titleColor := color.New(color.FgHiRed, color.Bold).PrintlnFunc()
 	
-	titleColor("TRACEROUTE (IPv4)")
+	if ipv6 {
+		titleColor("PORT SCAN (IPv6)")
+	} else {
+		titleColor("PORT SCAN (IPv4)")
+	}
 	fmt.Println(strings.Repeat("=", 50))
 	
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
+	// A scan of a bare address is easier to place with its name.
+	if result := scanTargetReverseDNS(host, ipv6); result != nil {
+		if result.IP == strings.Trim(host, "[]") {
+			fmt.Printf("Target: %s, %s\n", host, describeReverseDNS(result))
+		} else {
+			fmt.Printf("Target: %s (%s), %s\n", host, result.IP, describeReverseDNS(result))
+		}
+	}
 	
-	hops, err := gowebspy.SimpleTraceroute(ctx, host, 30)
+	results, err := runPortScan(host, ipv6)
 	if err != nil {
-		fmt.Printf("Error performing traceroute: %v\n", err)
+		fmt.Printf("Error scanning ports: %v\n", err)
+		fmt.Println()
 		return
 	}
 	
-	for _, hop := range hops {
-		if hop.Host != "" {
-			fmt.Printf("%2d  %s (%s)  %s\n", hop.Number, hop.IP, hop.Host, hop.RTT)
-		} else {
-			fmt.Printf("%2d  %s  %s\n", hop.Number, hop.IP, hop.RTT)
+	// Long range scans would drown the open ports in closed ones, so only
+	// list every port for short lists and summarise the rest.
+	listAll := len(results) <= len(commonPorts)*2
+	counts := map[gowebspy.PortState]int{}
+	
+	for _, result := range results {
+		counts[result.State]++
+		portName := getPortName(result.Port)
+		switch result.State {
+		case gowebspy.PortOpen:
+			color.New(color.FgHiGreen).Printf("✓ Port %d (%s): Open", result.Port, portName)
+			printService(result)
+			fmt.Println()
+		case gowebspy.PortFiltered:
+			if listAll {
+				color.New(color.FgHiYellow).Printf("? Port %d (%s): Filtered\n", result.Port, portName)
+			}
+		default:
+			if listAll {
+				color.New(color.FgHiRed).Printf("✗ Port %d (%s): Closed\n", result.Port, portName)
+			}
 		}
 	}
 	
+	if !listAll {
+		fmt.Printf("%d open, %d closed, %d filtered\n",
+			counts[gowebspy.PortOpen], counts[gowebspy.PortClosed], counts[gowebspy.PortFiltered])
+	}
+	
 	fmt.Println()
 }
 
-func printTracerouteIPv6(host string) {
+func printService(result gowebspy.PortResult) {
+	service := result.Service
+	if service == nil {
+		return
+	}
+	
+	description := service.Protocol
+	if service.TLS && service.Protocol != "tls" {
+		description += "/tls"
+	}
+	if service.Version != "" {
+		description += " " + service.Version
+	}
+	fmt.Printf("  [%s]", description)
+	
+	if service.Unexpected(result.Port) {
+		color.New(color.FgHiYellow).Printf(" unexpected service, expected %s", gowebspy.ExpectedProtocol(result.Port))
+	}
+}
+
+func runPortScan(host string, ipv6 bool) ([]gowebspy.PortResult, error) {
+	ports := commonPorts
+	if portList != "" {
+		parsed, err := gowebspy.ParsePortList(portList)
+		if err != nil {
+			return nil, err
+		}
+		ports = parsed
+	}
+	
+	return gowebspy.ScanPorts(context.Background(), host, ports, portScanOptions(ipv6))
+}
+
+func portScanOptions(ipv6 bool) gowebspy.ScanOptions {
+	opts := gowebspy.ScanOptions{
+		Timeout:        portTimeout,
+		Concurrency:    portWorkers,
+		Network:        "tcp4",
+		DetectServices: detectSvc,
+	}
+	if ipv6 {
+		opts.Network = "tcp6"
+	}
+	return opts
+}
+
+// scanTargetReverseDNS looks up the PTR name of the address a port scan of
+// host connects to, or returns nil without --rdns.
+func scanTargetReverseDNS(host string, ipv6 bool) *gowebspy.ReverseDNS {
+	if rdnsCache == nil {
+		return nil
+	}
+	ip, err := gowebspy.ResolveScanTarget(context.Background(), host, portScanOptions(ipv6))
+	if err != nil {
+		return nil
+	}
+	return rdnsCache.Lookup(context.Background(), ip)
+}
+
+func printTraceroute(host string, ipv6 bool) {
 	host = extractDomain(host)
 	
 	titleColor := color.New(color.FgHiCyan, color.Bold).PrintlnFunc()
 	
-	titleColor("TRACEROUTE (IPv6)")
+	if ipv6 {
+		titleColor("TRACEROUTE (IPv6)")
+	} else {
+		titleColor("TRACEROUTE (IPv4)")
+	}
 	fmt.Println(strings.Repeat("=", 50))
 	
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
-	
-	hops, err := gowebspy.TracerouteIPv6(ctx, host, 30)
+	hops, err := runTraceroute(host, ipv6)
 	if err != nil {
-		fmt.Printf("Error performing IPv6 traceroute: %v\n", err)
-		return
+		color.New(color.FgRed).Printf("Error performing traceroute: %v\n", err)
+		if errors.Is(err, gowebspy.ErrTraceroutePermission) && !traceTCP {
+			fmt.Println("Re-run with --trace-tcp-fallback for an unprivileged TCP connect estimate.")
+		}
 	}
 	
+	if len(hops) > 0 && hops[0].Estimated {
+		fmt.Println()
+		color.New(color.FgYellow, color.Bold).Printf("TCP CONNECT PATH ESTIMATE to port %d (not a real traceroute)\n", tracePort)
+		fmt.Println("Router addresses are unknown; only the hop count and timings are measured.")
+	}
+	
+	if showOwners {
+		if err := lookupHopOwners(hops); err != nil {
+			color.New(color.FgYellow).Fprintf(os.Stderr, "Warning: owner lookup: %v\n", err)
+		}
+	}
+	geoDB.LocateHops(hops)
+	rdnsCache.ResolveHops(context.Background(), hops)
+	
 	for _, hop := range hops {
+		if hop.Received == 0 {
+			fmt.Printf("%2d  *\n", hop.Number)
+			continue
+		}
+		
+		name := hop.IP
+		if name == "" {
+			name = "router (address unknown)"
+		}
 		if hop.Host != "" {
-			fmt.Printf("%2d  %s (%s)  %s\n", hop.Number, hop.IP, hop.Host, hop.RTT)
-		} else {
-			fmt.Printf("%2d  %s  %s\n", hop.Number, hop.IP, hop.RTT)
+			name = fmt.Sprintf("%s (%s)", hop.IP, hop.Host)
+		}
+		fmt.Printf("%2d  %s  %s  (min %s, max %s, loss %.0f%%)\n", hop.Number, name,
+			hop.AvgRTT.Round(time.Microsecond), hop.MinRTT.Round(time.Microsecond),
+			hop.MaxRTT.Round(time.Microsecond), hop.Loss*100)
+		if hop.ReverseDNS != nil && len(hop.ReverseDNS.Names) > 0 && !hop.ReverseDNS.ForwardConfirmed {
+			fmt.Printf("    %s\n", describeReverseDNS(hop.ReverseDNS))
+		}
+		if hop.Owner != nil {
+			fmt.Printf("    %s\n", describeOwner(hop.Owner))
+		}
+		if hop.Geo != nil {
+			fmt.Printf("    %s\n", describeGeo(hop.Geo))
 		}
 	}
 	
 	fmt.Println()
 }
 
+// runTraceroute returns the real trace, or when that fails and
+// --trace-tcp-fallback is set, a TCP connect estimate together with the
+// error that explains why the real trace failed.
+func runTraceroute(host string, ipv6 bool) ([]gowebspy.TracerouteHop, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+	
+	opts := gowebspy.TracerouteOptions{
+		MaxHops: 30,
+		UDP:     traceUDP,
+		IPv6:    ipv6,
+	}
+	
+	hops, err := gowebspy.Traceroute(ctx, host, opts)
+	
+	var traceErr *gowebspy.TracerouteError
+	if err == nil || !traceTCP || (errors.As(err, &traceErr) && traceErr.Reason == gowebspy.TracerouteReasonResolve) {
+		return hops, err
+	}
+	
+	estimate, estimateErr := gowebspy.EstimatePathTCP(ctx, host, tracePort, opts)
+	if estimateErr != nil {
+		return estimate, fmt.Errorf("%w (TCP estimate also failed: %v)", err, estimateErr)
+	}
+	return estimate, err
+}
+
+func lookupHopOwners(hops []gowebspy.TracerouteHop) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	return gowebspy.LookupHopOwners(ctx, hops, probeOpts.OwnerOptions())
+}
+
 func printDualStackSupport(domain string) {
 	domain = extractDomain(domain)
 	
@@ -506,11 +1935,14 @@ func printDualStackSupport(domain string) {
 	}
 	
 	ipInfo, _ := gowebspy.GetIPAddresses(domain)
+	geoDB.LocateAddresses(ipInfo)
+	rdnsCache.ResolveAddresses(context.Background(), ipInfo)
 	
 	fmt.Printf("IPv4 Support: ")
 	if len(ipInfo.IPv4Addresses) > 0 {
 		color.New(color.FgHiGreen).Println("Yes")
 		fmt.Printf("IPv4 Addresses: %s\n", strings.Join(ipInfo.IPv4Addresses, ", "))
+		printAddressDetails(ipInfo.IPv4Addresses, ipInfo)
 	} else {
 		color.New(color.FgHiRed).Println("No")
 	}
@@ -519,6 +1951,7 @@ func printDualStackSupport(domain string) {
 	if len(ipInfo.IPv6Addresses) > 0 {
 		color.New(color.FgHiGreen).Println("Yes")
 		fmt.Printf("IPv6 Addresses: %s\n", strings.Join(ipInfo.IPv6Addresses, ", "))
+		printAddressDetails(ipInfo.IPv6Addresses, ipInfo)
 	} else {
 		color.New(color.FgHiRed).Println("No")
 	}
@@ -533,11 +1966,51 @@ func printDualStackSupport(domain string) {
 	fmt.Println()
 }
 
+func printAddressDetails(ips []string, ipInfo *gowebspy.IPAddressInfo) {
+	for _, ip := range ips {
+		var details []string
+		if geoDB != nil {
+			details = append(details, describeGeo(ipInfo.Locations[ip]))
+		}
+		if rdnsCache != nil {
+			details = append(details, describeReverseDNS(ipInfo.ReverseDNS[ip]))
+		}
+		if len(details) > 0 {
+			fmt.Printf("  %-14s %s\n", ip, strings.Join(details, "; "))
+		}
+	}
+}
+
+// extractDomain returns the host of urlStr in its normalized ASCII form, so
+// internationalized names can be passed to the resolver as they are.
 func extractDomain(urlStr string) string {
 	urlStr = strings.TrimPrefix(urlStr, "http://")
 	urlStr = strings.TrimPrefix(urlStr, "https://")
 	domainPart := strings.Split(urlStr, "/")[0]
-	return strings.Split(domainPart, ":")[0]
+	if host, _, err := net.SplitHostPort(domainPart); err == nil {
+		domainPart = host
+	} else if !strings.HasPrefix(domainPart, "[") && strings.Count(domainPart, ":") == 1 {
+		domainPart = strings.Split(domainPart, ":")[0]
+	}
+	
+	host, err := gowebspy.NormalizeHost(domainPart)
+	if err != nil {
+		return domainPart
+	}
+	return host
+}
+
+// targetPort returns the port given in the URL, or fallback if there is none.
+func targetPort(urlStr string, fallback int) int {
+	urlStr = strings.TrimPrefix(urlStr, "http://")
+	urlStr = strings.TrimPrefix(urlStr, "https://")
+	domainPart := strings.Split(urlStr, "/")[0]
+	if _, portStr, err := net.SplitHostPort(domainPart); err == nil {
+		if port, err := strconv.Atoi(portStr); err == nil {
+			return port
+		}
+	}
+	return fallback
 }
 
 func getPortName(port int) string {
@@ -51,6 +51,8 @@ func TestExtractDomain(t *testing.T) {
 		{"http://sub.domain.com", "sub.domain.com"},
 		{"domain.com", "domain.com"},
 		{"https://domain.com:8080/path?query=1", "domain.com"},
+		{"https://Bücher.example/", "xn--bcher-kva.example"},
+		{"https://[2001:db8::1]:8443/", "2001:db8::1"},
 	}
 
 	for _, test := range tests {
@@ -60,3 +62,22 @@ func TestExtractDomain(t *testing.T) {
 		}
 	}
 }
+
+func TestTargetPort(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int
+	}{
+		{"https://example.com/path", 443},
+		{"example.com:8443", 8443},
+		{"https://domain.com:8080/path?query=1", 8080},
+		{"https://[2001:db8::1]:9443/", 9443},
+	}
+
+	for _, test := range tests {
+		result := targetPort(test.input, 443)
+		if result != test.expected {
+			t.Errorf("targetPort(%q) = %d, want %d", test.input, result, test.expected)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/ArjunSharda/gowebspy/pkg/gowebspy"
+	"github.com/fatih/color"
+)
+
+// runSubdomains enumerates the subdomains of target and, unless --no-scan is
+// given, scans each one found as if it had been listed in --input.
+func runSubdomains(target string, filterOpts *gowebspy.FilterOptions, opts gowebspy.Options) {
+	domain := extractDomain(target)
+
+	subOpts := gowebspy.SubdomainOptions{
+		DNS:              dnsQueryOptions(),
+		SkipZoneTransfer: noAXFR,
+	}
+	if wordlist != "" {
+		words, err := readTargets(wordlist)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading wordlist: %v\n", err)
+			os.Exit(1)
+		}
+		subOpts.Words = words
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	report, err := gowebspy.EnumerateSubdomains(ctx, domain, subOpts)
+	stop()
+
+	if formatJSON {
+		// One line per document, like the batch results that follow.
+		jsonReport := gowebspy.NewReport(target, nil, nil)
+		jsonReport.SetSubdomains(report, err)
+		if encodeErr := json.NewEncoder(os.Stdout).Encode(jsonReport); encodeErr != nil {
+			fmt.Fprintf(os.Stderr, "Error writing JSON: %v\n", encodeErr)
+			os.Exit(1)
+		}
+	} else if err == nil {
+		printSubdomains(report)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if noScan || len(report.Subdomains) == 0 {
+		return
+	}
+	if !formatJSON {
+		fmt.Println()
+	}
+	scanTargets(report.Names(), filterOpts, opts)
+}
+
+func printSubdomains(report *gowebspy.SubdomainReport) {
+	titleColor := color.New(color.FgHiCyan, color.Bold).PrintlnFunc()
+	keyColor := color.New(color.FgHiYellow).PrintFunc()
+
+	titleColor("SUBDOMAINS")
+	fmt.Println(strings.Repeat("=", 50))
+
+	for _, transfer := range report.ZoneTransfers {
+		keyColor("Zone transfer:  ")
+		switch {
+		case transfer.Allowed:
+			color.New(color.FgHiRed).Printf("%s allows AXFR (%d records)\n", transfer.Server, transfer.Records)
+		case transfer.Server != "":
+			color.New(color.FgHiGreen).Printf("%s refused\n", transfer.Server)
+		default:
+			color.New(color.FgYellow).Printf("not attempted: %s\n", transfer.Error)
+		}
+	}
+	if len(report.Wildcard) > 0 {
+		keyColor("Wildcard:       ")
+		color.New(color.FgYellow).Printf("any name resolves to %s; those answers are ignored\n", strings.Join(report.Wildcard, ", "))
+	}
+
+	keyColor("Found:          ")
+	fmt.Printf("%d subdomains of %s\n", len(report.Subdomains), report.Domain)
+	for _, subdomain := range report.Subdomains {
+		fmt.Printf("  %-40s %s", subdomain.Name, strings.Join(subdomain.Addresses, ", "))
+		if subdomain.CNAME != "" {
+			fmt.Printf(" (CNAME %s)", subdomain.CNAME)
+		}
+		color.New(color.FgHiBlack).Printf("  [%s]\n", strings.Join(subdomain.Sources, ", "))
+	}
+}
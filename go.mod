@@ -7,7 +7,11 @@ require (
 	github.com/fatih/color v1.18.0
 	github.com/likexian/whois v1.15.6
 	github.com/likexian/whois-parser v1.24.20
+	github.com/miekg/dns v1.1.62
+	github.com/oschwald/maxminddb-golang v1.13.1
 	github.com/spf13/cobra v1.9.1
+	golang.org/x/crypto v0.36.0
+	golang.org/x/net v0.37.0
 )
 
 require (
@@ -17,7 +21,9 @@ require (
 	github.com/mattn/go-colorable v0.1.14 // indirect
 	github.com/mattn/go-isatty v0.0.20 // indirect
 	github.com/spf13/pflag v1.0.6 // indirect
-	golang.org/x/net v0.37.0 // indirect
+	golang.org/x/mod v0.18.0 // indirect
+	golang.org/x/sync v0.12.0 // indirect
 	golang.org/x/sys v0.31.0 // indirect
 	golang.org/x/text v0.23.0 // indirect
+	golang.org/x/tools v0.22.0 // indirect
 )
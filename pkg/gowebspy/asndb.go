@@ -0,0 +1,326 @@
+package gowebspy
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/netip"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MRT record types and TABLE_DUMP_V2 subtypes (RFC 6396) that carry routes.
+const (
+	mrtTableDumpV2     = 13
+	mrtRIBIPv4Unicast  = 2
+	mrtRIBIPv6Unicast  = 4
+	maxMRTRecordLength = 16 << 20
+
+	bgpAttrASPath      = 2
+	bgpAttrExtendedLen = 0x10
+	bgpASPathSet       = 1
+	bgpASPathSequence  = 2
+)
+
+// ASNDatabase maps addresses to the autonomous system announcing them, for
+// looking up network owners without any queries. See LoadASNDatabase.
+type ASNDatabase struct {
+	// ranges come from range based files, sorted by start address.
+	ranges []asnRange
+	// origins come from routing table dumps: the origin AS of each prefix.
+	origins map[netip.Prefix]uint32
+}
+
+type asnRange struct {
+	start   netip.Addr
+	end     netip.Addr
+	asn     uint32
+	country string
+	name    string
+}
+
+// LoadASNDatabase reads an offline IP-to-ASN database. Two formats are
+// understood, optionally gzip or bzip2 compressed:
+//
+//   - the tab separated ip2asn files from iptoasn.com, with lines of
+//     range start, range end, AS number, country code and AS name;
+//   - MRT TABLE_DUMP_V2 routing table dumps such as the RIB files published
+//     by RouteViews and RIPE RIS. These give the announced prefix and origin
+//     AS but no AS names or countries.
+func LoadASNDatabase(path string) (*ASNDatabase, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ASN database: %w", err)
+	}
+	defer file.Close()
+	return ParseASNDatabase(file)
+}
+
+// ParseASNDatabase is LoadASNDatabase for an already open file.
+func ParseASNDatabase(r io.Reader) (*ASNDatabase, error) {
+	reader := bufio.NewReader(r)
+	magic, _ := reader.Peek(3)
+	switch {
+	case bytes.HasPrefix(magic, []byte{0x1f, 0x8b}):
+		gz, err := gzip.NewReader(reader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read ASN database: %w", err)
+		}
+		defer gz.Close()
+		reader = bufio.NewReader(gz)
+	case bytes.Equal(magic, []byte("BZh")):
+		reader = bufio.NewReader(bzip2.NewReader(reader))
+	}
+
+	db := &ASNDatabase{}
+	var err error
+	if header, _ := reader.Peek(6); len(header) == 6 && binary.BigEndian.Uint16(header[4:]) == mrtTableDumpV2 {
+		err = db.readMRT(reader)
+	} else {
+		err = db.readRanges(reader)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ASN database: %w", err)
+	}
+	if len(db.ranges) == 0 && len(db.origins) == 0 {
+		return nil, errors.New("ASN database contains no routes")
+	}
+	return db, nil
+}
+
+func (db *ASNDatabase) readRanges(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fields := strings.Split(text, "\t")
+		if len(fields) < 3 {
+			return fmt.Errorf("line %d: expected at least 3 tab separated fields", line)
+		}
+		start, err := netip.ParseAddr(fields[0])
+		if err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+		end, err := netip.ParseAddr(fields[1])
+		if err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+		asn, err := strconv.ParseUint(strings.TrimPrefix(fields[2], "AS"), 10, 32)
+		if err != nil {
+			return fmt.Errorf("line %d: invalid AS number %q", line, fields[2])
+		}
+
+		entry := asnRange{start: start.Unmap(), end: end.Unmap(), asn: uint32(asn)}
+		if len(fields) > 3 && fields[3] != "None" {
+			entry.country = fields[3]
+		}
+		if len(fields) > 4 && fields[4] != "Not routed" {
+			entry.name = fields[4]
+		}
+		db.ranges = append(db.ranges, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	sort.Slice(db.ranges, func(i, j int) bool {
+		return db.ranges[i].start.Less(db.ranges[j].start)
+	})
+	return nil
+}
+
+func (db *ASNDatabase) readMRT(r io.Reader) error {
+	db.origins = map[netip.Prefix]uint32{}
+	header := make([]byte, 12)
+	for {
+		if _, err := io.ReadFull(r, header); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("truncated MRT record: %w", err)
+		}
+		recordType := binary.BigEndian.Uint16(header[4:])
+		subtype := binary.BigEndian.Uint16(header[6:])
+		length := binary.BigEndian.Uint32(header[8:])
+		if length > maxMRTRecordLength {
+			return fmt.Errorf("MRT record of %d bytes is too large", length)
+		}
+
+		body := make([]byte, length)
+		if _, err := io.ReadFull(r, body); err != nil {
+			return fmt.Errorf("truncated MRT record: %w", err)
+		}
+		if recordType != mrtTableDumpV2 || (subtype != mrtRIBIPv4Unicast && subtype != mrtRIBIPv6Unicast) {
+			continue
+		}
+
+		prefix, origin, err := parseRIBEntry(body, subtype == mrtRIBIPv6Unicast)
+		if err != nil {
+			return err
+		}
+		if origin != 0 {
+			db.origins[prefix] = origin
+		}
+	}
+}
+
+// parseRIBEntry returns the prefix of a RIB_IPV4_UNICAST or RIB_IPV6_UNICAST
+// record and the origin AS seen by its first peer.
+func parseRIBEntry(body []byte, ipv6 bool) (netip.Prefix, uint32, error) {
+	errTruncated := errors.New("truncated MRT RIB entry")
+
+	// Sequence number, then the prefix length and as many bytes as needed.
+	if len(body) < 5 {
+		return netip.Prefix{}, 0, errTruncated
+	}
+	bits := int(body[4])
+	size := (bits + 7) / 8
+	addrLen := 4
+	if ipv6 {
+		addrLen = 16
+	}
+	if bits > addrLen*8 || len(body) < 5+size+2 {
+		return netip.Prefix{}, 0, errTruncated
+	}
+	raw := make([]byte, addrLen)
+	copy(raw, body[5:5+size])
+	addr, _ := netip.AddrFromSlice(raw)
+	prefix := netip.PrefixFrom(addr, bits).Masked()
+
+	rest := body[5+size:]
+	if binary.BigEndian.Uint16(rest) == 0 {
+		return prefix, 0, nil
+	}
+	// First entry: peer index, originated time, attribute length.
+	rest = rest[2:]
+	if len(rest) < 8 {
+		return prefix, 0, errTruncated
+	}
+	attrLen := int(binary.BigEndian.Uint16(rest[6:]))
+	rest = rest[8:]
+	if len(rest) < attrLen {
+		return prefix, 0, errTruncated
+	}
+	return prefix, originAS(rest[:attrLen]), nil
+}
+
+// originAS returns the last AS of the AS_PATH attribute, which is always
+// encoded with four byte AS numbers in TABLE_DUMP_V2. It returns 0 when there
+// is no AS_PATH.
+func originAS(attrs []byte) uint32 {
+	for len(attrs) >= 3 {
+		flags, attrType := attrs[0], attrs[1]
+		var length, offset int
+		if flags&bgpAttrExtendedLen != 0 {
+			if len(attrs) < 4 {
+				return 0
+			}
+			length, offset = int(binary.BigEndian.Uint16(attrs[2:])), 4
+		} else {
+			length, offset = int(attrs[2]), 3
+		}
+		if len(attrs) < offset+length {
+			return 0
+		}
+		value := attrs[offset : offset+length]
+		attrs = attrs[offset+length:]
+		if attrType != bgpAttrASPath {
+			continue
+		}
+
+		var origin uint32
+		for len(value) >= 2 {
+			segmentType, count := value[0], int(value[1])
+			value = value[2:]
+			if len(value) < count*4 || count == 0 {
+				break
+			}
+			switch segmentType {
+			case bgpASPathSequence:
+				origin = binary.BigEndian.Uint32(value[(count-1)*4:])
+			case bgpASPathSet:
+				// An aggregate has no single origin; take the first member.
+				origin = binary.BigEndian.Uint32(value)
+			}
+			value = value[count*4:]
+		}
+		return origin
+	}
+	return 0
+}
+
+// Lookup returns what the database knows about the network of ip. The
+// second result is false for addresses it has no route for.
+func (db *ASNDatabase) Lookup(ip string) (*NetworkOwner, bool) {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return nil, false
+	}
+	addr = addr.Unmap()
+
+	if db.origins != nil {
+		for bits := addr.BitLen(); bits >= 0; bits-- {
+			prefix, _ := addr.Prefix(bits)
+			if asn, ok := db.origins[prefix]; ok {
+				return &NetworkOwner{IP: ip, ASN: asn, Prefix: prefix.String(), Sources: []string{OwnerSourceDatabase}}, true
+			}
+		}
+	}
+
+	i := sort.Search(len(db.ranges), func(i int) bool {
+		return addr.Less(db.ranges[i].start)
+	}) - 1
+	if i < 0 || db.ranges[i].end.Less(addr) || db.ranges[i].asn == 0 {
+		return nil, false
+	}
+	entry := db.ranges[i]
+	return &NetworkOwner{
+		IP:      ip,
+		ASN:     entry.asn,
+		ASName:  entry.name,
+		Prefix:  rangeString(entry.start, entry.end),
+		Country: entry.country,
+		Sources: []string{OwnerSourceDatabase},
+	}, true
+}
+
+// rangeString returns start-end as a prefix when it is exactly one.
+func rangeString(start, end netip.Addr) string {
+	for bits := start.BitLen(); bits >= 0; bits-- {
+		prefix := netip.PrefixFrom(start, bits)
+		if prefix.Masked().Addr() != start {
+			break
+		}
+		if lastAddr(prefix) == end {
+			return prefix.String()
+		}
+	}
+	return start.String() + "-" + end.String()
+}
+
+func lastAddr(prefix netip.Prefix) netip.Addr {
+	raw := prefix.Masked().Addr().AsSlice()
+	for i := range raw {
+		hostBits := prefix.Bits() - i*8
+		switch {
+		case hostBits <= 0:
+			raw[i] = 0xff
+		case hostBits < 8:
+			raw[i] |= 0xff >> hostBits
+		}
+	}
+	addr, _ := netip.AddrFromSlice(raw)
+	return addr
+}
@@ -0,0 +1,131 @@
+package gowebspy
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"net/netip"
+	"strings"
+	"testing"
+)
+
+const testASNRanges = "1.0.0.0\t1.0.0.255\t13335\tUS\tCLOUDFLARENET\n" +
+	"1.0.1.0\t1.0.3.255\t0\tNone\tNot routed\n" +
+	"8.8.8.0\t8.8.8.255\t15169\tUS\tGOOGLE\n" +
+	"9.9.9.0\t9.9.9.9\t19281\tUS\tQUAD9-AS-1\n" +
+	"2001:4860::\t2001:4860:ffff:ffff:ffff:ffff:ffff:ffff\t15169\tUS\tGOOGLE\n"
+
+func TestASNDatabaseRanges(t *testing.T) {
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	gz.Write([]byte(testASNRanges))
+	gz.Close()
+
+	for name, data := range map[string][]byte{"plain": []byte(testASNRanges), "gzip": compressed.Bytes()} {
+		t.Run(name, func(t *testing.T) {
+			db, err := ParseASNDatabase(bytes.NewReader(data))
+			if err != nil {
+				t.Fatalf("ParseASNDatabase failed: %v", err)
+			}
+
+			tests := []struct {
+				ip     string
+				asn    uint32
+				prefix string
+				found  bool
+			}{
+				{"8.8.8.8", 15169, "8.8.8.0/24", true},
+				{"1.0.0.1", 13335, "1.0.0.0/24", true},
+				{"9.9.9.9", 19281, "9.9.9.0-9.9.9.9", true},
+				{"2001:4860:4860::8888", 15169, "2001:4860::/32", true},
+				{"1.0.2.1", 0, "", false},
+				{"8.8.9.1", 0, "", false},
+				{"0.0.0.1", 0, "", false},
+			}
+			for _, test := range tests {
+				owner, found := db.Lookup(test.ip)
+				if found != test.found {
+					t.Errorf("Lookup(%s) found = %v, want %v", test.ip, found, test.found)
+					continue
+				}
+				if found && (owner.ASN != test.asn || owner.Prefix != test.prefix) {
+					t.Errorf("Lookup(%s) = AS%d %s, want AS%d %s", test.ip, owner.ASN, owner.Prefix, test.asn, test.prefix)
+				}
+			}
+
+			if owner, _ := db.Lookup("8.8.4.4"); owner != nil {
+				t.Errorf("Lookup(8.8.4.4) = %+v, want nothing", owner)
+			}
+			if owner, _ := db.Lookup("8.8.8.8"); owner.ASName != "GOOGLE" || owner.Country != "US" {
+				t.Errorf("Lookup(8.8.8.8) = %+v", owner)
+			}
+		})
+	}
+}
+
+// mrtRecord encodes one MRT record with the given TABLE_DUMP_V2 subtype.
+func mrtRecord(subtype uint16, body []byte) []byte {
+	record := make([]byte, 12, 12+len(body))
+	binary.BigEndian.PutUint32(record, 1700000000)
+	binary.BigEndian.PutUint16(record[4:], mrtTableDumpV2)
+	binary.BigEndian.PutUint16(record[6:], subtype)
+	binary.BigEndian.PutUint32(record[8:], uint32(len(body)))
+	return append(record, body...)
+}
+
+// ribEntry encodes a RIB record for prefix with one peer that saw path.
+func ribEntry(prefix netip.Prefix, path ...uint32) []byte {
+	var attrs []byte
+	attrs = append(attrs, 0x40, 1, 1, 0) // ORIGIN IGP
+	asPath := []byte{bgpASPathSequence, byte(len(path))}
+	for _, asn := range path {
+		asPath = binary.BigEndian.AppendUint32(asPath, asn)
+	}
+	attrs = append(attrs, 0x40, bgpAttrASPath, byte(len(asPath)))
+	attrs = append(attrs, asPath...)
+
+	body := binary.BigEndian.AppendUint32(nil, 1)
+	body = append(body, byte(prefix.Bits()))
+	body = append(body, prefix.Addr().AsSlice()[:(prefix.Bits()+7)/8]...)
+	body = binary.BigEndian.AppendUint16(body, 1)
+	body = binary.BigEndian.AppendUint16(body, 0)
+	body = binary.BigEndian.AppendUint32(body, 1700000000)
+	body = binary.BigEndian.AppendUint16(body, uint16(len(attrs)))
+	return append(body, attrs...)
+}
+
+func TestASNDatabaseMRT(t *testing.T) {
+	var dump []byte
+	dump = append(dump, mrtRecord(1, []byte{192, 0, 2, 1, 0, 0, 0, 0})...) // PEER_INDEX_TABLE, skipped
+	dump = append(dump, mrtRecord(mrtRIBIPv4Unicast, ribEntry(netip.MustParsePrefix("8.0.0.0/9"), 3356, 3356))...)
+	dump = append(dump, mrtRecord(mrtRIBIPv4Unicast, ribEntry(netip.MustParsePrefix("8.8.8.0/24"), 3356, 15169))...)
+	dump = append(dump, mrtRecord(mrtRIBIPv6Unicast, ribEntry(netip.MustParsePrefix("2001:4860::/32"), 6939, 15169))...)
+
+	db, err := ParseASNDatabase(bytes.NewReader(dump))
+	if err != nil {
+		t.Fatalf("ParseASNDatabase failed: %v", err)
+	}
+
+	tests := []struct {
+		ip     string
+		asn    uint32
+		prefix string
+	}{
+		{"8.8.8.8", 15169, "8.8.8.0/24"},
+		{"8.8.4.4", 3356, "8.0.0.0/9"},
+		{"2001:4860:4860::8888", 15169, "2001:4860::/32"},
+	}
+	for _, test := range tests {
+		owner, found := db.Lookup(test.ip)
+		if !found || owner.ASN != test.asn || owner.Prefix != test.prefix {
+			t.Errorf("Lookup(%s) = %+v, want AS%d %s", test.ip, owner, test.asn, test.prefix)
+		}
+	}
+	if _, found := db.Lookup("9.9.9.9"); found {
+		t.Error("Lookup(9.9.9.9) should find nothing")
+	}
+
+	if _, err := ParseASNDatabase(bytes.NewReader(dump[:len(dump)-3])); err == nil || !strings.Contains(err.Error(), "truncated") {
+		t.Errorf("Expected a truncation error, got %v", err)
+	}
+}
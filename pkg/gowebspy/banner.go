@@ -0,0 +1,334 @@
+package gowebspy
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	defaultServiceTimeout = 2 * time.Second
+	maxBannerLength       = 256
+)
+
+// ServiceInfo describes what actually answered on a port, as opposed to what
+// usually runs there. Protocol is "unknown" when nothing could be identified.
+type ServiceInfo struct {
+	Protocol string
+	Version  string
+	Banner   string
+	TLS      bool
+}
+
+var expectedProtocols = map[int]string{
+	21:   "ftp",
+	22:   "ssh",
+	25:   "smtp",
+	80:   "http",
+	110:  "pop3",
+	143:  "imap",
+	443:  "http",
+	465:  "smtp",
+	587:  "smtp",
+	993:  "imap",
+	995:  "pop3",
+	3306: "mysql",
+	5432: "postgresql",
+	6379: "redis",
+	8080: "http",
+	8443: "http",
+}
+
+// ExpectedProtocol returns the protocol normally found on port, or "" if the
+// port has no well-known service.
+func ExpectedProtocol(port int) string {
+	return expectedProtocols[port]
+}
+
+// Unexpected reports whether the detected protocol differs from the one
+// normally found on port, e.g. SSH answering on 8080.
+func (s *ServiceInfo) Unexpected(port int) bool {
+	expected := ExpectedProtocol(port)
+	return s != nil && expected != "" && s.Protocol != "unknown" && s.Protocol != expected
+}
+
+// DetectService connects to host:port and identifies the service from its
+// greeting, or failing that by sending lightweight TLS, HTTP, Redis and
+// PostgreSQL probes on fresh connections.
+func DetectService(ctx context.Context, host string, port int, timeout time.Duration) (*ServiceInfo, error) {
+	return detectService(ctx, &net.Dialer{}, "tcp", net.JoinHostPort(host, strconv.Itoa(port)), host, timeout)
+}
+
+type serviceProbe func(conn net.Conn, serverName string, timeout time.Duration) *ServiceInfo
+
+func detectService(ctx context.Context, dialer *net.Dialer, network, addr, serverName string, timeout time.Duration) (*ServiceInfo, error) {
+	if timeout <= 0 {
+		timeout = defaultServiceTimeout
+	}
+
+	probes := []serviceProbe{probeGreeting, probeTLS, probeHTTP, probeRedis, probePostgreSQL}
+	var lastBanner string
+
+	for i, probe := range probes {
+		dialCtx, cancel := context.WithTimeout(ctx, timeout)
+		conn, err := dialer.DialContext(dialCtx, network, addr)
+		cancel()
+		if err != nil {
+			if i == 0 {
+				return nil, err
+			}
+			break
+		}
+
+		conn.SetDeadline(time.Now().Add(timeout))
+		service := probe(conn, serverName, timeout)
+		conn.Close()
+
+		if service != nil && service.Protocol != "unknown" {
+			return service, nil
+		}
+		if service != nil && service.Banner != "" {
+			lastBanner = service.Banner
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+	}
+
+	return &ServiceInfo{Protocol: "unknown", Banner: lastBanner}, nil
+}
+
+func probeGreeting(conn net.Conn, serverName string, timeout time.Duration) *ServiceInfo {
+	data := readGreeting(conn, timeout)
+	if len(data) == 0 {
+		return nil
+	}
+	return identifyGreeting(data)
+}
+
+// identifyGreeting recognises services that speak first.
+func identifyGreeting(data []byte) *ServiceInfo {
+	if service := identifyMySQL(data); service != nil {
+		return service
+	}
+
+	line := firstLine(data)
+	upper := strings.ToUpper(line)
+
+	switch {
+	case strings.HasPrefix(line, "SSH-"):
+		version := line
+		if parts := strings.SplitN(line, "-", 3); len(parts) == 3 {
+			version = parts[2]
+		}
+		return &ServiceInfo{Protocol: "ssh", Version: version, Banner: line}
+	case strings.HasPrefix(line, "220"):
+		protocol := "smtp"
+		if strings.Contains(upper, "FTP") {
+			protocol = "ftp"
+		}
+		return &ServiceInfo{Protocol: protocol, Version: strings.TrimSpace(strings.TrimLeft(line[3:], " -")), Banner: line}
+	case strings.HasPrefix(line, "+OK"):
+		return &ServiceInfo{Protocol: "pop3", Version: strings.TrimSpace(line[3:]), Banner: line}
+	case strings.HasPrefix(upper, "* OK"):
+		return &ServiceInfo{Protocol: "imap", Version: strings.TrimSpace(line[4:]), Banner: line}
+	}
+
+	return &ServiceInfo{Protocol: "unknown", Banner: line}
+}
+
+// identifyMySQL parses the initial handshake packet (or the error packet a
+// server sends to hosts it refuses) that MySQL and MariaDB send on connect.
+func identifyMySQL(data []byte) *ServiceInfo {
+	if len(data) < 6 || data[3] != 0 {
+		return nil
+	}
+	length := int(data[0]) | int(data[1])<<8 | int(data[2])<<16
+	if length == 0 || length > len(data)-4 {
+		return nil
+	}
+	payload := data[4 : 4+length]
+
+	switch payload[0] {
+	case 0x0a:
+		end := bytes.IndexByte(payload[1:], 0)
+		if end < 0 {
+			return nil
+		}
+		version := string(payload[1 : 1+end])
+		return &ServiceInfo{Protocol: "mysql", Version: version, Banner: version}
+	case 0xff:
+		if len(payload) < 3 {
+			return nil
+		}
+		message := sanitizeBanner(payload[3:])
+		return &ServiceInfo{Protocol: "mysql", Banner: message}
+	}
+
+	return nil
+}
+
+func probeTLS(conn net.Conn, serverName string, timeout time.Duration) *ServiceInfo {
+	tlsConn := tls.Client(conn, &tls.Config{
+		InsecureSkipVerify: true,
+		ServerName:         serverName,
+		MinVersion:         tls.VersionTLS10,
+		NextProtos:         []string{"h2", "http/1.1"},
+	})
+	if err := tlsConn.Handshake(); err != nil {
+		return nil
+	}
+
+	state := tlsConn.ConnectionState()
+	service := &ServiceInfo{Protocol: "unknown", TLS: true, Banner: tls.VersionName(state.Version)}
+
+	// Implicit-TLS mail ports greet inside the tunnel; everything else gets
+	// an HTTP request.
+	if data := readGreeting(tlsConn, timeout); len(data) > 0 {
+		if inner := identifyGreeting(data); inner.Protocol != "unknown" {
+			inner.TLS = true
+			return inner
+		}
+	} else if state.NegotiatedProtocol == "h2" {
+		service.Protocol = "http"
+		service.Version = "HTTP/2"
+		return service
+	} else if inner := probeHTTP(tlsConn, serverName, timeout); inner != nil && inner.Protocol == "http" {
+		inner.TLS = true
+		return inner
+	}
+
+	service.Protocol = "tls"
+	service.Version = tls.VersionName(state.Version)
+	return service
+}
+
+func probeHTTP(conn net.Conn, serverName string, timeout time.Duration) *ServiceInfo {
+	request := fmt.Sprintf("HEAD / HTTP/1.0\r\nHost: %s\r\nUser-Agent: gowebspy\r\n\r\n", serverName)
+	if _, err := conn.Write([]byte(request)); err != nil {
+		return nil
+	}
+
+	data := readSome(conn)
+	if len(data) == 0 {
+		return nil
+	}
+
+	if !bytes.HasPrefix(data, []byte("HTTP/")) {
+		return &ServiceInfo{Protocol: "unknown", Banner: firstLine(data)}
+	}
+
+	service := &ServiceInfo{Protocol: "http", Banner: firstLine(data)}
+	resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(data)), nil)
+	if err == nil {
+		resp.Body.Close()
+		service.Version = resp.Header.Get("Server")
+	}
+	return service
+}
+
+func probeRedis(conn net.Conn, serverName string, timeout time.Duration) *ServiceInfo {
+	if _, err := conn.Write([]byte("PING\r\n")); err != nil {
+		return nil
+	}
+
+	service := identifyRedisReply(readSome(conn))
+	if service == nil || !strings.HasPrefix(service.Banner, "+PONG") {
+		return service
+	}
+
+	if _, err := conn.Write([]byte("INFO server\r\n")); err != nil {
+		return service
+	}
+	for _, line := range strings.Split(string(readSome(conn)), "\r\n") {
+		if version, ok := strings.CutPrefix(line, "redis_version:"); ok {
+			service.Version = version
+		}
+	}
+	return service
+}
+
+func identifyRedisReply(data []byte) *ServiceInfo {
+	line := firstLine(data)
+	for _, prefix := range []string{"+PONG", "-NOAUTH", "-DENIED", "-ERR unknown command", "-WRONGPASS"} {
+		if strings.HasPrefix(line, prefix) {
+			return &ServiceInfo{Protocol: "redis", Banner: line}
+		}
+	}
+	return nil
+}
+
+// probePostgreSQL sends an SSLRequest, which every PostgreSQL server answers
+// with a single 'S' or 'N' byte before any authentication happens.
+func probePostgreSQL(conn net.Conn, serverName string, timeout time.Duration) *ServiceInfo {
+	request := make([]byte, 8)
+	binary.BigEndian.PutUint32(request[0:4], 8)
+	binary.BigEndian.PutUint32(request[4:8], 80877103)
+	if _, err := conn.Write(request); err != nil {
+		return nil
+	}
+
+	reply := make([]byte, 1)
+	if _, err := conn.Read(reply); err != nil {
+		return nil
+	}
+
+	switch reply[0] {
+	case 'S':
+		return &ServiceInfo{Protocol: "postgresql", Banner: "SSL supported"}
+	case 'N':
+		return &ServiceInfo{Protocol: "postgresql", Banner: "SSL not supported"}
+	}
+	return nil
+}
+
+// readGreeting waits up to half of timeout for the peer to speak first and
+// leaves the rest of the budget for follow-up probes on the same connection.
+func readGreeting(conn net.Conn, timeout time.Duration) []byte {
+	conn.SetReadDeadline(time.Now().Add(timeout / 2))
+	data := readSome(conn)
+	conn.SetReadDeadline(time.Now().Add(timeout))
+	return data
+}
+
+// readSome returns whatever the peer sends before the connection deadline.
+// A quiet peer is not an error, it just means the service doesn't greet.
+func readSome(conn net.Conn) []byte {
+	buf := make([]byte, 4096)
+	n, err := conn.Read(buf)
+	if err != nil && !errors.Is(err, os.ErrDeadlineExceeded) && n == 0 {
+		return nil
+	}
+	return buf[:n]
+}
+
+func firstLine(data []byte) string {
+	if idx := bytes.IndexAny(data, "\r\n"); idx >= 0 {
+		data = data[:idx]
+	}
+	return sanitizeBanner(data)
+}
+
+func sanitizeBanner(data []byte) string {
+	var b strings.Builder
+	for _, r := range string(data) {
+		if r >= 0x20 && r != 0x7f && r != utf8.RuneError {
+			b.WriteRune(r)
+		}
+		if b.Len() >= maxBannerLength {
+			break
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
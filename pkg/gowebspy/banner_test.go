@@ -0,0 +1,201 @@
+package gowebspy
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// serveOnce starts a listener that hands every accepted connection to handle
+// and returns its port.
+func serveOnce(t *testing.T, handle func(conn net.Conn)) int {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				conn.SetDeadline(time.Now().Add(2 * time.Second))
+				handle(conn)
+			}()
+		}
+	}()
+
+	return listener.Addr().(*net.TCPAddr).Port
+}
+
+func greeter(greeting string) func(conn net.Conn) {
+	return func(conn net.Conn) {
+		conn.Write([]byte(greeting))
+		io.Copy(io.Discard, conn)
+	}
+}
+
+func mysqlGreeting(version string) string {
+	payload := "\x0a" + version + "\x00" + "\x08\x00\x00\x00" + "abcdefgh\x00"
+	length := len(payload)
+	return string([]byte{byte(length), byte(length >> 8), byte(length >> 16), 0}) + payload
+}
+
+func redisServer(conn net.Conn) {
+	reader := bufio.NewReader(conn)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		switch strings.TrimSpace(line) {
+		case "PING":
+			conn.Write([]byte("+PONG\r\n"))
+		case "INFO server":
+			body := "# Server\r\nredis_version:7.2.4\r\n"
+			conn.Write([]byte("$" + strconv.Itoa(len(body)) + "\r\n" + body + "\r\n"))
+		default:
+			conn.Write([]byte("-ERR unknown command\r\n"))
+		}
+	}
+}
+
+func postgresServer(conn net.Conn) {
+	request := make([]byte, 8)
+	if _, err := io.ReadFull(conn, request); err != nil {
+		return
+	}
+	if string(request[4:8]) == "\x04\xd2\x16\x2f" {
+		conn.Write([]byte("N"))
+	}
+}
+
+func TestDetectService(t *testing.T) {
+	tlsServer := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Server", "secure-test")
+	}))
+	tlsServer.Config.ErrorLog = log.New(io.Discard, "", 0)
+	tlsServer.StartTLS()
+	defer tlsServer.Close()
+	tlsPort := tlsServer.Listener.Addr().(*net.TCPAddr).Port
+
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Server", "plain-test")
+	}))
+	defer httpServer.Close()
+	httpPort := httpServer.Listener.Addr().(*net.TCPAddr).Port
+
+	smtpsPort := func() int {
+		cert := tlsServer.TLS.Certificates[0]
+		listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: []tls.Certificate{cert}})
+		if err != nil {
+			t.Fatalf("Failed to listen: %v", err)
+		}
+		t.Cleanup(func() { listener.Close() })
+		go func() {
+			for {
+				conn, err := listener.Accept()
+				if err != nil {
+					return
+				}
+				go greeter("220 mail.example.com ESMTP Postfix\r\n")(conn)
+			}
+		}()
+		return listener.Addr().(*net.TCPAddr).Port
+	}()
+
+	tests := []struct {
+		name     string
+		port     int
+		protocol string
+		version  string
+		tls      bool
+	}{
+		{"ssh", serveOnce(t, greeter("SSH-2.0-OpenSSH_9.6p1 Ubuntu-3ubuntu13\r\n")), "ssh", "OpenSSH_9.6p1 Ubuntu-3ubuntu13", false},
+		{"smtp", serveOnce(t, greeter("220 mail.example.com ESMTP Postfix\r\n")), "smtp", "mail.example.com ESMTP Postfix", false},
+		{"ftp", serveOnce(t, greeter("220 (vsFTPd 3.0.5)\r\n")), "ftp", "(vsFTPd 3.0.5)", false},
+		{"pop3", serveOnce(t, greeter("+OK Dovecot ready.\r\n")), "pop3", "Dovecot ready.", false},
+		{"imap", serveOnce(t, greeter("* OK [CAPABILITY IMAP4rev1] Dovecot ready.\r\n")), "imap", "[CAPABILITY IMAP4rev1] Dovecot ready.", false},
+		{"mysql", serveOnce(t, greeter(mysqlGreeting("8.0.36"))), "mysql", "8.0.36", false},
+		{"http", httpPort, "http", "plain-test", false},
+		{"https", tlsPort, "http", "secure-test", true},
+		{"smtps", smtpsPort, "smtp", "mail.example.com ESMTP Postfix", true},
+		{"redis", serveOnce(t, redisServer), "redis", "7.2.4", false},
+		{"postgresql", serveOnce(t, postgresServer), "postgresql", "", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			service, err := DetectService(context.Background(), "127.0.0.1", test.port, 400*time.Millisecond)
+			if err != nil {
+				t.Fatalf("DetectService failed: %v", err)
+			}
+			if service.Protocol != test.protocol {
+				t.Errorf("Protocol = %q, want %q (banner %q)", service.Protocol, test.protocol, service.Banner)
+			}
+			if service.Version != test.version {
+				t.Errorf("Version = %q, want %q", service.Version, test.version)
+			}
+			if service.TLS != test.tls {
+				t.Errorf("TLS = %v, want %v", service.TLS, test.tls)
+			}
+		})
+	}
+}
+
+func TestDetectServiceUnknown(t *testing.T) {
+	port := serveOnce(t, func(conn net.Conn) {
+		io.Copy(io.Discard, conn)
+	})
+
+	service, err := DetectService(context.Background(), "127.0.0.1", port, 200*time.Millisecond)
+	if err != nil {
+		t.Fatalf("DetectService failed: %v", err)
+	}
+	if service.Protocol != "unknown" {
+		t.Errorf("Protocol = %q, want unknown", service.Protocol)
+	}
+}
+
+func TestServiceInfoUnexpected(t *testing.T) {
+	ssh := &ServiceInfo{Protocol: "ssh"}
+	if !ssh.Unexpected(8080) {
+		t.Errorf("SSH on 8080 should be unexpected")
+	}
+	if ssh.Unexpected(22) {
+		t.Errorf("SSH on 22 should be expected")
+	}
+	if ssh.Unexpected(2222) {
+		t.Errorf("Ports without a well-known service are never unexpected")
+	}
+}
+
+func TestScanPortsDetectServices(t *testing.T) {
+	port := serveOnce(t, greeter("SSH-2.0-dropbear_2022.83\r\n"))
+
+	results, err := ScanPorts(context.Background(), "127.0.0.1", []int{port}, ScanOptions{
+		DetectServices: true,
+		ServiceTimeout: 400 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("ScanPorts failed: %v", err)
+	}
+	if results[0].Service == nil || results[0].Service.Protocol != "ssh" {
+		t.Errorf("Expected SSH to be detected, got %+v", results[0].Service)
+	}
+}
@@ -0,0 +1,191 @@
+package gowebspy
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultBatchWorkers = 10
+
+type BatchOptions struct {
+	// Workers bounds how many targets are scanned at once. Defaults to 10.
+	Workers int
+	// HostInterval is the minimum delay between two scans of the same host.
+	// Zero disables per-host rate limiting.
+	HostInterval time.Duration
+	Options      Options
+	// Filter, if set, is applied to every successful result; see
+	// BatchResult.Matched.
+	Filter *FilterOptions
+}
+
+type BatchResult struct {
+	Target   string
+	Info     *WebsiteInfo
+	Err      error
+	Matched  bool
+	Duration time.Duration
+}
+
+type BatchSummary struct {
+	Total     int
+	Succeeded int
+	Failed    int
+	Matched   int
+	Elapsed   time.Duration
+}
+
+func (s *BatchSummary) Add(result BatchResult) {
+	s.Total++
+	if result.Err != nil {
+		s.Failed++
+		return
+	}
+	s.Succeeded++
+	if result.Matched {
+		s.Matched++
+	}
+}
+
+// ReadTargets reads one target per line, skipping blank lines and lines
+// starting with '#'.
+func ReadTargets(r io.Reader) ([]string, error) {
+	var targets []string
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		targets = append(targets, line)
+	}
+
+	return targets, scanner.Err()
+}
+
+// ScanBatch runs GetWebsiteInfoWithOptions for every target on a bounded
+// worker pool. Results are sent as soon as they finish, so they arrive in
+// completion order rather than input order. The channel is closed once every
+// target has been scanned or ctx is cancelled.
+func ScanBatch(ctx context.Context, targets []string, opts BatchOptions) <-chan BatchResult {
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = defaultBatchWorkers
+	}
+
+	jobs := make(chan string)
+	results := make(chan BatchResult, workers)
+	limiter := newHostLimiter(opts.HostInterval)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for target := range jobs {
+				result := scanTarget(ctx, target, opts, limiter)
+				select {
+				case results <- result:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+
+	go func() {
+		defer close(jobs)
+		for _, target := range targets {
+			select {
+			case jobs <- target:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	return results
+}
+
+func scanTarget(ctx context.Context, target string, opts BatchOptions, limiter *hostLimiter) BatchResult {
+	result := BatchResult{Target: target}
+
+	if err := limiter.wait(ctx, targetHost(target)); err != nil {
+		result.Err = err
+		return result
+	}
+
+	start := time.Now()
+	result.Info, result.Err = GetWebsiteInfoWithOptions(ctx, target, opts.Options)
+	result.Duration = time.Since(start)
+
+	if result.Err == nil {
+		result.Matched = opts.Filter == nil || ApplyFilter(result.Info, opts.Filter)
+	}
+
+	return result
+}
+
+func targetHost(target string) string {
+	if !strings.HasPrefix(target, "http://") && !strings.HasPrefix(target, "https://") {
+		target = "https://" + target
+	}
+	parsed, err := url.Parse(target)
+	if err != nil {
+		return target
+	}
+	return strings.ToLower(parsed.Hostname())
+}
+
+// hostLimiter hands out time slots per host so that scans of the same host
+// are at least interval apart, regardless of which worker runs them.
+type hostLimiter struct {
+	interval time.Duration
+	mu       sync.Mutex
+	next     map[string]time.Time
+}
+
+func newHostLimiter(interval time.Duration) *hostLimiter {
+	return &hostLimiter{interval: interval, next: make(map[string]time.Time)}
+}
+
+func (l *hostLimiter) wait(ctx context.Context, host string) error {
+	if l.interval <= 0 {
+		return ctx.Err()
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	slot := l.next[host]
+	if slot.Before(now) {
+		slot = now
+	}
+	l.next[host] = slot.Add(l.interval)
+	l.mu.Unlock()
+
+	delay := time.Until(slot)
+	if delay <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
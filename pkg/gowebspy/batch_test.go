@@ -0,0 +1,106 @@
+package gowebspy
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestReadTargets(t *testing.T) {
+	input := "example.com\n\n# comment\n  https://example.org/path  \n"
+
+	targets, err := ReadTargets(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ReadTargets failed: %v", err)
+	}
+
+	expected := []string{"example.com", "https://example.org/path"}
+	if len(targets) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, targets)
+	}
+	for i := range expected {
+		if targets[i] != expected[i] {
+			t.Errorf("targets[%d] = %q, want %q", i, targets[i], expected[i])
+		}
+	}
+}
+
+func TestScanBatch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		_, err := w.Write([]byte("OK"))
+		if err != nil {
+			t.Fatalf("Failed to write response: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	targets := []string{
+		server.URL + "/a",
+		server.URL + "/b",
+		server.URL + "/missing",
+		"http://127.0.0.1:1/",
+	}
+
+	filter := NewFilterOptions()
+	filter.MaxStatusCode = 299
+
+	var summary BatchSummary
+	seen := map[string]BatchResult{}
+	for result := range ScanBatch(context.Background(), targets, BatchOptions{
+		Workers: 2,
+		Options: Options{SkipWhois: true},
+		Filter:  filter,
+	}) {
+		seen[result.Target] = result
+		summary.Add(result)
+	}
+
+	if summary.Total != 4 || summary.Succeeded != 3 || summary.Failed != 1 || summary.Matched != 2 {
+		t.Errorf("Unexpected summary: %+v", summary)
+	}
+	if seen[server.URL+"/missing"].Matched {
+		t.Errorf("404 result should not match the filter")
+	}
+	if seen["http://127.0.0.1:1/"].Err == nil {
+		t.Errorf("Expected an error for the unreachable target")
+	}
+}
+
+func TestScanBatchHostInterval(t *testing.T) {
+	var mu sync.Mutex
+	var hits []time.Time
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		hits = append(hits, time.Now())
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	interval := 50 * time.Millisecond
+	targets := []string{server.URL + "/1", server.URL + "/2", server.URL + "/3"}
+
+	for range ScanBatch(context.Background(), targets, BatchOptions{
+		Workers:      3,
+		HostInterval: interval,
+		Options:      Options{SkipWhois: true},
+	}) {
+	}
+
+	if len(hits) != 3 {
+		t.Fatalf("Expected 3 requests, got %d", len(hits))
+	}
+	for i := 1; i < len(hits); i++ {
+		if gap := hits[i].Sub(hits[i-1]); gap < interval-10*time.Millisecond {
+			t.Errorf("Requests %d and %d were only %v apart", i-1, i, gap)
+		}
+	}
+}
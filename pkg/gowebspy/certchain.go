@@ -0,0 +1,189 @@
+package gowebspy
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// CertificateInfo describes one certificate of the chain a server presented.
+type CertificateInfo struct {
+	Subject            string
+	Issuer             string
+	CommonName         string
+	SerialNumber       string
+	SignatureAlgorithm string
+	KeyType            string
+	KeySize            int
+	NotBefore          time.Time
+	NotAfter           time.Time
+	DNSNames           []string
+	IsCA               bool
+	SHA1Fingerprint    string
+	SHA256Fingerprint  string
+}
+
+// VerifyFailureReason says why a presented chain did not verify. It is empty
+// when verification succeeded.
+type VerifyFailureReason string
+
+const (
+	VerifyHostnameMismatch    VerifyFailureReason = "hostname mismatch"
+	VerifyUntrustedRoot       VerifyFailureReason = "untrusted root"
+	VerifyMissingIntermediate VerifyFailureReason = "missing intermediate"
+	VerifyExpiredIntermediate VerifyFailureReason = "expired intermediate"
+	VerifyExpired             VerifyFailureReason = "expired"
+	VerifyNotYetValid         VerifyFailureReason = "not yet valid"
+	VerifyRevoked             VerifyFailureReason = "revoked"
+	VerifyOther               VerifyFailureReason = "other"
+)
+
+// ChainVerification is the result of verifying a presented chain against a
+// set of roots and a hostname.
+type ChainVerification struct {
+	Verified bool
+	Reason   VerifyFailureReason
+	// HostnameMatch reports whether the leaf is valid for the hostname. It is
+	// checked even when the chain itself fails, whose reason then wins.
+	HostnameMatch bool
+	// Detail is the underlying x509 error message, if any.
+	Detail string
+}
+
+// LoadCABundle reads PEM certificates from path into a pool that can be used
+// as Options.RootCAs.
+func LoadCABundle(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no PEM certificates found in %s", path)
+	}
+	return pool, nil
+}
+
+// VerifyChain verifies certs, as presented by a server with the leaf first,
+// for hostname at the given time. A nil roots pool means the system roots.
+// The hostname is always checked, so a broken chain still reports whether
+// the leaf would have matched.
+//
+// Go's verifier reports every broken chain as an unknown authority, so the
+// reason is refined by looking at the presented certificates: an expired
+// intermediate is reported as such, a self-signed last certificate means the
+// root is not trusted, and a last certificate that points to its issuer via
+// AIA means the server left out an intermediate.
+func VerifyChain(certs []*x509.Certificate, hostname string, roots *x509.CertPool, now time.Time) *ChainVerification {
+	if len(certs) == 0 {
+		return &ChainVerification{Reason: VerifyOther, Detail: "no certificates presented"}
+	}
+
+	leaf := certs[0]
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+
+	hostErr := leaf.VerifyHostname(hostname)
+	_, err := leaf.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		CurrentTime:   now,
+	})
+	if err != nil {
+		detail := err.Error()
+		if hostErr != nil {
+			detail += "; " + hostErr.Error()
+		}
+		return &ChainVerification{Reason: classifyVerifyError(err, certs, now), HostnameMatch: hostErr == nil, Detail: detail}
+	}
+
+	if hostErr != nil {
+		return &ChainVerification{Reason: VerifyHostnameMismatch, Detail: hostErr.Error()}
+	}
+
+	return &ChainVerification{Verified: true, HostnameMatch: true}
+}
+
+func classifyVerifyError(err error, certs []*x509.Certificate, now time.Time) VerifyFailureReason {
+	var invalid x509.CertificateInvalidError
+	if errors.As(err, &invalid) && invalid.Reason == x509.Expired {
+		if invalid.Cert != nil && invalid.Cert != certs[0] {
+			return VerifyExpiredIntermediate
+		}
+		if now.Before(certs[0].NotBefore) {
+			return VerifyNotYetValid
+		}
+		return VerifyExpired
+	}
+
+	var unknown x509.UnknownAuthorityError
+	if !errors.As(err, &unknown) {
+		return VerifyOther
+	}
+
+	for _, cert := range certs[1:] {
+		if now.After(cert.NotAfter) || now.Before(cert.NotBefore) {
+			return VerifyExpiredIntermediate
+		}
+	}
+
+	last := certs[len(certs)-1]
+	if isSelfSigned(last) {
+		return VerifyUntrustedRoot
+	}
+	if len(last.IssuingCertificateURL) > 0 {
+		return VerifyMissingIntermediate
+	}
+	return VerifyUntrustedRoot
+}
+
+func isSelfSigned(cert *x509.Certificate) bool {
+	// CheckSignatureFrom would reject self-signed leaves that are not CAs.
+	return bytes.Equal(cert.RawIssuer, cert.RawSubject) &&
+		cert.CheckSignature(cert.SignatureAlgorithm, cert.RawTBSCertificate, cert.Signature) == nil
+}
+
+func describeCertificate(cert *x509.Certificate) CertificateInfo {
+	keyType, keySize := publicKeyInfo(cert.PublicKey)
+	sha1Sum := sha1.Sum(cert.Raw)
+	sha256Sum := sha256.Sum256(cert.Raw)
+
+	return CertificateInfo{
+		Subject:            cert.Subject.String(),
+		Issuer:             cert.Issuer.String(),
+		CommonName:         cert.Subject.CommonName,
+		SerialNumber:       cert.SerialNumber.Text(16),
+		SignatureAlgorithm: cert.SignatureAlgorithm.String(),
+		KeyType:            keyType,
+		KeySize:            keySize,
+		NotBefore:          cert.NotBefore,
+		NotAfter:           cert.NotAfter,
+		DNSNames:           cert.DNSNames,
+		IsCA:               cert.IsCA,
+		SHA1Fingerprint:    hex.EncodeToString(sha1Sum[:]),
+		SHA256Fingerprint:  hex.EncodeToString(sha256Sum[:]),
+	}
+}
+
+func publicKeyInfo(key any) (string, int) {
+	switch key := key.(type) {
+	case *rsa.PublicKey:
+		return "RSA", key.N.BitLen()
+	case *ecdsa.PublicKey:
+		return "ECDSA", key.Curve.Params().BitSize
+	case ed25519.PublicKey:
+		return "Ed25519", 256
+	}
+	return "unknown", 0
+}
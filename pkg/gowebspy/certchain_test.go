@@ -0,0 +1,146 @@
+package gowebspy
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"testing"
+	"time"
+)
+
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+// issueCert creates a certificate for template signed by parent, or a
+// self-signed one when parent is nil.
+func issueCert(t *testing.T, template *x509.Certificate, parent *testCert) *testCert {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+
+	signer, signerCert := key, template
+	if parent != nil {
+		signer, signerCert = parent.key, parent.cert
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, signerCert, &key.PublicKey, signer)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("Failed to parse certificate: %v", err)
+	}
+	return &testCert{cert: cert, key: key}
+}
+
+func caTemplate(name string, serial int64, notBefore, notAfter time.Time) *x509.Certificate {
+	return &x509.Certificate{
+		SerialNumber:          big.NewInt(serial),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             notBefore,
+		NotAfter:              notAfter,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+}
+
+func leafTemplate(name string, serial int64, notBefore, notAfter time.Time) *x509.Certificate {
+	return &x509.Certificate{
+		SerialNumber:          big.NewInt(serial),
+		Subject:               pkix.Name{CommonName: name},
+		DNSNames:              []string{name},
+		NotBefore:             notBefore,
+		NotAfter:              notAfter,
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IssuingCertificateURL: []string{"http://ca.example.test/intermediate.crt"},
+		BasicConstraintsValid: true,
+	}
+}
+
+func TestVerifyChain(t *testing.T) {
+	now := time.Now()
+	past, future := now.Add(-365*24*time.Hour), now.Add(365*24*time.Hour)
+
+	root := issueCert(t, caTemplate("Test Root", 1, past, future), nil)
+	intermediate := issueCert(t, caTemplate("Test Intermediate", 2, past, future), root)
+	expiredIntermediate := issueCert(t, caTemplate("Expired Intermediate", 3, past, now.Add(-time.Hour)), root)
+
+	leaf := issueCert(t, leafTemplate("www.example.test", 10, past, future), intermediate)
+	expiredLeaf := issueCert(t, leafTemplate("www.example.test", 11, past, now.Add(-time.Hour)), intermediate)
+	leafOfExpired := issueCert(t, leafTemplate("www.example.test", 12, past, future), expiredIntermediate)
+
+	untrustedRoot := issueCert(t, caTemplate("Untrusted Root", 20, past, future), nil)
+	untrustedLeaf := issueCert(t, leafTemplate("www.example.test", 21, past, future), untrustedRoot)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(root.cert)
+
+	tests := []struct {
+		name     string
+		chain    []*testCert
+		hostname string
+		reason   VerifyFailureReason
+		match    bool
+	}{
+		{"valid", []*testCert{leaf, intermediate}, "www.example.test", "", true},
+		{"hostname mismatch", []*testCert{leaf, intermediate}, "other.example.test", VerifyHostnameMismatch, false},
+		{"missing intermediate", []*testCert{leaf}, "www.example.test", VerifyMissingIntermediate, true},
+		{"expired intermediate", []*testCert{leafOfExpired, expiredIntermediate}, "www.example.test", VerifyExpiredIntermediate, true},
+		{"expired leaf", []*testCert{expiredLeaf, intermediate}, "www.example.test", VerifyExpired, true},
+		{"untrusted root", []*testCert{untrustedLeaf, untrustedRoot}, "www.example.test", VerifyUntrustedRoot, true},
+		{"untrusted root and hostname mismatch", []*testCert{untrustedLeaf, untrustedRoot}, "other.example.test", VerifyUntrustedRoot, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var certs []*x509.Certificate
+			for _, c := range test.chain {
+				certs = append(certs, c.cert)
+			}
+
+			result := VerifyChain(certs, test.hostname, roots, now)
+			if result.Verified != (test.reason == "") {
+				t.Errorf("Verified = %v, want %v (%s)", result.Verified, test.reason == "", result.Detail)
+			}
+			if result.Reason != test.reason {
+				t.Errorf("Reason = %q, want %q (%s)", result.Reason, test.reason, result.Detail)
+			}
+			if result.HostnameMatch != test.match {
+				t.Errorf("HostnameMatch = %v, want %v (%s)", result.HostnameMatch, test.match, result.Detail)
+			}
+		})
+	}
+}
+
+func TestDescribeCertificate(t *testing.T) {
+	now := time.Now()
+	root := issueCert(t, caTemplate("Test Root", 0x1f, now.Add(-time.Hour), now.Add(time.Hour)), nil)
+
+	info := describeCertificate(root.cert)
+	if info.Subject != "CN=Test Root" || info.Issuer != "CN=Test Root" {
+		t.Errorf("Subject = %q, Issuer = %q", info.Subject, info.Issuer)
+	}
+	if info.SerialNumber != "1f" {
+		t.Errorf("SerialNumber = %q, want 1f", info.SerialNumber)
+	}
+	if info.KeyType != "ECDSA" || info.KeySize != 256 {
+		t.Errorf("Key = %s/%d, want ECDSA/256", info.KeyType, info.KeySize)
+	}
+	if info.SignatureAlgorithm != "ECDSA-SHA256" || !info.IsCA {
+		t.Errorf("SignatureAlgorithm = %q, IsCA = %v", info.SignatureAlgorithm, info.IsCA)
+	}
+	if len(info.SHA1Fingerprint) != 40 || len(info.SHA256Fingerprint) != 64 {
+		t.Errorf("Unexpected fingerprints %q %q", info.SHA1Fingerprint, info.SHA256Fingerprint)
+	}
+}
@@ -0,0 +1,144 @@
+package gowebspy
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// maxCookieSize is the name plus value size browsers are required to
+// accept (RFC 6265 6.1); larger cookies may be dropped.
+const maxCookieSize = 4096
+
+// CookieIssue is an insecure cookie setting found by ParseCookies.
+type CookieIssue string
+
+const (
+	CookieSameSiteNoneInsecure CookieIssue = "samesite-none-without-secure"
+	CookieSessionNotHTTPOnly   CookieIssue = "session-without-httponly"
+	CookieNotSecure            CookieIssue = "not-secure"
+	CookieHostPrefix           CookieIssue = "host-prefix-violation"
+	CookieSecurePrefix         CookieIssue = "secure-prefix-violation"
+	CookieOversized            CookieIssue = "oversized"
+)
+
+// CookieIssues lists every CookieIssue.
+var CookieIssues = []CookieIssue{
+	CookieSameSiteNoneInsecure, CookieSessionNotHTTPOnly, CookieNotSecure,
+	CookieHostPrefix, CookieSecurePrefix, CookieOversized,
+}
+
+// CookieInfo is one Set-Cookie header of a response.
+type CookieInfo struct {
+	Name  string
+	Value string
+	// Domain is empty for host-only cookies, and has no leading dot.
+	Domain string
+	Path   string
+	// Expires is when the cookie expires, from Max-Age if set or else
+	// Expires. Session is set when it has neither and so lasts until the
+	// browser closes.
+	Expires  time.Time
+	Session  bool
+	Secure   bool
+	HttpOnly bool
+	// SameSite is Strict, Lax or None, or empty when unset, in which case
+	// browsers treat it as Lax.
+	SameSite string
+	// Size is the length of the name and value.
+	Size   int
+	Issues []CookieIssue
+}
+
+// HasIssue reports whether the cookie has issue.
+func (c *CookieInfo) HasIssue(issue CookieIssue) bool {
+	for _, i := range c.Issues {
+		if i == issue {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseCookies reads the Set-Cookie headers of a response and flags insecure
+// settings. https says whether the response came over TLS, where every
+// cookie should be Secure. Headers that can't be parsed are skipped, as
+// browsers do.
+func ParseCookies(headers http.Header, https bool) []CookieInfo {
+	var cookies []CookieInfo
+	now := time.Now()
+
+	for _, line := range headers.Values("Set-Cookie") {
+		cookie, err := http.ParseSetCookie(line)
+		if err != nil {
+			continue
+		}
+
+		info := CookieInfo{
+			Name:     cookie.Name,
+			Value:    cookie.Value,
+			Domain:   strings.TrimPrefix(cookie.Domain, "."),
+			Path:     cookie.Path,
+			Secure:   cookie.Secure,
+			HttpOnly: cookie.HttpOnly,
+			Size:     len(cookie.Name) + len(cookie.Value),
+		}
+		switch {
+		case cookie.MaxAge > 0:
+			info.Expires = now.Add(time.Duration(cookie.MaxAge) * time.Second)
+		case cookie.MaxAge < 0:
+			// Max-Age=0 or less deletes the cookie.
+			info.Expires = now
+		case !cookie.Expires.IsZero():
+			info.Expires = cookie.Expires
+		default:
+			info.Session = true
+		}
+		switch cookie.SameSite {
+		case http.SameSiteStrictMode:
+			info.SameSite = "Strict"
+		case http.SameSiteLaxMode:
+			info.SameSite = "Lax"
+		case http.SameSiteNoneMode:
+			info.SameSite = "None"
+		}
+
+		info.Issues = cookieIssues(info, https)
+		cookies = append(cookies, info)
+	}
+
+	return cookies
+}
+
+func cookieIssues(cookie CookieInfo, https bool) []CookieIssue {
+	var issues []CookieIssue
+
+	// Browsers reject SameSite=None without Secure.
+	if cookie.SameSite == "None" && !cookie.Secure {
+		issues = append(issues, CookieSameSiteNoneInsecure)
+	}
+	if cookie.Session && !cookie.HttpOnly {
+		issues = append(issues, CookieSessionNotHTTPOnly)
+	}
+	if https && !cookie.Secure {
+		issues = append(issues, CookieNotSecure)
+	}
+	// The prefixes are matched case-insensitively by current browsers
+	// (RFC 6265bis 4.1.3).
+	if hasPrefixFold(cookie.Name, "__Host-") &&
+		(!cookie.Secure || !https || cookie.Domain != "" || cookie.Path != "/") {
+		issues = append(issues, CookieHostPrefix)
+	}
+	if hasPrefixFold(cookie.Name, "__Secure-") && (!cookie.Secure || !https) {
+		issues = append(issues, CookieSecurePrefix)
+	}
+	if cookie.Size > maxCookieSize {
+		issues = append(issues, CookieOversized)
+	}
+
+	return issues
+}
+
+func hasPrefixFold(s, prefix string) bool {
+	return len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix)
+}
@@ -0,0 +1,113 @@
+package gowebspy
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseCookies(t *testing.T) {
+	headers := http.Header{}
+	headers.Add("Set-Cookie", "__Host-id=abc; Path=/; Secure; HttpOnly; SameSite=Strict")
+	headers.Add("Set-Cookie", "prefs=dark; Domain=.example.com; Path=/app; Max-Age=3600; SameSite=Lax")
+	headers.Add("Set-Cookie", "tracker=1; Expires=Wed, 21 Oct 2015 07:28:00 GMT; SameSite=None")
+	headers.Add("Set-Cookie", "not a cookie")
+
+	cookies := ParseCookies(headers, true)
+	if len(cookies) != 3 {
+		t.Fatalf("Expected 3 cookies, got %+v", cookies)
+	}
+
+	host := cookies[0]
+	if !host.Secure || !host.HttpOnly || host.SameSite != "Strict" || !host.Session || host.Path != "/" || len(host.Issues) != 0 {
+		t.Errorf("Unexpected __Host- cookie %+v", host)
+	}
+	if host.Size != len("__Host-id")+len("abc") {
+		t.Errorf("Size = %d", host.Size)
+	}
+
+	prefs := cookies[1]
+	if prefs.Domain != "example.com" || prefs.Session || time.Until(prefs.Expires) < 59*time.Minute {
+		t.Errorf("Unexpected persistent cookie %+v", prefs)
+	}
+	if !reflect.DeepEqual(prefs.Issues, []CookieIssue{CookieNotSecure}) {
+		t.Errorf("Issues = %v, want only not-secure", prefs.Issues)
+	}
+
+	tracker := cookies[2]
+	if !tracker.Expires.Equal(time.Date(2015, 10, 21, 7, 28, 0, 0, time.UTC)) {
+		t.Errorf("Expires = %s", tracker.Expires)
+	}
+	if !tracker.HasIssue(CookieSameSiteNoneInsecure) || tracker.HasIssue(CookieSessionNotHTTPOnly) {
+		t.Errorf("Issues = %v, want samesite-none-without-secure but not session-without-httponly", tracker.Issues)
+	}
+}
+
+func TestCookieIssues(t *testing.T) {
+	tests := []struct {
+		header string
+		https  bool
+		want   []CookieIssue
+	}{
+		{"sid=1", false, []CookieIssue{CookieSessionNotHTTPOnly}},
+		{"sid=1; HttpOnly", true, []CookieIssue{CookieNotSecure}},
+		{"__Host-sid=1; Secure; HttpOnly; Path=/; Domain=example.com", true, []CookieIssue{CookieHostPrefix}},
+		{"__host-sid=1; Secure; HttpOnly", true, []CookieIssue{CookieHostPrefix}},
+		{"__Host-sid=1; Secure; HttpOnly; Path=/", false, []CookieIssue{CookieHostPrefix}},
+		{"__Secure-sid=1; HttpOnly", true, []CookieIssue{CookieNotSecure, CookieSecurePrefix}},
+		{"__Secure-sid=1; Secure; HttpOnly", true, nil},
+		{"big=" + strings.Repeat("x", 5000) + "; Secure; HttpOnly", true, []CookieIssue{CookieOversized}},
+	}
+
+	for _, test := range tests {
+		headers := http.Header{"Set-Cookie": {test.header}}
+		cookies := ParseCookies(headers, test.https)
+		if len(cookies) != 1 {
+			t.Fatalf("%.40s: expected one cookie, got %d", test.header, len(cookies))
+		}
+		if !reflect.DeepEqual(cookies[0].Issues, test.want) {
+			t.Errorf("%.40s (https=%v): issues = %v, want %v", test.header, test.https, cookies[0].Issues, test.want)
+		}
+	}
+}
+
+func TestFilterCookies(t *testing.T) {
+	info := &WebsiteInfo{
+		StatusCode: 200,
+		Cookies: []CookieInfo{
+			{Name: "sid", Secure: true, HttpOnly: true},
+			{Name: "tracker", SameSite: "None", Issues: []CookieIssue{CookieSameSiteNoneInsecure}},
+		},
+	}
+
+	opts := NewFilterOptions()
+	opts.CookieMustExist = []string{"sid"}
+	if !ApplyFilter(info, opts) {
+		t.Error("Expected the sid cookie to be found")
+	}
+	opts.CookieMustExist = []string{"csrf"}
+	if ApplyFilter(info, opts) {
+		t.Error("Expected a missing csrf cookie to fail")
+	}
+
+	opts = NewFilterOptions()
+	opts.CookieIssues = []CookieIssue{CookieSameSiteNoneInsecure}
+	if !ApplyFilter(info, opts) {
+		t.Error("Expected the tracker cookie's issue to match")
+	}
+	opts.CookieIssues = []CookieIssue{CookieHostPrefix}
+	if ApplyFilter(info, opts) {
+		t.Error("Expected no cookie with a __Host- violation")
+	}
+
+	opts = NewFilterOptions()
+	opts.CookiesMustBeClean = true
+	if ApplyFilter(info, opts) {
+		t.Error("Expected a site with cookie issues to fail CookiesMustBeClean")
+	}
+	if !ApplyFilter(&WebsiteInfo{StatusCode: 200, Cookies: info.Cookies[:1]}, opts) {
+		t.Error("Expected a site with clean cookies to pass CookiesMustBeClean")
+	}
+}
@@ -0,0 +1,340 @@
+package gowebspy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+const (
+	defaultEDNSBufferSize = 1232
+	defaultDNSPort        = "53"
+	resolvConfPath        = "/etc/resolv.conf"
+)
+
+// DefaultDNSQueryTypes are the record types LookupDNS asks for when none are
+// given.
+var DefaultDNSQueryTypes = []string{"A", "AAAA", "CNAME", "MX", "NS", "TXT", "SOA", "CAA"}
+
+type DNSQueryOptions struct {
+	// Servers are the resolvers to ask, as "host" or "host:port", tried in
+	// order until one answers. Empty means the system's resolvers from
+	// /etc/resolv.conf.
+	Servers []string
+	// Authoritative asks the zone's authoritative name servers directly,
+	// without recursion. They are found through Servers.
+	Authoritative bool
+	// AuthoritativePort is the port the authoritative name servers are
+	// queried on. Defaults to 53.
+	AuthoritativePort string
+	// TCP always queries over TCP. Otherwise UDP is used, falling back to TCP
+	// when the answer is truncated.
+	TCP bool
+	// UDPSize is the EDNS0 buffer size to advertise.
+	UDPSize uint16
+	// DNSSEC sets the EDNS0 DO bit so signatures are returned.
+	DNSSEC bool
+	// CheckingDisabled sets the CD bit, asking a validating resolver to
+	// return data even when its DNSSEC validation fails.
+	CheckingDisabled bool
+	// Timeout applies to each query.
+	Timeout time.Duration
+}
+
+func (o DNSQueryOptions) withDefaults() DNSQueryOptions {
+	if o.Timeout <= 0 {
+		o.Timeout = defaultDNSTimeout
+	}
+	if o.UDPSize == 0 {
+		o.UDPSize = defaultEDNSBufferSize
+	}
+	if o.AuthoritativePort == "" {
+		o.AuthoritativePort = defaultDNSPort
+	}
+	return o
+}
+
+// DNSResponse is the answer to one query made with QueryDNS.
+type DNSResponse struct {
+	Name string
+	Type string
+	// Server is the address of the server that answered, and Protocol "udp"
+	// or "tcp".
+	Server   string
+	Protocol string
+	RCode    string
+	RTT      time.Duration
+	// Authoritative is the AA flag. AuthenticatedData is the AD flag: the
+	// resolver says it validated the answer with DNSSEC.
+	Authoritative     bool
+	AuthenticatedData bool
+	Answer            []DNSRecord
+	Authority         []DNSRecord
+	Additional        []DNSRecord
+
+	msg *dns.Msg
+}
+
+// DNSRecord is a resource record. Value is its data in zone file
+// presentation format, e.g. "10 mx.example.com." for an MX record.
+type DNSRecord struct {
+	Name  string
+	Type  string
+	TTL   uint32
+	Value string
+
+	rr dns.RR
+}
+
+// Values returns the Value of each answer record of type rrType, leaving out
+// the CNAMEs and signatures that may come with them.
+func (r *DNSResponse) Values(rrType string) []string {
+	var values []string
+	for _, record := range r.Answer {
+		if record.Type == rrType {
+			values = append(values, record.Value)
+		}
+	}
+	return values
+}
+
+// QueryDNS sends one query for name and record type rrType (such as "MX",
+// "TLSA" or "HTTPS") and returns the answer with TTLs and the server that
+// gave it. A PTR query for an IP address asks for its reverse name.
+//
+// Negative answers such as NXDOMAIN are not errors; see DNSResponse.RCode.
+func QueryDNS(ctx context.Context, name, rrType string, opts DNSQueryOptions) (*DNSResponse, error) {
+	opts = opts.withDefaults()
+
+	rrType = strings.ToUpper(rrType)
+	qtype, ok := dns.StringToType[rrType]
+	if !ok {
+		return nil, fmt.Errorf("unsupported DNS record type %q", rrType)
+	}
+
+	qname, err := queryName(name, qtype)
+	if err != nil {
+		return nil, fmt.Errorf("DNS query for %s failed: %w", name, err)
+	}
+
+	var servers []string
+	if opts.Authoritative {
+		servers, err = AuthoritativeServers(ctx, qname, opts)
+	} else {
+		servers, err = opts.servers()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("DNS query for %s failed: %w", qname, err)
+	}
+
+	msg := new(dns.Msg)
+	msg.SetQuestion(qname, qtype)
+	msg.RecursionDesired = !opts.Authoritative
+	msg.CheckingDisabled = opts.CheckingDisabled
+	msg.SetEdns0(opts.UDPSize, opts.DNSSEC)
+
+	response, err := exchangeDNS(ctx, msg, servers, opts)
+	if err != nil {
+		return nil, fmt.Errorf("DNS query for %s %s failed: %w", qname, rrType, err)
+	}
+	return response, nil
+}
+
+// LookupDNS queries name for each of rrTypes, or DefaultDNSQueryTypes when
+// none are given. Failed queries are reported in the error, alongside the
+// responses that did arrive.
+func LookupDNS(ctx context.Context, name string, rrTypes []string, opts DNSQueryOptions) ([]*DNSResponse, error) {
+	if len(rrTypes) == 0 {
+		rrTypes = DefaultDNSQueryTypes
+	}
+
+	var responses []*DNSResponse
+	var errs []error
+	for _, rrType := range rrTypes {
+		response, err := QueryDNS(ctx, name, rrType, opts)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		responses = append(responses, response)
+	}
+	return responses, errors.Join(errs...)
+}
+
+// AuthoritativeServers returns the addresses of the name servers of the zone
+// name belongs to, found by asking opts.Servers for the NS records of name and
+// then of each parent domain in turn.
+func AuthoritativeServers(ctx context.Context, name string, opts DNSQueryOptions) ([]string, error) {
+	opts = opts.withDefaults()
+	opts.Authoritative = false
+
+	zone := dns.Fqdn(name)
+	var hosts []string
+	for {
+		response, err := QueryDNS(ctx, zone, "NS", opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, record := range response.Answer {
+			if ns, ok := record.rr.(*dns.NS); ok && strings.EqualFold(ns.Hdr.Name, zone) {
+				hosts = append(hosts, ns.Ns)
+			}
+		}
+		if len(hosts) > 0 {
+			break
+		}
+
+		next, end := dns.NextLabel(zone, 0)
+		if end {
+			return nil, fmt.Errorf("no name servers found for %s", name)
+		}
+		zone = zone[next:]
+	}
+
+	var servers []string
+	for _, host := range hosts {
+		for _, rrType := range []string{"A", "AAAA"} {
+			response, err := QueryDNS(ctx, host, rrType, opts)
+			if err != nil {
+				continue
+			}
+			for _, address := range response.Values(rrType) {
+				servers = append(servers, net.JoinHostPort(address, opts.AuthoritativePort))
+			}
+		}
+	}
+	if len(servers) == 0 {
+		return nil, fmt.Errorf("could not resolve the name servers of %s (%s)", zone, strings.Join(hosts, ", "))
+	}
+	return servers, nil
+}
+
+// NewDNSResolver returns a resolver that sends every query to server
+// ("host" or "host:port") instead of the system's resolvers, for use as
+// Options.Resolver.
+func NewDNSResolver(server string) *net.Resolver {
+	server = withDNSPort(server)
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, network, server)
+		},
+	}
+}
+
+func (o DNSQueryOptions) servers() ([]string, error) {
+	servers := o.Servers
+	if len(servers) == 0 {
+		config, err := dns.ClientConfigFromFile(resolvConfPath)
+		if err != nil {
+			return nil, fmt.Errorf("no DNS server given and the system resolvers are unknown: %w", err)
+		}
+		servers = config.Servers
+	}
+	if len(servers) == 0 {
+		return nil, errors.New("no DNS servers configured")
+	}
+
+	addresses := make([]string, len(servers))
+	for i, server := range servers {
+		addresses[i] = withDNSPort(server)
+	}
+	return addresses, nil
+}
+
+// withDNSPort adds port 53 to server unless it has a port already.
+func withDNSPort(server string) string {
+	if _, _, err := net.SplitHostPort(server); err == nil {
+		return server
+	}
+	return net.JoinHostPort(strings.Trim(server, "[]"), defaultDNSPort)
+}
+
+// queryName returns the fully qualified name to ask for: the reverse name
+// for PTR queries of IP addresses, otherwise name in punycode.
+func queryName(name string, qtype uint16) (string, error) {
+	if qtype == dns.TypePTR && net.ParseIP(strings.Trim(name, "[]")) != nil {
+		return dns.ReverseAddr(strings.Trim(name, "[]"))
+	}
+	if name == "." {
+		return name, nil
+	}
+	host, err := NormalizeHost(name)
+	if err != nil {
+		return "", err
+	}
+	return dns.Fqdn(host), nil
+}
+
+// exchangeDNS sends msg to each server in turn and returns the first answer.
+func exchangeDNS(ctx context.Context, msg *dns.Msg, servers []string, opts DNSQueryOptions) (*DNSResponse, error) {
+	var errs []error
+	for _, server := range servers {
+		protocol := "udp"
+		if opts.TCP {
+			protocol = "tcp"
+		}
+
+		reply, rtt, err := exchangeDNSOnce(ctx, msg, server, protocol, opts)
+		if err == nil && reply.Truncated && protocol == "udp" {
+			protocol = "tcp"
+			reply, rtt, err = exchangeDNSOnce(ctx, msg, server, protocol, opts)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", server, err))
+			if ctx.Err() != nil {
+				break
+			}
+			continue
+		}
+		return newDNSResponse(msg, reply, server, protocol, rtt), nil
+	}
+	return nil, errors.Join(errs...)
+}
+
+func exchangeDNSOnce(ctx context.Context, msg *dns.Msg, server, protocol string, opts DNSQueryOptions) (*dns.Msg, time.Duration, error) {
+	client := &dns.Client{Net: protocol, Timeout: opts.Timeout, UDPSize: opts.UDPSize}
+	return client.ExchangeContext(ctx, msg, server)
+}
+
+func newDNSResponse(query, reply *dns.Msg, server, protocol string, rtt time.Duration) *DNSResponse {
+	question := query.Question[0]
+	return &DNSResponse{
+		Name:              question.Name,
+		Type:              dns.TypeToString[question.Qtype],
+		Server:            server,
+		Protocol:          protocol,
+		RCode:             dns.RcodeToString[reply.Rcode],
+		RTT:               rtt,
+		Authoritative:     reply.Authoritative,
+		AuthenticatedData: reply.AuthenticatedData,
+		Answer:            newDNSRecords(reply.Answer),
+		Authority:         newDNSRecords(reply.Ns),
+		Additional:        newDNSRecords(reply.Extra),
+		msg:               reply,
+	}
+}
+
+func newDNSRecords(rrs []dns.RR) []DNSRecord {
+	var records []DNSRecord
+	for _, rr := range rrs {
+		header := rr.Header()
+		if header.Rrtype == dns.TypeOPT {
+			continue
+		}
+		records = append(records, DNSRecord{
+			Name:  header.Name,
+			Type:  dns.TypeToString[header.Rrtype],
+			TTL:   header.Ttl,
+			Value: strings.TrimPrefix(rr.String(), header.String()),
+			rr:    rr,
+		})
+	}
+	return records
+}
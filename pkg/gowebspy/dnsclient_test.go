@@ -0,0 +1,205 @@
+package gowebspy
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/miekg/dns"
+)
+
+// dnsServer runs handler on UDP and TCP on the same local port and returns
+// the address.
+func dnsServer(t *testing.T, handler dns.HandlerFunc) string {
+	var packetConn net.PacketConn
+	var listener net.Listener
+	for attempt := 0; listener == nil; attempt++ {
+		var err error
+		packetConn, err = net.ListenPacket("udp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("Failed to listen: %v", err)
+		}
+		listener, err = net.Listen("tcp", packetConn.LocalAddr().String())
+		if err != nil {
+			packetConn.Close()
+			if attempt == 10 {
+				t.Fatalf("Failed to listen: %v", err)
+			}
+		}
+	}
+
+	for _, server := range []*dns.Server{{PacketConn: packetConn, Handler: handler}, {Listener: listener, Handler: handler}} {
+		started := make(chan struct{})
+		server.NotifyStartedFunc = func() { close(started) }
+		go server.ActivateAndServe()
+		<-started
+		t.Cleanup(func() { server.Shutdown() })
+	}
+	return packetConn.LocalAddr().String()
+}
+
+// testZone answers for example.test as a recursive resolver would, and for
+// the records of large.example.test only over TCP.
+func testZone(w dns.ResponseWriter, req *dns.Msg) {
+	reply := new(dns.Msg)
+	reply.SetReply(req)
+	reply.Authoritative = !req.RecursionDesired
+
+	question := req.Question[0]
+	add := func(records ...string) {
+		for _, record := range records {
+			rr, err := dns.NewRR(record)
+			if err != nil {
+				panic(err)
+			}
+			reply.Answer = append(reply.Answer, rr)
+		}
+	}
+
+	switch strings.ToLower(question.Name) + " " + dns.TypeToString[question.Qtype] {
+	case "example.test. A":
+		add("example.test. 300 IN A 192.0.2.10")
+	case "example.test. MX":
+		add("example.test. 3600 IN MX 10 mail.example.test.")
+	case "example.test. NS":
+		add("example.test. 86400 IN NS ns1.example.test.")
+	case "ns1.example.test. A":
+		add("ns1.example.test. 86400 IN A 127.0.0.1")
+	case "example.test. CAA":
+		add(`example.test. 3600 IN CAA 0 issue "letsencrypt.org"`)
+	case "example.test. HTTPS":
+		add(`example.test. 300 IN HTTPS 1 . alpn="h3,h2"`)
+	case "_443._tcp.example.test. TLSA":
+		add("_443._tcp.example.test. 300 IN TLSA 3 1 1 0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef")
+	case "www.example.test. A":
+		add("www.example.test. 60 IN CNAME example.test.", "example.test. 300 IN A 192.0.2.10")
+	case "10.2.0.192.in-addr.arpa. PTR":
+		add("10.2.0.192.in-addr.arpa. 3600 IN PTR example.test.")
+	case "large.example.test. TXT":
+		if _, udp := w.RemoteAddr().(*net.UDPAddr); udp {
+			reply.Truncated = true
+			break
+		}
+		add(`large.example.test. 300 IN TXT "` + strings.Repeat("x", 200) + `"`)
+	default:
+		reply.Rcode = dns.RcodeNameError
+		soa, _ := dns.NewRR("example.test. 300 IN SOA ns1.example.test. hostmaster.example.test. 1 7200 900 1209600 300")
+		reply.Ns = append(reply.Ns, soa)
+	}
+
+	if opt := req.IsEdns0(); opt != nil {
+		reply.SetEdns0(opt.UDPSize(), opt.Do())
+	}
+	w.WriteMsg(reply)
+}
+
+func TestQueryDNS(t *testing.T) {
+	server := dnsServer(t, testZone)
+	opts := DNSQueryOptions{Servers: []string{server}}
+	ctx := context.Background()
+
+	tests := []struct {
+		name, rrType string
+		want         string
+		ttl          uint32
+	}{
+		{"example.test", "A", "192.0.2.10", 300},
+		{"example.test", "MX", "10 mail.example.test.", 3600},
+		{"example.test", "caa", `0 issue "letsencrypt.org"`, 3600},
+		{"example.test", "HTTPS", `1 . alpn="h3,h2"`, 300},
+		{"_443._tcp.example.test", "TLSA", "3 1 1 0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef", 300},
+		{"192.0.2.10", "PTR", "example.test.", 3600},
+		{"www.example.test.", "A", "192.0.2.10", 300},
+	}
+	for _, test := range tests {
+		response, err := QueryDNS(ctx, test.name, test.rrType, opts)
+		if err != nil {
+			t.Errorf("QueryDNS(%s, %s) failed: %v", test.name, test.rrType, err)
+			continue
+		}
+		rrType := strings.ToUpper(test.rrType)
+		values := response.Values(rrType)
+		if len(values) != 1 || values[0] != test.want {
+			t.Errorf("QueryDNS(%s, %s) = %q, want %q", test.name, rrType, values, test.want)
+			continue
+		}
+		for _, record := range response.Answer {
+			if record.Type == rrType && record.TTL != test.ttl {
+				t.Errorf("QueryDNS(%s, %s) TTL = %d, want %d", test.name, rrType, record.TTL, test.ttl)
+			}
+		}
+		if response.Server != server || response.Protocol != "udp" || response.RCode != "NOERROR" {
+			t.Errorf("QueryDNS(%s, %s) answered by %s over %s with %s", test.name, rrType, response.Server, response.Protocol, response.RCode)
+		}
+	}
+
+	response, err := QueryDNS(ctx, "missing.example.test", "A", opts)
+	if err != nil {
+		t.Fatalf("QueryDNS for a missing name failed: %v", err)
+	}
+	if response.RCode != "NXDOMAIN" || len(response.Answer) != 0 || len(response.Authority) != 1 || response.Authority[0].Type != "SOA" {
+		t.Errorf("Unexpected negative answer: %+v", response)
+	}
+
+	if _, err := QueryDNS(ctx, "example.test", "BOGUS", opts); err == nil {
+		t.Error("Expected an error for an unknown record type")
+	}
+}
+
+func TestQueryDNSTruncated(t *testing.T) {
+	server := dnsServer(t, testZone)
+
+	response, err := QueryDNS(context.Background(), "large.example.test", "TXT", DNSQueryOptions{Servers: []string{"127.0.0.1:1", server}})
+	if err != nil {
+		t.Fatalf("QueryDNS failed: %v", err)
+	}
+	if response.Protocol != "tcp" || response.Server != server || len(response.Values("TXT")) != 1 {
+		t.Errorf("Expected the truncated answer to be retried over TCP, got %+v", response)
+	}
+}
+
+func TestQueryDNSAuthoritative(t *testing.T) {
+	server := dnsServer(t, testZone)
+	_, port, _ := net.SplitHostPort(server)
+
+	servers, err := AuthoritativeServers(context.Background(), "www.example.test", DNSQueryOptions{Servers: []string{server}, AuthoritativePort: port})
+	if err != nil {
+		t.Fatalf("AuthoritativeServers failed: %v", err)
+	}
+	if len(servers) != 1 || servers[0] != server {
+		t.Errorf("AuthoritativeServers = %v, want [%s]", servers, server)
+	}
+
+	response, err := QueryDNS(context.Background(), "example.test", "MX", DNSQueryOptions{Servers: []string{server}, Authoritative: true, AuthoritativePort: port, TCP: true})
+	if err != nil {
+		t.Fatalf("QueryDNS failed: %v", err)
+	}
+	if !response.Authoritative || response.Protocol != "tcp" {
+		t.Errorf("Expected an authoritative answer over TCP, got %+v", response)
+	}
+}
+
+func TestLookupDNS(t *testing.T) {
+	server := dnsServer(t, testZone)
+
+	responses, err := LookupDNS(context.Background(), "example.test", []string{"A", "MX", "NOPE"}, DNSQueryOptions{Servers: []string{server}})
+	if err == nil || !strings.Contains(err.Error(), "NOPE") {
+		t.Errorf("Expected the unknown type to be reported, got %v", err)
+	}
+	if len(responses) != 2 || responses[0].Type != "A" || responses[1].Type != "MX" {
+		t.Errorf("Unexpected responses: %+v", responses)
+	}
+}
+
+func TestNewDNSResolver(t *testing.T) {
+	server := dnsServer(t, testZone)
+
+	addrs, err := NewDNSResolver(server).LookupHost(context.Background(), "example.test")
+	if err != nil {
+		t.Fatalf("LookupHost failed: %v", err)
+	}
+	if len(addrs) != 1 || addrs[0] != "192.0.2.10" {
+		t.Errorf("LookupHost = %v, want [192.0.2.10]", addrs)
+	}
+}
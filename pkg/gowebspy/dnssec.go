@@ -0,0 +1,662 @@
+package gowebspy
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+const defaultSignatureExpiryWarning = 7 * 24 * time.Hour
+
+// RootTrustAnchors are the DS records of the root zone's key signing keys,
+// KSK-2017 and KSK-2024, as published by IANA.
+var RootTrustAnchors = []string{
+	". IN DS 20326 8 2 E06D44B80B8F1D39A95C0B0D7C65D08458E880409BBC683457104237C7F8EC8D",
+	". IN DS 38696 8 2 683D2D0ACB8C9B712A1948B27F741219298D0A450D612C483AF444A4C0FB2B16",
+}
+
+type DNSSECStatus string
+
+const (
+	// DNSSECSecure means every link from the root to the domain validates.
+	DNSSECSecure DNSSECStatus = "secure"
+	// DNSSECInsecure means a zone on the way is provably unsigned: its
+	// parent has no DS record for it.
+	DNSSECInsecure DNSSECStatus = "insecure"
+	// DNSSECBogus means the domain should be signed but validation fails,
+	// which validating resolvers answer with SERVFAIL.
+	DNSSECBogus DNSSECStatus = "bogus"
+)
+
+type DNSSECOptions struct {
+	// DNS is used for the queries. The DO and CD bits are always set, so a
+	// validating resolver hands over broken data instead of failing.
+	DNS DNSQueryOptions
+	// TrustAnchors are the root DS records in presentation format.
+	// Defaults to RootTrustAnchors.
+	TrustAnchors []string
+	// ExpiryWarning is how close to its expiration a signature has to be to
+	// be warned about. Defaults to a week.
+	ExpiryWarning time.Duration
+	// Time is when signatures must be valid. Zero means now.
+	Time time.Time
+}
+
+func (o DNSSECOptions) withDefaults() DNSSECOptions {
+	o.DNS.DNSSEC = true
+	o.DNS.CheckingDisabled = true
+	if len(o.TrustAnchors) == 0 {
+		o.TrustAnchors = RootTrustAnchors
+	}
+	if o.ExpiryWarning <= 0 {
+		o.ExpiryWarning = defaultSignatureExpiryWarning
+	}
+	if o.Time.IsZero() {
+		o.Time = time.Now()
+	}
+	return o
+}
+
+// DNSSECReport is the result of CheckDNSSEC.
+type DNSSECReport struct {
+	Domain string
+	Status DNSSECStatus
+	// Signed is false when a zone on the way to the domain has no DS
+	// record in its parent, so nothing below it can be validated.
+	Signed bool
+	// Zones are the zones the chain of trust passes through, root first.
+	Zones []DNSSECZone
+	// Algorithms are the DNSKEY algorithms used along the chain.
+	Algorithms []string
+	// EarliestExpiry is when the first signature along the chain expires.
+	EarliestExpiry time.Time
+	// Problems explain a bogus status. Warnings are for signatures about to
+	// expire and deprecated algorithms.
+	Problems []string
+	Warnings []string
+}
+
+type DNSSECZone struct {
+	Name   string
+	Status DNSSECStatus
+	// DS are the delegation signer records for the zone in its parent; for
+	// the root, the trust anchors.
+	DS         []DNSSECDelegation
+	Keys       []DNSSECKey
+	Signatures []DNSSECSignature
+}
+
+type DNSSECDelegation struct {
+	KeyTag     uint16
+	Algorithm  string
+	DigestType uint8
+	// Matched is set when one of the zone's DNSKEYs hashes to this record.
+	Matched bool
+}
+
+type DNSSECKey struct {
+	KeyTag    uint16
+	Algorithm string
+	Flags     uint16
+	// SecureEntryPoint is set for key signing keys.
+	SecureEntryPoint bool
+}
+
+// DNSSECSignature is an RRSIG checked during validation.
+type DNSSECSignature struct {
+	// Owner and Covers name the RRset the signature is over.
+	Owner      string
+	Covers     string
+	Signer     string
+	KeyTag     uint16
+	Algorithm  string
+	Inception  time.Time
+	Expiration time.Time
+	Valid      bool
+	Error      string
+}
+
+// deprecatedAlgorithms must not be used for signing per RFC 8624.
+var deprecatedAlgorithms = map[uint8]bool{
+	dns.RSAMD5:           true,
+	dns.DSA:              true,
+	dns.RSASHA1:          true,
+	dns.DSANSEC3SHA1:     true,
+	dns.RSASHA1NSEC3SHA1: true,
+	dns.ECCGOST:          true,
+}
+
+// dnssecValidator carries the state of one CheckDNSSEC walk.
+type dnssecValidator struct {
+	ctx    context.Context
+	opts   DNSSECOptions
+	report *DNSSECReport
+	// zone and keys are those of the deepest validated zone so far.
+	zone string
+	keys []*dns.DNSKEY
+}
+
+// CheckDNSSEC validates the chain of trust from the root to domain: the DS
+// record of each zone in its parent, the zone's DNSKEYs and their
+// signatures, and finally the signature of the domain's own A records, or
+// the signed proof that the domain or its A records don't exist.
+//
+// The error is only set when the queries fail; a broken chain is reported
+// as DNSSECBogus.
+func CheckDNSSEC(ctx context.Context, domain string, opts DNSSECOptions) (*DNSSECReport, error) {
+	opts = opts.withDefaults()
+
+	name, err := queryName(domain, dns.TypeA)
+	if err != nil {
+		return nil, fmt.Errorf("DNSSEC check of %s failed: %w", domain, err)
+	}
+
+	v := &dnssecValidator{ctx: ctx, opts: opts, report: &DNSSECReport{Domain: name, Status: DNSSECSecure}}
+	if err := v.walk(name); err != nil {
+		return nil, fmt.Errorf("DNSSEC check of %s failed: %w", name, err)
+	}
+
+	report := v.report
+	report.Signed = report.Status != DNSSECInsecure
+	sort.Strings(report.Algorithms)
+	return report, nil
+}
+
+func (v *dnssecValidator) walk(name string) error {
+	labels := dns.SplitDomainName(name)
+	for i := len(labels); i >= 0; i-- {
+		zone := dns.Fqdn(strings.Join(labels[i:], "."))
+
+		var ds []*dns.DS
+		var err error
+		if zone == "." {
+			ds, err = v.trustAnchors()
+		} else {
+			var isZone bool
+			ds, isZone, err = v.delegation(zone)
+			if err == nil && !isZone {
+				continue
+			}
+		}
+		if err != nil {
+			return err
+		}
+		if v.report.Status != DNSSECSecure {
+			return nil
+		}
+
+		if err := v.enterZone(zone, ds); err != nil {
+			return err
+		}
+		if v.report.Status != DNSSECSecure {
+			return nil
+		}
+	}
+
+	return v.checkTarget(name)
+}
+
+func (v *dnssecValidator) trustAnchors() ([]*dns.DS, error) {
+	var anchors []*dns.DS
+	for _, anchor := range v.opts.TrustAnchors {
+		rr, err := dns.NewRR(anchor)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trust anchor %q: %w", anchor, err)
+		}
+		ds, ok := rr.(*dns.DS)
+		if !ok {
+			return nil, fmt.Errorf("trust anchor %q is not a DS record", anchor)
+		}
+		anchors = append(anchors, ds)
+	}
+	return anchors, nil
+}
+
+// delegation looks up the DS records of zone in its parent. When there are
+// none it finds out whether zone is a zone at all, and if it is, checks
+// that the parent proves the DS records do not exist.
+func (v *dnssecValidator) delegation(zone string) ([]*dns.DS, bool, error) {
+	response, err := v.query(zone, dns.TypeDS)
+	if err != nil {
+		return nil, false, err
+	}
+
+	rrset, sigs := rrsetOf(response.msg.Answer, zone, dns.TypeDS)
+	if len(rrset) > 0 {
+		zoneEntry := DNSSECZone{Name: zone}
+		if !v.verify(&zoneEntry, rrset, sigs) {
+			v.fail(&zoneEntry, "the DS records of %s do not validate with the keys of %s", zone, v.zone)
+		}
+		ds := make([]*dns.DS, len(rrset))
+		for i, rr := range rrset {
+			ds[i] = rr.(*dns.DS)
+		}
+		// The zone's own entry is added by enterZone; carry the DS
+		// signatures over to it.
+		v.report.Zones = append(v.report.Zones, zoneEntry)
+		return ds, true, nil
+	}
+
+	soa, err := v.query(zone, dns.TypeSOA)
+	if err != nil {
+		return nil, false, err
+	}
+	if apex, _ := rrsetOf(soa.msg.Answer, zone, dns.TypeSOA); len(apex) == 0 {
+		return nil, false, nil
+	}
+
+	zoneEntry := DNSSECZone{Name: zone, Status: DNSSECInsecure}
+	if v.provesNoDS(&zoneEntry, response, zone) {
+		v.report.Status = DNSSECInsecure
+		if keys, err := v.query(zone, dns.TypeDNSKEY); err == nil {
+			if rrset, _ := rrsetOf(keys.msg.Answer, zone, dns.TypeDNSKEY); len(rrset) > 0 {
+				v.report.Warnings = append(v.report.Warnings, fmt.Sprintf("%s publishes DNSKEYs but has no DS record in %s, so it cannot be validated", zone, v.zone))
+			}
+		}
+	} else {
+		v.fail(&zoneEntry, "%s has no DS record and %s does not prove its absence", zone, v.zone)
+	}
+	v.report.Zones = append(v.report.Zones, zoneEntry)
+	return nil, true, nil
+}
+
+// enterZone checks that one of the zone's DNSKEYs matches a DS record and
+// signs the DNSKEY RRset, and makes the keys the ones to validate with.
+func (v *dnssecValidator) enterZone(zone string, ds []*dns.DS) error {
+	// delegation may already have started the entry with the DS signatures.
+	zoneEntry := DNSSECZone{Name: zone}
+	if n := len(v.report.Zones); n > 0 && v.report.Zones[n-1].Name == zone {
+		zoneEntry = v.report.Zones[n-1]
+		v.report.Zones = v.report.Zones[:n-1]
+	}
+	zoneEntry.Status = DNSSECSecure
+	defer func() { v.report.Zones = append(v.report.Zones, zoneEntry) }()
+
+	response, err := v.query(zone, dns.TypeDNSKEY)
+	if err != nil {
+		return err
+	}
+	rrset, sigs := rrsetOf(response.msg.Answer, zone, dns.TypeDNSKEY)
+
+	var keys, entryPoints []*dns.DNSKEY
+	for _, rr := range rrset {
+		key := rr.(*dns.DNSKEY)
+		keys = append(keys, key)
+		zoneEntry.Keys = append(zoneEntry.Keys, DNSSECKey{
+			KeyTag:           key.KeyTag(),
+			Algorithm:        algorithmName(key.Algorithm),
+			Flags:            key.Flags,
+			SecureEntryPoint: key.Flags&dns.SEP != 0,
+		})
+		v.addAlgorithm(key.Algorithm)
+	}
+
+	for _, record := range ds {
+		delegation := DNSSECDelegation{KeyTag: record.KeyTag, Algorithm: algorithmName(record.Algorithm), DigestType: record.DigestType}
+		for _, key := range keys {
+			if key.KeyTag() != record.KeyTag || key.Algorithm != record.Algorithm {
+				continue
+			}
+			if digest := key.ToDS(record.DigestType); digest != nil && strings.EqualFold(digest.Digest, record.Digest) {
+				delegation.Matched = true
+				entryPoints = append(entryPoints, key)
+			}
+		}
+		zoneEntry.DS = append(zoneEntry.DS, delegation)
+	}
+
+	switch {
+	case len(keys) == 0:
+		v.fail(&zoneEntry, "%s has DS records but no DNSKEY records", zone)
+	case len(entryPoints) == 0:
+		v.fail(&zoneEntry, "no DNSKEY of %s matches its DS records", zone)
+	default:
+		v.zone, v.keys = zone, entryPoints
+		if !v.verify(&zoneEntry, rrset, sigs) {
+			v.fail(&zoneEntry, "the DNSKEY records of %s are not signed by a key matching its DS records", zone)
+		}
+	}
+	v.zone, v.keys = zone, keys
+	return nil
+}
+
+// checkTarget validates the A records of name with the keys of its zone or,
+// when it has none, the proof that they don't exist.
+func (v *dnssecValidator) checkTarget(name string) error {
+	response, err := v.query(name, dns.TypeA)
+	if err != nil {
+		return err
+	}
+	rrset, sigs := rrsetOf(response.msg.Answer, name, dns.TypeA)
+	if len(rrset) == 0 {
+		rrset, sigs = rrsetOf(response.msg.Answer, name, dns.TypeCNAME)
+	}
+
+	zoneEntry := &v.report.Zones[len(v.report.Zones)-1]
+	if len(rrset) == 0 {
+		switch {
+		case v.provesNoTarget(zoneEntry, response, name):
+		case response.msg.Rcode == dns.RcodeNameError:
+			v.fail(zoneEntry, "%s does not exist but %s does not prove it", name, v.zone)
+		default:
+			v.fail(zoneEntry, "%s has no A records but %s does not prove it", name, v.zone)
+		}
+		return nil
+	}
+	if !v.verify(zoneEntry, rrset, sigs) {
+		v.fail(zoneEntry, "the %s records of %s do not validate with the keys of %s",
+			dns.TypeToString[rrset[0].Header().Rrtype], name, v.zone)
+	}
+	return nil
+}
+
+// provesNoDS checks the NSEC or NSEC3 records of a negative DS response:
+// they must be signed by the parent and show there is no DS record for zone,
+// or, for NSEC3 opt-out, that unsigned delegations may exist there.
+func (v *dnssecValidator) provesNoDS(zoneEntry *DNSSECZone, response *DNSResponse, zone string) bool {
+	proven := false
+	for _, rrType := range []uint16{dns.TypeNSEC, dns.TypeNSEC3} {
+		for owner := range ownersOf(response.msg.Ns, rrType) {
+			rrset, sigs := rrsetOf(response.msg.Ns, owner, rrType)
+			if !v.verify(zoneEntry, rrset, sigs) {
+				continue
+			}
+			for _, rr := range rrset {
+				switch denial := rr.(type) {
+				case *dns.NSEC:
+					if strings.EqualFold(denial.Hdr.Name, zone) && !hasType(denial.TypeBitMap, dns.TypeDS) {
+						proven = true
+					}
+				case *dns.NSEC3:
+					if denial.Match(zone) && !hasType(denial.TypeBitMap, dns.TypeDS) {
+						proven = true
+					}
+					if denial.Cover(zone) && denial.Flags&1 != 0 {
+						proven = true
+					}
+				}
+			}
+		}
+	}
+	return proven
+}
+
+// provesNoTarget checks the NSEC or NSEC3 records of a negative answer for
+// the A records of name. They must be signed by the zone and show, for
+// NXDOMAIN, that neither name nor a wildcard that could stand in for it
+// exists, or otherwise that name has no A or CNAME records.
+func (v *dnssecValidator) provesNoTarget(zoneEntry *DNSSECZone, response *DNSResponse, name string) bool {
+	var nsecs []*dns.NSEC
+	var nsec3s []*dns.NSEC3
+	for _, rrType := range []uint16{dns.TypeNSEC, dns.TypeNSEC3} {
+		for owner := range ownersOf(response.msg.Ns, rrType) {
+			rrset, sigs := rrsetOf(response.msg.Ns, owner, rrType)
+			if !v.verify(zoneEntry, rrset, sigs) {
+				continue
+			}
+			for _, rr := range rrset {
+				switch denial := rr.(type) {
+				case *dns.NSEC:
+					nsecs = append(nsecs, denial)
+				case *dns.NSEC3:
+					nsec3s = append(nsec3s, denial)
+				}
+			}
+		}
+	}
+
+	if response.msg.Rcode == dns.RcodeNameError {
+		return nsecDeniesName(nsecs, name) || nsec3DeniesName(nsec3s, name)
+	}
+	for _, nsec := range nsecs {
+		if strings.EqualFold(nsec.Hdr.Name, name) && !hasType(nsec.TypeBitMap, dns.TypeA) && !hasType(nsec.TypeBitMap, dns.TypeCNAME) {
+			return true
+		}
+	}
+	for _, nsec3 := range nsec3s {
+		if nsec3.Match(name) && !hasType(nsec3.TypeBitMap, dns.TypeA) && !hasType(nsec3.TypeBitMap, dns.TypeCNAME) {
+			return true
+		}
+	}
+	return false
+}
+
+// nsecDeniesName reports whether nsecs prove name doesn't exist: one covers
+// name, and one covers the wildcard at its closest encloser (RFC 4035 5.4).
+func nsecDeniesName(nsecs []*dns.NSEC, name string) bool {
+	for _, nsec := range nsecs {
+		if !nsecCovers(nsec, name) {
+			continue
+		}
+		// The closest encloser is the longest ancestor of name that
+		// exists, which the covering record's owner or next name shares.
+		common := max(dns.CompareDomainName(name, nsec.Hdr.Name), dns.CompareDomainName(name, nsec.NextDomain))
+		wildcard := wildcardAt(ancestor(name, common))
+		for _, other := range nsecs {
+			if nsecCovers(other, wildcard) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// nsec3DeniesName reports whether nsec3s prove name doesn't exist: one
+// matches its closest encloser, and others cover the next closer name and
+// the wildcard at the closest encloser (RFC 5155 8.4).
+func nsec3DeniesName(nsec3s []*dns.NSEC3, name string) bool {
+	labels := dns.CountLabel(name)
+	for i := labels - 1; i >= 0; i-- {
+		encloser := ancestor(name, i)
+		if !nsec3Any(nsec3s, func(nsec3 *dns.NSEC3) bool { return nsec3.Match(encloser) }) {
+			continue
+		}
+		nextCloser := ancestor(name, i+1)
+		return nsec3Any(nsec3s, func(nsec3 *dns.NSEC3) bool { return nsec3Covers(nsec3, nextCloser) }) &&
+			nsec3Any(nsec3s, func(nsec3 *dns.NSEC3) bool { return nsec3Covers(nsec3, wildcardAt(encloser)) })
+	}
+	return false
+}
+
+// nsec3Covers reports whether name hashes strictly between the owner and
+// next hash of nsec3. NSEC3.Cover also accepts the owner hash itself.
+func nsec3Covers(nsec3 *dns.NSEC3, name string) bool {
+	return nsec3.Cover(name) && !nsec3.Match(name)
+}
+
+func nsec3Any(nsec3s []*dns.NSEC3, match func(*dns.NSEC3) bool) bool {
+	for _, nsec3 := range nsec3s {
+		if match(nsec3) {
+			return true
+		}
+	}
+	return false
+}
+
+// nsecCovers reports whether name falls strictly between the owner and next
+// name of nsec in canonical order. The last record of a zone wraps around
+// to the apex.
+func nsecCovers(nsec *dns.NSEC, name string) bool {
+	owner, next := nsec.Hdr.Name, nsec.NextDomain
+	if canonicalCompare(owner, next) < 0 {
+		return canonicalCompare(owner, name) < 0 && canonicalCompare(name, next) < 0
+	}
+	return canonicalCompare(owner, name) < 0 || canonicalCompare(name, next) < 0
+}
+
+// canonicalCompare orders names as DNSSEC does (RFC 4034 6.1): label by
+// label from the right, case-insensitively.
+func canonicalCompare(a, b string) int {
+	aLabels, bLabels := dns.SplitDomainName(strings.ToLower(a)), dns.SplitDomainName(strings.ToLower(b))
+	for i := 1; i <= len(aLabels) && i <= len(bLabels); i++ {
+		if c := strings.Compare(aLabels[len(aLabels)-i], bLabels[len(bLabels)-i]); c != 0 {
+			return c
+		}
+	}
+	return len(aLabels) - len(bLabels)
+}
+
+// ancestor returns the last labels labels of name, "." for none.
+func ancestor(name string, labels int) string {
+	all := dns.SplitDomainName(name)
+	if labels <= 0 || len(all) == 0 {
+		return "."
+	}
+	if labels > len(all) {
+		labels = len(all)
+	}
+	return dns.Fqdn(strings.Join(all[len(all)-labels:], "."))
+}
+
+// wildcardAt returns the wildcard name directly below encloser.
+func wildcardAt(encloser string) string {
+	if encloser == "." {
+		return "*."
+	}
+	return "*." + encloser
+}
+
+// verify checks the signatures over rrset made with the current zone's keys,
+// records them in zoneEntry, and reports whether at least one is valid.
+func (v *dnssecValidator) verify(zoneEntry *DNSSECZone, rrset []dns.RR, sigs []*dns.RRSIG) bool {
+	if len(rrset) == 0 {
+		return false
+	}
+	header := rrset[0].Header()
+
+	valid := false
+	for _, sig := range sigs {
+		signature := DNSSECSignature{
+			Owner:      header.Name,
+			Covers:     dns.TypeToString[sig.TypeCovered],
+			Signer:     sig.SignerName,
+			KeyTag:     sig.KeyTag,
+			Algorithm:  algorithmName(sig.Algorithm),
+			Inception:  time.Unix(int64(sig.Inception), 0).UTC(),
+			Expiration: time.Unix(int64(sig.Expiration), 0).UTC(),
+		}
+
+		var key *dns.DNSKEY
+		for _, candidate := range v.keys {
+			if candidate.KeyTag() == sig.KeyTag && candidate.Algorithm == sig.Algorithm {
+				key = candidate
+				break
+			}
+		}
+
+		switch {
+		case !strings.EqualFold(sig.SignerName, v.zone):
+			signature.Error = fmt.Sprintf("signed by %s instead of %s", sig.SignerName, v.zone)
+		case key == nil:
+			signature.Error = fmt.Sprintf("no DNSKEY with tag %d in %s", sig.KeyTag, v.zone)
+		default:
+			if err := sig.Verify(key, rrset); err != nil {
+				signature.Error = err.Error()
+			} else if !sig.ValidityPeriod(v.opts.Time) {
+				signature.Error = "signature is not valid at this time"
+				if v.opts.Time.After(signature.Expiration) {
+					signature.Error = "signature expired at " + signature.Expiration.Format(time.RFC3339)
+				}
+			} else {
+				signature.Valid = true
+			}
+		}
+
+		if signature.Valid {
+			valid = true
+			v.trackExpiry(signature)
+		}
+		zoneEntry.Signatures = append(zoneEntry.Signatures, signature)
+	}
+	return valid
+}
+
+func (v *dnssecValidator) trackExpiry(signature DNSSECSignature) {
+	report := v.report
+	if report.EarliestExpiry.IsZero() || signature.Expiration.Before(report.EarliestExpiry) {
+		report.EarliestExpiry = signature.Expiration
+	}
+	if remaining := signature.Expiration.Sub(v.opts.Time); remaining < v.opts.ExpiryWarning {
+		report.Warnings = append(report.Warnings, fmt.Sprintf("the RRSIG over %s %s (key %d) expires in %s",
+			signature.Owner, signature.Covers, signature.KeyTag, remaining.Round(time.Minute)))
+	}
+}
+
+func (v *dnssecValidator) addAlgorithm(algorithm uint8) {
+	name := algorithmName(algorithm)
+	for _, known := range v.report.Algorithms {
+		if known == name {
+			return
+		}
+	}
+	v.report.Algorithms = append(v.report.Algorithms, name)
+	if deprecatedAlgorithms[algorithm] {
+		v.report.Warnings = append(v.report.Warnings, fmt.Sprintf("algorithm %s is deprecated for signing", name))
+	}
+}
+
+func (v *dnssecValidator) fail(zoneEntry *DNSSECZone, format string, args ...interface{}) {
+	zoneEntry.Status = DNSSECBogus
+	v.report.Status = DNSSECBogus
+	v.report.Problems = append(v.report.Problems, fmt.Sprintf(format, args...))
+}
+
+func (v *dnssecValidator) query(name string, qtype uint16) (*DNSResponse, error) {
+	response, err := QueryDNS(v.ctx, name, dns.TypeToString[qtype], v.opts.DNS)
+	if err != nil {
+		return nil, err
+	}
+	if response.RCode != dns.RcodeToString[dns.RcodeSuccess] && response.RCode != dns.RcodeToString[dns.RcodeNameError] {
+		return nil, fmt.Errorf("%s %s query answered %s", name, dns.TypeToString[qtype], response.RCode)
+	}
+	return response, nil
+}
+
+// rrsetOf returns the records of type rrType owned by name in section and
+// the signatures over them.
+func rrsetOf(section []dns.RR, name string, rrType uint16) ([]dns.RR, []*dns.RRSIG) {
+	var rrset []dns.RR
+	var sigs []*dns.RRSIG
+	for _, rr := range section {
+		header := rr.Header()
+		if !strings.EqualFold(header.Name, name) {
+			continue
+		}
+		if header.Rrtype == rrType {
+			rrset = append(rrset, rr)
+		} else if sig, ok := rr.(*dns.RRSIG); ok && sig.TypeCovered == rrType {
+			sigs = append(sigs, sig)
+		}
+	}
+	return rrset, sigs
+}
+
+func ownersOf(section []dns.RR, rrType uint16) map[string]bool {
+	owners := map[string]bool{}
+	for _, rr := range section {
+		if rr.Header().Rrtype == rrType {
+			owners[rr.Header().Name] = true
+		}
+	}
+	return owners
+}
+
+func hasType(bitmap []uint16, rrType uint16) bool {
+	for _, t := range bitmap {
+		if t == rrType {
+			return true
+		}
+	}
+	return false
+}
+
+func algorithmName(algorithm uint8) string {
+	if name, ok := dns.AlgorithmToString[algorithm]; ok {
+		return name
+	}
+	return fmt.Sprintf("ALG%d", algorithm)
+}
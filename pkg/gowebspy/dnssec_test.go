@@ -0,0 +1,268 @@
+package gowebspy
+
+import (
+	"context"
+	"crypto"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// testSigningZone is a zone with a key signing key and a zone signing key.
+type testSigningZone struct {
+	name     string
+	ksk, zsk *dns.DNSKEY
+	kskPriv  crypto.Signer
+	zskPriv  crypto.Signer
+}
+
+func newTestSigningZone(t *testing.T, name string) *testSigningZone {
+	zone := &testSigningZone{name: name}
+	zone.ksk, zone.kskPriv = generateTestKey(t, name, 257)
+	zone.zsk, zone.zskPriv = generateTestKey(t, name, 256)
+	return zone
+}
+
+func generateTestKey(t *testing.T, zone string, flags uint16) (*dns.DNSKEY, crypto.Signer) {
+	key := &dns.DNSKEY{
+		Hdr:       dns.RR_Header{Name: zone, Rrtype: dns.TypeDNSKEY, Class: dns.ClassINET, Ttl: 3600},
+		Flags:     flags,
+		Protocol:  3,
+		Algorithm: dns.ECDSAP256SHA256,
+	}
+	priv, err := key.Generate(256)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	return key, priv.(crypto.Signer)
+}
+
+// sign returns rrset followed by its signature made with the zone's ZSK, or
+// its KSK for the DNSKEY RRset.
+func (z *testSigningZone) sign(t *testing.T, rrset []dns.RR, expiration time.Time) []dns.RR {
+	key, priv := z.zsk, z.zskPriv
+	if rrset[0].Header().Rrtype == dns.TypeDNSKEY {
+		key, priv = z.ksk, z.kskPriv
+	}
+	sig := &dns.RRSIG{
+		Hdr:        dns.RR_Header{Name: rrset[0].Header().Name, Rrtype: dns.TypeRRSIG, Class: dns.ClassINET, Ttl: rrset[0].Header().Ttl},
+		Algorithm:  key.Algorithm,
+		Inception:  uint32(expiration.Add(-60 * 24 * time.Hour).Unix()),
+		Expiration: uint32(expiration.Unix()),
+		KeyTag:     key.KeyTag(),
+		SignerName: z.name,
+	}
+	if err := sig.Sign(priv, rrset); err != nil {
+		t.Fatalf("Failed to sign %s: %v", rrset[0].Header().Name, err)
+	}
+	return append(append([]dns.RR{}, rrset...), sig)
+}
+
+func (z *testSigningZone) keys() []dns.RR {
+	return []dns.RR{z.ksk, z.zsk}
+}
+
+func (z *testSigningZone) ds() dns.RR {
+	return z.ksk.ToDS(dns.SHA256)
+}
+
+func mustRR(t *testing.T, record string) dns.RR {
+	rr, err := dns.NewRR(record)
+	if err != nil {
+		t.Fatalf("Invalid record %q: %v", record, err)
+	}
+	return rr
+}
+
+// dnssecTestServer serves a signed hierarchy: the root, test., the signed
+// example.test. and the unsigned plain.test. It returns the server address,
+// the trust anchor and the time to validate at.
+func dnssecTestServer(t *testing.T) (string, string, time.Time) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	valid := now.Add(30 * 24 * time.Hour)
+
+	root := newTestSigningZone(t, ".")
+	tld := newTestSigningZone(t, "test.")
+	example := newTestSigningZone(t, "example.test.")
+
+	answers := map[string][]dns.RR{
+		". DNSKEY":              root.sign(t, root.keys(), valid),
+		"test. DS":              root.sign(t, []dns.RR{tld.ds()}, valid),
+		"test. DNSKEY":          tld.sign(t, tld.keys(), valid),
+		"example.test. DS":      tld.sign(t, []dns.RR{example.ds()}, valid),
+		"example.test. DNSKEY":  example.sign(t, example.keys(), valid),
+		"example.test. SOA":     example.sign(t, []dns.RR{mustRR(t, "example.test. 300 IN SOA ns1.example.test. hostmaster.example.test. 1 7200 900 1209600 300")}, valid),
+		"plain.test. SOA":       {mustRR(t, "plain.test. 300 IN SOA ns1.plain.test. hostmaster.plain.test. 1 7200 900 1209600 300")},
+		"www.plain.test. A":     {mustRR(t, "www.plain.test. 300 IN A 192.0.2.20")},
+		"www.example.test. A":   example.sign(t, []dns.RR{mustRR(t, "www.example.test. 300 IN A 192.0.2.10")}, now.Add(48*time.Hour)),
+		"stale.example.test. A": example.sign(t, []dns.RR{mustRR(t, "stale.example.test. 300 IN A 192.0.2.11")}, now.Add(-time.Hour)),
+	}
+	forged := example.sign(t, []dns.RR{mustRR(t, "forged.example.test. 300 IN A 192.0.2.12")}, valid)
+	forged[0] = mustRR(t, "forged.example.test. 300 IN A 198.51.100.1")
+	answers["forged.example.test. A"] = forged
+
+	// Part of the NSEC chain of example.test.: the apex record covers the
+	// wildcard, and the one of mail.example.test. covers nothere and
+	// nowild.
+	apexNSEC := example.sign(t, []dns.RR{mustRR(t, "example.test. 300 IN NSEC forged.example.test. A NS SOA RRSIG NSEC DNSKEY")}, valid)
+	mailNSEC := example.sign(t, []dns.RR{mustRR(t, "mail.example.test. 300 IN NSEC stale.example.test. MX RRSIG NSEC")}, valid)
+	authority := map[string][]dns.RR{
+		"plain.test. DS":           tld.sign(t, []dns.RR{mustRR(t, "plain.test. 300 IN NSEC www.test. NS RRSIG NSEC")}, valid),
+		"mail.example.test. A":     mailNSEC,
+		"nothere.example.test. A":  append(append([]dns.RR{}, apexNSEC...), mailNSEC...),
+		"nowild.example.test. A":   mailNSEC,
+		"unproven.example.test. A": nil,
+	}
+	nxdomain := map[string]bool{
+		"nothere.example.test. A":  true,
+		"nowild.example.test. A":   true,
+		"unproven.example.test. A": true,
+	}
+
+	server := dnsServer(t, func(w dns.ResponseWriter, req *dns.Msg) {
+		reply := new(dns.Msg)
+		reply.SetReply(req)
+		question := req.Question[0]
+		key := strings.ToLower(question.Name) + " " + dns.TypeToString[question.Qtype]
+		reply.Answer = answers[key]
+		reply.Ns = authority[key]
+		if nxdomain[key] {
+			reply.Rcode = dns.RcodeNameError
+		}
+		reply.SetEdns0(4096, true)
+		w.WriteMsg(reply)
+	})
+	return server, root.ds().String(), now
+}
+
+func TestCheckDNSSEC(t *testing.T) {
+	server, anchor, now := dnssecTestServer(t)
+	opts := DNSSECOptions{DNS: DNSQueryOptions{Servers: []string{server}}, TrustAnchors: []string{anchor}, Time: now}
+
+	report, err := CheckDNSSEC(context.Background(), "www.example.test", opts)
+	if err != nil {
+		t.Fatalf("CheckDNSSEC failed: %v", err)
+	}
+	if report.Status != DNSSECSecure || !report.Signed || len(report.Problems) != 0 {
+		t.Fatalf("Expected a secure chain, got %s: %v", report.Status, report.Problems)
+	}
+
+	var zones []string
+	for _, zone := range report.Zones {
+		zones = append(zones, zone.Name)
+		if zone.Status != DNSSECSecure || len(zone.Keys) != 2 || len(zone.DS) != 1 || !zone.DS[0].Matched {
+			t.Errorf("Unexpected zone %s: %+v", zone.Name, zone)
+		}
+	}
+	if strings.Join(zones, " ") != ". test. example.test." {
+		t.Errorf("Zones = %v, want the root, test. and example.test.", zones)
+	}
+	if len(report.Algorithms) != 1 || report.Algorithms[0] != "ECDSAP256SHA256" {
+		t.Errorf("Algorithms = %v", report.Algorithms)
+	}
+
+	if !report.EarliestExpiry.Equal(now.Add(48 * time.Hour)) {
+		t.Errorf("EarliestExpiry = %s, want the A record's signature", report.EarliestExpiry)
+	}
+	if len(report.Warnings) != 1 || !strings.Contains(report.Warnings[0], "www.example.test. A") {
+		t.Errorf("Expected a warning about the expiring signature, got %v", report.Warnings)
+	}
+}
+
+func TestCheckDNSSECInsecure(t *testing.T) {
+	server, anchor, now := dnssecTestServer(t)
+	opts := DNSSECOptions{DNS: DNSQueryOptions{Servers: []string{server}}, TrustAnchors: []string{anchor}, Time: now}
+
+	report, err := CheckDNSSEC(context.Background(), "www.plain.test", opts)
+	if err != nil {
+		t.Fatalf("CheckDNSSEC failed: %v", err)
+	}
+	if report.Status != DNSSECInsecure || report.Signed {
+		t.Fatalf("Expected an insecure delegation, got %s: %v", report.Status, report.Problems)
+	}
+	last := report.Zones[len(report.Zones)-1]
+	if last.Name != "plain.test." || last.Status != DNSSECInsecure || len(last.Signatures) != 1 || !last.Signatures[0].Valid {
+		t.Errorf("Expected a validated denial for plain.test., got %+v", last)
+	}
+}
+
+func TestCheckDNSSECDenial(t *testing.T) {
+	server, anchor, now := dnssecTestServer(t)
+	opts := DNSSECOptions{DNS: DNSQueryOptions{Servers: []string{server}}, TrustAnchors: []string{anchor}, Time: now}
+
+	for _, domain := range []string{"nothere.example.test", "mail.example.test"} {
+		report, err := CheckDNSSEC(context.Background(), domain, opts)
+		if err != nil {
+			t.Fatalf("CheckDNSSEC(%s) failed: %v", domain, err)
+		}
+		if report.Status != DNSSECSecure || len(report.Problems) != 0 {
+			t.Errorf("CheckDNSSEC(%s) = %s %v, want a secure denial", domain, report.Status, report.Problems)
+		}
+	}
+}
+
+func TestNSEC3DeniesName(t *testing.T) {
+	var hashes []string
+	for _, name := range []string{"example.test.", "www.example.test."} {
+		hashes = append(hashes, dns.HashName(name, dns.SHA1, 1, "AB"))
+	}
+	sort.Strings(hashes)
+	var nsec3s []*dns.NSEC3
+	for i, hash := range hashes {
+		next := hashes[(i+1)%len(hashes)]
+		nsec3s = append(nsec3s, mustRR(t, hash+".example.test. 300 IN NSEC3 1 0 1 AB "+next+" A RRSIG").(*dns.NSEC3))
+	}
+
+	if !nsec3DeniesName(nsec3s, "nothere.example.test.") {
+		t.Error("Expected the NSEC3 chain to prove nothere.example.test. doesn't exist")
+	}
+	if nsec3DeniesName(nsec3s, "www.example.test.") {
+		t.Error("Expected www.example.test., which exists, not to be denied")
+	}
+	if nsec3DeniesName(nsec3s[:0], "nothere.example.test.") {
+		t.Error("Expected no proof without records")
+	}
+}
+
+func TestCheckDNSSECBogus(t *testing.T) {
+	server, anchor, now := dnssecTestServer(t)
+	opts := DNSSECOptions{DNS: DNSQueryOptions{Servers: []string{server}}, TrustAnchors: []string{anchor}, Time: now}
+
+	tests := []struct {
+		domain  string
+		anchors []string
+		problem string
+		sigErr  string
+	}{
+		{"forged.example.test", nil, "A records of forged.example.test.", "bad signature"},
+		{"stale.example.test", nil, "A records of stale.example.test.", "expired"},
+		{"www.example.test", []string{". IN DS 12345 13 2 " + strings.Repeat("AB", 32)}, "no DNSKEY of . matches", ""},
+		{"unproven.example.test", nil, "unproven.example.test. does not exist but example.test. does not prove it", ""},
+		{"nowild.example.test", nil, "nowild.example.test. does not exist", ""},
+	}
+	for _, test := range tests {
+		opts := opts
+		if test.anchors != nil {
+			opts.TrustAnchors = test.anchors
+		}
+		report, err := CheckDNSSEC(context.Background(), test.domain, opts)
+		if err != nil {
+			t.Fatalf("CheckDNSSEC(%s) failed: %v", test.domain, err)
+		}
+		if report.Status != DNSSECBogus || len(report.Problems) != 1 || !strings.Contains(report.Problems[0], test.problem) {
+			t.Errorf("CheckDNSSEC(%s) = %s %v, want bogus with %q", test.domain, report.Status, report.Problems, test.problem)
+			continue
+		}
+		if test.sigErr == "" {
+			continue
+		}
+		zone := report.Zones[len(report.Zones)-1]
+		sig := zone.Signatures[len(zone.Signatures)-1]
+		if sig.Valid || !strings.Contains(sig.Error, test.sigErr) {
+			t.Errorf("CheckDNSSEC(%s) signature = %+v, want error %q", test.domain, sig, test.sigErr)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package gowebspy
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+
+	"golang.org/x/net/idna"
+	"golang.org/x/net/publicsuffix"
+)
+
+// hostProfile converts host names to their ASCII (punycode) form. It is the
+// lookup profile without the strict STD3 and hyphen rules, which would reject
+// names seen in the wild such as _dmarc.example.com or r3---sn-x.example.com.
+var hostProfile = idna.New(
+	idna.MapForLookup(),
+	idna.BidiRule(),
+	idna.Transitional(false),
+	idna.StrictDomainName(false),
+	idna.CheckHyphens(false),
+)
+
+// NormalizeHost returns host in the form used on the wire: lower case, without
+// a trailing dot, and with internationalized labels converted to punycode, so
+// "Bücher.Example." becomes "xn--bcher-kva.example". IP addresses are returned
+// unchanged apart from IPv6 brackets being removed.
+func NormalizeHost(host string) (string, error) {
+	host = strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+	if ip := net.ParseIP(host); ip != nil {
+		return ip.String(), nil
+	}
+
+	host = strings.TrimSuffix(host, ".")
+	if host == "" {
+		return "", errors.New("empty host name")
+	}
+	ascii, err := hostProfile.ToASCII(host)
+	if err != nil {
+		return "", fmt.Errorf("invalid host name %q: %w", host, err)
+	}
+	return strings.ToLower(ascii), nil
+}
+
+// DisplayHost returns host with punycode labels converted back to Unicode for
+// display. Names that do not decode are returned as they are.
+func DisplayHost(host string) string {
+	unicode, err := idna.Display.ToUnicode(host)
+	if err != nil {
+		return host
+	}
+	return unicode
+}
+
+// RegistrableDomain returns the domain a registrant controls for host, which
+// is its public suffix plus one label: foo.example.co.uk gives example.co.uk.
+// It uses the Public Suffix List compiled into golang.org/x/net. See
+// SuffixList.RegistrableDomain to use a newer copy of the list.
+func RegistrableDomain(host string) (string, error) {
+	var list *SuffixList
+	return list.RegistrableDomain(host)
+}
+
+// SuffixList is a Public Suffix List loaded at run time, for when the list
+// embedded in the binary is too old. A nil *SuffixList uses the embedded list.
+type SuffixList struct {
+	rules map[string]suffixRule
+}
+
+type suffixRule struct {
+	exception bool
+	icann     bool
+}
+
+// LoadSuffixList reads a list in the public_suffix_list.dat format, as
+// published at https://publicsuffix.org/list/public_suffix_list.dat.
+func LoadSuffixList(path string) (*SuffixList, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read public suffix list: %w", err)
+	}
+	defer file.Close()
+	return ParseSuffixList(file)
+}
+
+// ParseSuffixList parses a list in the public_suffix_list.dat format. Rules in
+// the ICANN section are reported as ICANN suffixes; the rest as private ones.
+func ParseSuffixList(r io.Reader) (*SuffixList, error) {
+	list := &SuffixList{rules: map[string]suffixRule{}}
+	icann := false
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case strings.HasPrefix(line, "// ===BEGIN ICANN DOMAINS==="):
+			icann = true
+			continue
+		case strings.HasPrefix(line, "// ===END ICANN DOMAINS==="):
+			icann = false
+			continue
+		case line == "" || strings.HasPrefix(line, "//"):
+			continue
+		}
+
+		rule := suffixRule{icann: icann}
+		name := strings.Fields(line)[0]
+		// Wildcards keep their "*." prefix as part of the key, since a
+		// wildcard and a plain rule for the same name can both exist.
+		prefix := ""
+		if strings.HasPrefix(name, "!") {
+			rule.exception = true
+			name = name[1:]
+		} else if strings.HasPrefix(name, "*.") {
+			prefix = "*."
+			name = name[2:]
+		}
+
+		name, err := NormalizeHost(name)
+		if err != nil {
+			return nil, fmt.Errorf("invalid public suffix rule %q: %w", line, err)
+		}
+		list.rules[prefix+name] = rule
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read public suffix list: %w", err)
+	}
+	if len(list.rules) == 0 {
+		return nil, errors.New("public suffix list contains no rules")
+	}
+	return list, nil
+}
+
+// PublicSuffix returns the public suffix of an already normalized domain and
+// whether it is managed by ICANN. Domains no rule covers fall back to their
+// last label, as the list's algorithm prescribes.
+func (l *SuffixList) PublicSuffix(domain string) (string, bool) {
+	if l == nil {
+		return publicsuffix.PublicSuffix(domain)
+	}
+
+	labels := strings.Split(domain, ".")
+	for i := range labels {
+		name := strings.Join(labels[i:], ".")
+		if rule, ok := l.rules[name]; ok && rule.exception {
+			return strings.Join(labels[i+1:], "."), rule.icann
+		}
+		if rule, ok := l.rules["*."+name]; ok && i > 0 {
+			return strings.Join(labels[i-1:], "."), rule.icann
+		}
+		if rule, ok := l.rules[name]; ok {
+			return name, rule.icann
+		}
+	}
+	return labels[len(labels)-1], false
+}
+
+// RegistrableDomain is the package-level RegistrableDomain using l.
+func (l *SuffixList) RegistrableDomain(host string) (string, error) {
+	host, err := NormalizeHost(host)
+	if err != nil {
+		return "", err
+	}
+	if net.ParseIP(host) != nil {
+		return "", fmt.Errorf("%s is an IP address, not a domain", host)
+	}
+	if strings.Contains(host, "..") || strings.HasPrefix(host, ".") {
+		return "", fmt.Errorf("invalid host name %q: empty label", host)
+	}
+
+	suffix, _ := l.PublicSuffix(host)
+	if host == suffix {
+		return "", fmt.Errorf("%s is a public suffix", host)
+	}
+	rest := strings.TrimSuffix(host, "."+suffix)
+	return rest[strings.LastIndex(rest, ".")+1:] + "." + suffix, nil
+}
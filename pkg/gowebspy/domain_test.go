@@ -0,0 +1,120 @@
+package gowebspy
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNormalizeHost(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"Example.COM.", "example.com"},
+		{"münchen.de", "xn--mnchen-3ya.de"},
+		{"xn--mnchen-3ya.de", "xn--mnchen-3ya.de"},
+		{"_dmarc.example.com", "_dmarc.example.com"},
+		{"[2001:db8::1]", "2001:db8::1"},
+		{"192.0.2.1", "192.0.2.1"},
+	}
+
+	for _, test := range tests {
+		result, err := NormalizeHost(test.input)
+		if err != nil || result != test.expected {
+			t.Errorf("NormalizeHost(%q) = %q, %v, want %q", test.input, result, err, test.expected)
+		}
+	}
+
+	if _, err := NormalizeHost(""); err == nil {
+		t.Error("NormalizeHost(\"\") expected an error")
+	}
+}
+
+func TestDisplayHost(t *testing.T) {
+	if result := DisplayHost("xn--mnchen-3ya.de"); result != "münchen.de" {
+		t.Errorf("DisplayHost = %q, want münchen.de", result)
+	}
+}
+
+func TestRegistrableDomain(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		wantErr  bool
+	}{
+		{"foo.example.co.uk", "example.co.uk", false},
+		{"www.example.com", "example.com", false},
+		{"example.com", "example.com", false},
+		{"a.b.c.example.com.au", "example.com.au", false},
+		{"foo.bar.github.io", "bar.github.io", false},
+		{"www.Bücher.de", "xn--bcher-kva.de", false},
+		{"shop.例え.jp", "xn--r8jz45g.jp", false},
+		{"co.uk", "", true},
+		{"192.0.2.1", "", true},
+	}
+
+	for _, test := range tests {
+		result, err := RegistrableDomain(test.input)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("RegistrableDomain(%q) = %q, expected an error", test.input, result)
+			}
+			continue
+		}
+		if err != nil || result != test.expected {
+			t.Errorf("RegistrableDomain(%q) = %q, %v, want %q", test.input, result, err, test.expected)
+		}
+	}
+}
+
+const testSuffixList = `// A trimmed copy of the list format.
+// ===BEGIN ICANN DOMAINS===
+uk
+co.uk
+ck
+*.ck
+!www.ck
+рф
+// ===END ICANN DOMAINS===
+// ===BEGIN PRIVATE DOMAINS===
+example.net
+// ===END PRIVATE DOMAINS===
+`
+
+func TestSuffixList(t *testing.T) {
+	list, err := ParseSuffixList(strings.NewReader(testSuffixList))
+	if err != nil {
+		t.Fatalf("ParseSuffixList failed: %v", err)
+	}
+
+	tests := []struct {
+		input    string
+		expected string
+		icann    bool
+	}{
+		{"foo.example.co.uk", "example.co.uk", true},
+		{"a.b.ck", "a.b.ck", true},
+		{"www.ck", "www.ck", true},
+		{"sub.www.ck", "www.ck", true},
+		{"пример.рф", "xn--e1afmkfd.xn--p1ai", true},
+		{"site.example.net", "site.example.net", false},
+		{"deep.site.example.net", "site.example.net", false},
+		{"foo.unlisted", "foo.unlisted", false},
+	}
+
+	for _, test := range tests {
+		result, err := list.RegistrableDomain(test.input)
+		if err != nil || result != test.expected {
+			t.Errorf("RegistrableDomain(%q) = %q, %v, want %q", test.input, result, err, test.expected)
+			continue
+		}
+		host, _ := NormalizeHost(test.input)
+		if _, icann := list.PublicSuffix(host); icann != test.icann {
+			t.Errorf("PublicSuffix(%q) icann = %v, want %v", host, icann, test.icann)
+		}
+	}
+
+	if _, err := ParseSuffixList(strings.NewReader("// nothing here\n")); err == nil {
+		t.Error("ParseSuffixList of an empty list expected an error")
+	}
+}
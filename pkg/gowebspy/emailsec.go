@@ -0,0 +1,813 @@
+package gowebspy
+
+import (
+	"bufio"
+	"context"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+const (
+	defaultEmailTimeout = 10 * time.Second
+	// spfLookupLimit and spfVoidLookupLimit are from RFC 7208 section 4.6.4.
+	spfLookupLimit     = 10
+	spfVoidLookupLimit = 2
+	maxMTASTSPolicy    = 64 << 10
+)
+
+// DefaultDKIMSelectors are tried when no selectors are given. DKIM selectors
+// cannot be listed, so these are just the ones common providers use.
+var DefaultDKIMSelectors = []string{"default", "google", "selector1", "selector2", "k1", "k2", "s1", "s2", "dkim", "mail"}
+
+type EmailSecurityOptions struct {
+	// DNS is used for the TXT and MX lookups.
+	DNS DNSQueryOptions
+	// DKIMSelectors are looked up under _domainkey. Defaults to
+	// DefaultDKIMSelectors.
+	DKIMSelectors []string
+	// HTTPClient fetches the MTA-STS policy. Redirects are never followed,
+	// as RFC 8461 requires.
+	HTTPClient *http.Client
+	// Timeout covers the whole check.
+	Timeout time.Duration
+}
+
+func (o EmailSecurityOptions) withDefaults() EmailSecurityOptions {
+	if len(o.DKIMSelectors) == 0 {
+		o.DKIMSelectors = DefaultDKIMSelectors
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = defaultEmailTimeout
+	}
+	var client http.Client
+	if o.HTTPClient != nil {
+		client = *o.HTTPClient
+	}
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	o.HTTPClient = &client
+	return o
+}
+
+// EmailSecurityReport interprets a domain's email authentication records.
+// Each part lists its own Errors, which make the record ineffective, and
+// Warnings for weaker configurations.
+type EmailSecurityReport struct {
+	Domain string
+	SPF    *SPFResult
+	DMARC  *DMARCResult
+	// DKIM has an entry for each selector that has a key or whose lookup
+	// failed.
+	DKIM   []DKIMResult
+	MTASTS *MTASTSResult
+	TLSRPT *TLSRPTResult
+	BIMI   *BIMIResult
+	// Score is out of 100; Grade is A to F.
+	Score int
+	Grade string
+	// Warnings are about the setup as a whole, such as no DKIM key found.
+	Warnings []string
+}
+
+type SPFResult struct {
+	Record string
+	// All is the final "all" mechanism with its qualifier, e.g. "-all".
+	All string
+	// Lookups counts the terms that need DNS queries, following includes
+	// and redirects, against the limit of 10.
+	Lookups     int
+	VoidLookups int
+	Includes    []string
+	Errors      []string
+	Warnings    []string
+}
+
+type DMARCResult struct {
+	Record string
+	// Domain is where the record was found: _dmarc under the domain itself
+	// or under its organizational domain.
+	Domain          string
+	Policy          string
+	SubdomainPolicy string
+	Percent         int
+	AlignDKIM       string
+	AlignSPF        string
+	ReportURIs      []string
+	ForensicURIs    []string
+	Errors          []string
+	Warnings        []string
+}
+
+type DKIMResult struct {
+	Selector string
+	Record   string
+	KeyType  string
+	KeyBits  int
+	// Testing is the t=y flag; Revoked means the key is empty.
+	Testing  bool
+	Revoked  bool
+	Errors   []string
+	Warnings []string
+}
+
+type MTASTSResult struct {
+	Record string
+	ID     string
+	Policy *MTASTSPolicy
+	// PolicyURL is where the policy was fetched from.
+	PolicyURL string
+	Errors    []string
+	Warnings  []string
+}
+
+type MTASTSPolicy struct {
+	Version string
+	Mode    string
+	MX      []string
+	MaxAge  int
+}
+
+type TLSRPTResult struct {
+	Record     string
+	ReportURIs []string
+	Errors     []string
+	Warnings   []string
+}
+
+type BIMIResult struct {
+	Record string
+	// Logo is the l= SVG URL and Authority the a= certificate (VMC) URL.
+	Logo      string
+	Authority string
+	Errors    []string
+	Warnings  []string
+}
+
+// CheckEmailSecurity looks up and validates the SPF, DMARC, DKIM, MTA-STS,
+// TLS-RPT and BIMI records of domain and grades the result. Lookup failures
+// are reported as errors of the record concerned.
+func CheckEmailSecurity(ctx context.Context, domain string, opts EmailSecurityOptions) (*EmailSecurityReport, error) {
+	opts = opts.withDefaults()
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+
+	domain, err := NormalizeHost(domain)
+	if err == nil && net.ParseIP(domain) != nil {
+		err = fmt.Errorf("%s is an IP address, not a mail domain", domain)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("email security check failed: %w", err)
+	}
+
+	c := &emailChecker{ctx: ctx, opts: opts, txtCache: map[string][]string{}}
+	report := &EmailSecurityReport{
+		Domain: domain,
+		SPF:    c.checkSPF(domain),
+		DMARC:  c.checkDMARC(domain),
+		MTASTS: c.checkMTASTS(domain),
+		TLSRPT: c.checkTLSRPT(domain),
+		BIMI:   c.checkBIMI(domain),
+	}
+	for _, selector := range opts.DKIMSelectors {
+		if dkim := c.checkDKIM(domain, selector); dkim != nil {
+			report.DKIM = append(report.DKIM, *dkim)
+		}
+	}
+
+	if len(report.DKIM) == 0 {
+		report.Warnings = append(report.Warnings, fmt.Sprintf("no DKIM key found for selectors %s; pass the selectors your mail provider uses", strings.Join(opts.DKIMSelectors, ", ")))
+	}
+	if report.MTASTS.Record != "" && report.TLSRPT.Record == "" {
+		report.Warnings = append(report.Warnings, "MTA-STS is set up without TLS-RPT, so delivery failures are not reported")
+	}
+	if report.BIMI.Record != "" && !dmarcEnforced(report.DMARC) {
+		report.Warnings = append(report.Warnings, "BIMI logos are only shown with a DMARC policy of quarantine or reject at pct=100")
+	}
+
+	report.Score, report.Grade = gradeEmailSecurity(report)
+	return report, nil
+}
+
+type emailChecker struct {
+	ctx      context.Context
+	opts     EmailSecurityOptions
+	txtCache map[string][]string
+}
+
+// txt returns the TXT records of name with their strings joined, or none
+// when the name does not exist.
+func (c *emailChecker) txt(name string) ([]string, error) {
+	if records, ok := c.txtCache[name]; ok {
+		return records, nil
+	}
+	response, err := QueryDNS(c.ctx, name, "TXT", c.opts.DNS)
+	if err != nil {
+		return nil, err
+	}
+	if response.RCode != dns.RcodeToString[dns.RcodeSuccess] && response.RCode != dns.RcodeToString[dns.RcodeNameError] {
+		return nil, fmt.Errorf("TXT lookup of %s answered %s", name, response.RCode)
+	}
+
+	var records []string
+	for _, record := range response.Answer {
+		if txt, ok := record.rr.(*dns.TXT); ok {
+			records = append(records, strings.Join(txt.Txt, ""))
+		}
+	}
+	c.txtCache[name] = records
+	return records, nil
+}
+
+// versioned returns the records of name whose first tag is version, such as
+// "v=spf1", compared case-insensitively.
+func (c *emailChecker) versioned(name, version string) ([]string, error) {
+	records, err := c.txt(name)
+	if err != nil {
+		return nil, err
+	}
+	var matching []string
+	for _, record := range records {
+		first := strings.TrimSpace(strings.SplitN(strings.SplitN(record, ";", 2)[0], " ", 2)[0])
+		if strings.EqualFold(first, version) {
+			matching = append(matching, record)
+		}
+	}
+	return matching, nil
+}
+
+// single picks the one record of a kind, reporting a missing record or
+// several of them, which receivers treat as none.
+func single(kind, name string, records []string, err error, errs *[]string) string {
+	switch {
+	case err != nil:
+		*errs = append(*errs, fmt.Sprintf("%s lookup failed: %v", kind, err))
+	case len(records) == 0:
+		*errs = append(*errs, fmt.Sprintf("no %s record at %s", kind, name))
+	case len(records) > 1:
+		*errs = append(*errs, fmt.Sprintf("%d %s records at %s; there must be exactly one", len(records), kind, name))
+	default:
+		return records[0]
+	}
+	return ""
+}
+
+func (c *emailChecker) checkSPF(domain string) *SPFResult {
+	result := &SPFResult{}
+	records, err := c.versioned(domain, "v=spf1")
+	result.Record = single("SPF", domain, records, err, &result.Errors)
+	if result.Record == "" {
+		return result
+	}
+
+	result.All = c.walkSPF(result, domain, result.Record, map[string]bool{domain: true})
+	switch result.All {
+	case "-all":
+	case "~all":
+		result.Warnings = append(result.Warnings, "~all only soft-fails mail from other senders; -all rejects it")
+	case "?all":
+		result.Warnings = append(result.Warnings, "?all makes no statement about other senders")
+	case "+all", "all":
+		result.Errors = append(result.Errors, result.All+" allows anyone to send mail as this domain")
+	case "":
+		result.Warnings = append(result.Warnings, "the record does not end in an all mechanism, so other senders are neutral")
+	}
+	if result.Lookups > spfLookupLimit {
+		result.Errors = append(result.Errors, fmt.Sprintf("%d DNS lookups exceed the limit of %d, so receivers return permerror", result.Lookups, spfLookupLimit))
+	}
+	if result.VoidLookups > spfVoidLookupLimit {
+		result.Errors = append(result.Errors, fmt.Sprintf("%d lookups found nothing, more than the limit of %d", result.VoidLookups, spfVoidLookupLimit))
+	}
+	return result
+}
+
+// walkSPF counts the lookups of domain's record and those it includes, and
+// returns the all mechanism that applies to domain. stack holds the domains
+// being walked, to stop include loops.
+func (c *emailChecker) walkSPF(result *SPFResult, domain, record string, stack map[string]bool) string {
+	var all, redirect string
+	for _, term := range strings.Fields(record)[1:] {
+		name, value, modifier := splitSPFTerm(term)
+		if modifier {
+			// exp= needs no lookup until a message fails, and unknown
+			// modifiers must be ignored.
+			if name == "redirect" {
+				result.Lookups++
+				redirect = value
+			}
+			continue
+		}
+
+		qualifier := ""
+		if name != "" && strings.ContainsAny(name[:1], "+-~?") {
+			qualifier, name = name[:1], name[1:]
+		}
+		switch name {
+		case "all":
+			all = qualifier + "all"
+		case "include":
+			result.Lookups++
+			if value == "" {
+				result.Errors = append(result.Errors, "include without a domain in "+domain)
+				continue
+			}
+			result.Includes = append(result.Includes, value)
+			c.followSPF(result, value, stack)
+		case "a", "mx":
+			result.Lookups++
+			c.countVoid(result, defaultString(value, domain), strings.ToUpper(name))
+		case "exists":
+			result.Lookups++
+			if value == "" {
+				result.Errors = append(result.Errors, "exists without a domain in "+domain)
+			}
+		case "ptr":
+			result.Lookups++
+			result.Warnings = append(result.Warnings, "the ptr mechanism is slow and deprecated (RFC 7208 5.5)")
+		case "ip4", "ip6":
+			if !validSPFNetwork(value, name == "ip6") {
+				result.Errors = append(result.Errors, fmt.Sprintf("invalid %s network %q in %s", name, value, domain))
+			}
+		default:
+			result.Errors = append(result.Errors, fmt.Sprintf("unknown mechanism %q in %s", term, domain))
+		}
+	}
+
+	// A redirect only applies when there is no all mechanism.
+	if redirect != "" && all == "" {
+		result.Includes = append(result.Includes, redirect)
+		return c.followSPF(result, redirect, stack)
+	}
+	return all
+}
+
+// followSPF walks the record of an included or redirected domain and returns
+// its all mechanism.
+func (c *emailChecker) followSPF(result *SPFResult, domain string, stack map[string]bool) string {
+	if strings.Contains(domain, "%") {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("%s uses macros and was not followed", domain))
+		return ""
+	}
+	domain = strings.ToLower(strings.TrimSuffix(domain, "."))
+	if stack[domain] {
+		result.Errors = append(result.Errors, fmt.Sprintf("%s includes itself", domain))
+		return ""
+	}
+
+	records, err := c.versioned(domain, "v=spf1")
+	switch {
+	case err != nil:
+		result.Errors = append(result.Errors, fmt.Sprintf("lookup of included %s failed: %v", domain, err))
+		return ""
+	case len(records) == 0:
+		result.VoidLookups++
+		result.Errors = append(result.Errors, fmt.Sprintf("%s has no SPF record, so including it is a permerror", domain))
+		return ""
+	case len(records) > 1:
+		result.Errors = append(result.Errors, fmt.Sprintf("%s has %d SPF records", domain, len(records)))
+		return ""
+	}
+
+	stack[domain] = true
+	defer delete(stack, domain)
+	return c.walkSPF(result, domain, records[0], stack)
+}
+
+// countVoid counts a lookup for an a or mx mechanism that finds nothing.
+func (c *emailChecker) countVoid(result *SPFResult, name, rrType string) {
+	if strings.Contains(name, "%") {
+		return
+	}
+	response, err := QueryDNS(c.ctx, name, rrType, c.opts.DNS)
+	if err == nil && len(response.Values(rrType)) == 0 {
+		result.VoidLookups++
+	}
+}
+
+// splitSPFTerm splits a mechanism at ":" or "/", or a modifier at "=".
+func splitSPFTerm(term string) (name, value string, modifier bool) {
+	if i := strings.IndexAny(term, ":/="); i >= 0 {
+		if term[i] == '=' {
+			return strings.ToLower(term[:i]), term[i+1:], true
+		}
+		name = strings.ToLower(term[:i])
+		if term[i] == '/' {
+			return name, "", false
+		}
+		value = term[i+1:]
+		// Only ip4 and ip6 keep their prefix length; for the others it
+		// follows the domain.
+		if bare := strings.TrimLeft(name, "+-~?"); bare != "ip4" && bare != "ip6" {
+			if slash := strings.Index(value, "/"); slash >= 0 {
+				value = value[:slash]
+			}
+		}
+		return name, value, false
+	}
+	return strings.ToLower(term), "", false
+}
+
+func validSPFNetwork(value string, ipv6 bool) bool {
+	if prefix, err := netip.ParsePrefix(value); err == nil {
+		return prefix.Addr().Is6() == ipv6
+	}
+	addr, err := netip.ParseAddr(value)
+	return err == nil && addr.Is6() == ipv6
+}
+
+func (c *emailChecker) checkDMARC(domain string) *DMARCResult {
+	result := &DMARCResult{Domain: "_dmarc." + domain}
+	records, err := c.versioned(result.Domain, "v=DMARC1")
+	if err == nil && len(records) == 0 {
+		// Receivers fall back to the organizational domain (RFC 7489 6.6.3).
+		if org, orgErr := RegistrableDomain(domain); orgErr == nil && org != domain {
+			if orgRecords, orgErr := c.versioned("_dmarc."+org, "v=DMARC1"); orgErr == nil && len(orgRecords) > 0 {
+				result.Domain, records = "_dmarc."+org, orgRecords
+			}
+		}
+	}
+	result.Record = single("DMARC", result.Domain, records, err, &result.Errors)
+	if result.Record == "" {
+		return result
+	}
+
+	tags := parseTagList(result.Record)
+	result.Policy = strings.ToLower(tags["p"])
+	result.SubdomainPolicy = strings.ToLower(tags["sp"])
+	result.AlignDKIM = strings.ToLower(defaultString(tags["adkim"], "r"))
+	result.AlignSPF = strings.ToLower(defaultString(tags["aspf"], "r"))
+	result.ReportURIs = splitURIs(tags["rua"])
+	result.ForensicURIs = splitURIs(tags["ruf"])
+	result.Percent = 100
+	if pct, ok := tags["pct"]; ok {
+		n, err := strconv.Atoi(pct)
+		if err != nil || n < 0 || n > 100 {
+			result.Errors = append(result.Errors, fmt.Sprintf("invalid pct %q", pct))
+		} else {
+			result.Percent = n
+		}
+	}
+
+	switch result.Policy {
+	case "reject", "quarantine":
+	case "none":
+		result.Warnings = append(result.Warnings, "p=none only monitors; spoofed mail is still delivered")
+	case "":
+		result.Errors = append(result.Errors, "the record has no p= policy")
+	default:
+		result.Errors = append(result.Errors, fmt.Sprintf("unknown policy p=%s", result.Policy))
+	}
+	if result.SubdomainPolicy == "none" && result.Policy != "none" {
+		result.Warnings = append(result.Warnings, "sp=none leaves subdomains unprotected")
+	}
+	if result.Percent < 100 {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("pct=%d applies the policy to only part of the failing mail", result.Percent))
+	}
+	if len(result.ReportURIs) == 0 {
+		result.Warnings = append(result.Warnings, "no rua= address, so no aggregate reports are sent")
+	}
+	return result
+}
+
+// checkDKIM returns nil when selector has no key. A failed lookup is a
+// result with the error, since the key may well exist.
+func (c *emailChecker) checkDKIM(domain, selector string) *DKIMResult {
+	name := selector + "._domainkey." + domain
+	records, err := c.txt(name)
+	if err != nil {
+		return &DKIMResult{Selector: selector, Errors: []string{fmt.Sprintf("DKIM lookup failed: %v", err)}}
+	}
+	if len(records) == 0 {
+		return nil
+	}
+
+	result := &DKIMResult{Selector: selector, Record: records[0]}
+	if len(records) > 1 {
+		result.Errors = append(result.Errors, fmt.Sprintf("%d TXT records at %s; there must be exactly one", len(records), name))
+	}
+
+	tags := parseTagList(result.Record)
+	if version, ok := tags["v"]; ok && version != "DKIM1" {
+		result.Errors = append(result.Errors, fmt.Sprintf("unknown version v=%s", version))
+	}
+	result.KeyType = strings.ToLower(defaultString(tags["k"], "rsa"))
+	for _, flag := range strings.Split(tags["t"], ":") {
+		if strings.TrimSpace(flag) == "y" {
+			result.Testing = true
+			result.Warnings = append(result.Warnings, "t=y marks the key as testing, so verifiers may ignore failures")
+		}
+	}
+
+	key, ok := tags["p"]
+	if !ok {
+		result.Errors = append(result.Errors, "the record has no p= public key")
+		return result
+	}
+	if key == "" {
+		result.Revoked = true
+		result.Warnings = append(result.Warnings, "the key is revoked (empty p=)")
+		return result
+	}
+
+	der, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(key), ""))
+	if err != nil {
+		result.Errors = append(result.Errors, "the public key is not valid base64")
+		return result
+	}
+	switch result.KeyType {
+	case "rsa":
+		parsed, err := x509.ParsePKIXPublicKey(der)
+		if err != nil {
+			// Some signers publish a bare PKCS#1 key.
+			parsed, err = x509.ParsePKCS1PublicKey(der)
+		}
+		rsaKey, ok := parsed.(*rsa.PublicKey)
+		if err != nil || !ok {
+			result.Errors = append(result.Errors, "the public key is not a valid RSA key")
+			return result
+		}
+		result.KeyBits = rsaKey.N.BitLen()
+		switch {
+		case result.KeyBits < 1024:
+			result.Errors = append(result.Errors, fmt.Sprintf("a %d-bit RSA key is too short to be accepted", result.KeyBits))
+		case result.KeyBits < 2048:
+			result.Warnings = append(result.Warnings, fmt.Sprintf("a %d-bit RSA key is weak; use 2048 bits", result.KeyBits))
+		}
+	case "ed25519":
+		if len(der) != ed25519.PublicKeySize {
+			result.Errors = append(result.Errors, "the public key is not a valid Ed25519 key")
+			return result
+		}
+		result.KeyBits = 256
+	default:
+		result.Errors = append(result.Errors, fmt.Sprintf("unknown key type k=%s", result.KeyType))
+	}
+	return result
+}
+
+func (c *emailChecker) checkMTASTS(domain string) *MTASTSResult {
+	result := &MTASTSResult{}
+	name := "_mta-sts." + domain
+	records, err := c.versioned(name, "v=STSv1")
+	if err == nil && len(records) == 0 {
+		result.Warnings = append(result.Warnings, "no MTA-STS record, so senders may deliver over unauthenticated TLS")
+		return result
+	}
+	result.Record = single("MTA-STS", name, records, err, &result.Errors)
+	if result.Record == "" {
+		return result
+	}
+
+	result.ID = parseTagList(result.Record)["id"]
+	if result.ID == "" {
+		result.Errors = append(result.Errors, "the record has no id=")
+	}
+
+	result.PolicyURL = "https://mta-sts." + domain + "/.well-known/mta-sts.txt"
+	policy, err := c.fetchMTASTSPolicy(result.PolicyURL)
+	if err != nil {
+		result.Errors = append(result.Errors, fmt.Sprintf("failed to fetch the policy: %v", err))
+		return result
+	}
+	result.Policy = policy
+
+	switch policy.Mode {
+	case "enforce":
+	case "testing":
+		result.Warnings = append(result.Warnings, "mode: testing only reports failures; use enforce")
+	case "none":
+		result.Warnings = append(result.Warnings, "mode: none disables MTA-STS")
+	default:
+		result.Errors = append(result.Errors, fmt.Sprintf("invalid mode %q", policy.Mode))
+	}
+	if policy.Version != "STSv1" {
+		result.Errors = append(result.Errors, fmt.Sprintf("invalid version %q", policy.Version))
+	}
+	if policy.MaxAge <= 0 {
+		result.Errors = append(result.Errors, "max_age is missing")
+	} else if policy.MaxAge < 86400 {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("max_age of %ds is short; weeks are recommended", policy.MaxAge))
+	}
+	if len(policy.MX) == 0 && policy.Mode != "none" {
+		result.Errors = append(result.Errors, "the policy lists no mx patterns")
+	}
+
+	if response, err := QueryDNS(c.ctx, domain, "MX", c.opts.DNS); err == nil && policy.Mode != "none" {
+		for _, record := range response.Answer {
+			if mx, ok := record.rr.(*dns.MX); ok && !mtaSTSMatches(policy.MX, mx.Mx) {
+				result.Errors = append(result.Errors, fmt.Sprintf("MX host %s is not allowed by the policy", strings.TrimSuffix(mx.Mx, ".")))
+			}
+		}
+	}
+	return result
+}
+
+func (c *emailChecker) fetchMTASTSPolicy(url string) (*MTASTSPolicy, error) {
+	req, err := http.NewRequestWithContext(c.ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.opts.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+
+	policy := &MTASTSPolicy{}
+	scanner := bufio.NewScanner(io.LimitReader(resp.Body, maxMTASTSPolicy))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch strings.TrimSpace(key) {
+		case "version":
+			policy.Version = value
+		case "mode":
+			policy.Mode = value
+		case "mx":
+			policy.MX = append(policy.MX, value)
+		case "max_age":
+			policy.MaxAge, _ = strconv.Atoi(value)
+		}
+	}
+	return policy, scanner.Err()
+}
+
+// mtaSTSMatches reports whether host is allowed by one of the policy's mx
+// patterns, where "*.example.com" matches exactly one extra label.
+func mtaSTSMatches(patterns []string, host string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(strings.TrimSuffix(pattern, "."))
+		if pattern == host {
+			return true
+		}
+		if suffix, ok := strings.CutPrefix(pattern, "*."); ok {
+			if label, rest, found := strings.Cut(host, "."); found && label != "" && rest == suffix {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (c *emailChecker) checkTLSRPT(domain string) *TLSRPTResult {
+	result := &TLSRPTResult{}
+	name := "_smtp._tls." + domain
+	records, err := c.versioned(name, "v=TLSRPTv1")
+	if err == nil && len(records) == 0 {
+		return result
+	}
+	result.Record = single("TLS-RPT", name, records, err, &result.Errors)
+	if result.Record == "" {
+		return result
+	}
+
+	result.ReportURIs = splitURIs(parseTagList(result.Record)["rua"])
+	if len(result.ReportURIs) == 0 {
+		result.Errors = append(result.Errors, "the record has no rua= address")
+	}
+	for _, uri := range result.ReportURIs {
+		if !strings.HasPrefix(uri, "mailto:") && !strings.HasPrefix(uri, "https:") {
+			result.Errors = append(result.Errors, fmt.Sprintf("rua %q must be a mailto: or https: URI", uri))
+		}
+	}
+	return result
+}
+
+func (c *emailChecker) checkBIMI(domain string) *BIMIResult {
+	result := &BIMIResult{}
+	name := "default._bimi." + domain
+	records, err := c.versioned(name, "v=BIMI1")
+	if err == nil && len(records) == 0 {
+		return result
+	}
+	result.Record = single("BIMI", name, records, err, &result.Errors)
+	if result.Record == "" {
+		return result
+	}
+
+	tags := parseTagList(result.Record)
+	result.Logo = tags["l"]
+	result.Authority = tags["a"]
+	if result.Logo != "" && !strings.HasPrefix(result.Logo, "https://") {
+		result.Errors = append(result.Errors, "the logo (l=) must be served over HTTPS")
+	}
+	if result.Logo == "" && result.Authority == "" {
+		result.Warnings = append(result.Warnings, "the record has neither a logo nor a certificate, which declines BIMI")
+	}
+	if result.Authority == "" && result.Logo != "" {
+		result.Warnings = append(result.Warnings, "no Verified Mark Certificate (a=); most mailbox providers require one")
+	}
+	return result
+}
+
+func dmarcEnforced(dmarc *DMARCResult) bool {
+	return len(dmarc.Errors) == 0 && (dmarc.Policy == "quarantine" || dmarc.Policy == "reject") && dmarc.Percent == 100
+}
+
+// gradeEmailSecurity scores SPF (25), DMARC (30), DKIM (20), MTA-STS (15),
+// TLS-RPT (5) and BIMI (5). A record with errors scores nothing.
+func gradeEmailSecurity(report *EmailSecurityReport) (int, string) {
+	score := 0
+
+	if spf := report.SPF; spf.Record != "" && len(spf.Errors) == 0 {
+		switch spf.All {
+		case "-all":
+			score += 25
+		case "~all":
+			score += 20
+		default:
+			score += 10
+		}
+	}
+
+	if dmarc := report.DMARC; dmarc.Record != "" && len(dmarc.Errors) == 0 {
+		switch {
+		case dmarcEnforced(dmarc) && dmarc.Policy == "reject":
+			score += 30
+		case dmarcEnforced(dmarc):
+			score += 25
+		case dmarc.Policy != "none":
+			score += 18
+		default:
+			score += 10
+		}
+	}
+
+	for _, dkim := range report.DKIM {
+		if len(dkim.Errors) == 0 && !dkim.Revoked {
+			if dkim.KeyBits >= 2048 || dkim.KeyType == "ed25519" {
+				score += 20
+			} else {
+				score += 12
+			}
+			break
+		}
+	}
+
+	if mtaSTS := report.MTASTS; mtaSTS.Policy != nil && len(mtaSTS.Errors) == 0 {
+		switch mtaSTS.Policy.Mode {
+		case "enforce":
+			score += 15
+		case "testing":
+			score += 7
+		}
+	}
+	if tlsRPT := report.TLSRPT; tlsRPT.Record != "" && len(tlsRPT.Errors) == 0 {
+		score += 5
+	}
+	if bimi := report.BIMI; bimi.Record != "" && len(bimi.Errors) == 0 {
+		score += 5
+	}
+
+	return score, letterGrade(score)
+}
+
+// parseTagList parses a DKIM-style "tag=value; tag=value" list (RFC 6376
+// 3.2), as also used by DMARC, MTA-STS, TLS-RPT and BIMI records.
+func parseTagList(record string) map[string]string {
+	tags := map[string]string{}
+	for _, part := range strings.Split(record, ";") {
+		name, value, ok := strings.Cut(part, "=")
+		if !ok {
+			continue
+		}
+		tags[strings.ToLower(strings.TrimSpace(name))] = strings.TrimSpace(value)
+	}
+	return tags
+}
+
+func splitURIs(value string) []string {
+	var uris []string
+	for _, uri := range strings.Split(value, ",") {
+		if uri = strings.TrimSpace(uri); uri != "" {
+			uris = append(uris, uri)
+		}
+	}
+	return uris
+}
+
+func defaultString(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
@@ -0,0 +1,254 @@
+package gowebspy
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/miekg/dns"
+)
+
+// dkimRecord returns a DKIM record with a new RSA key of bits.
+func dkimRecord(t *testing.T, bits int, flags string) string {
+	key, err := rsa.GenerateKey(rand.Reader, bits)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatalf("Failed to marshal key: %v", err)
+	}
+	return "v=DKIM1; k=rsa; " + flags + "p=" + base64.StdEncoding.EncodeToString(der)
+}
+
+// emailTestServer serves the DNS records of good.test, which is set up as
+// recommended, and bad.test, which gets most things wrong, and their MTA-STS
+// policies. It returns the options to check them with.
+func emailTestServer(t *testing.T) EmailSecurityOptions {
+	records := map[string][]string{
+		"good.test. TXT":                    {"v=spf1 include:_spf.mail.test -all", "site-verification=1234"},
+		"_spf.mail.test. TXT":               {"v=spf1 ip4:192.0.2.0/24 ip6:2001:db8::/32 a:relay.mail.test -all"},
+		"relay.mail.test. A":                {"192.0.2.25"},
+		"_dmarc.good.test. TXT":             {"v=DMARC1; p=reject; rua=mailto:dmarc@good.test"},
+		"default._domainkey.good.test. TXT": {dkimRecord(t, 2048, "")},
+		"_mta-sts.good.test. TXT":           {"v=STSv1; id=20250301"},
+		"_smtp._tls.good.test. TXT":         {"v=TLSRPTv1; rua=mailto:tls@good.test"},
+		"default._bimi.good.test. TXT":      {"v=BIMI1; l=https://good.test/logo.svg; a=https://good.test/vmc.pem"},
+		"good.test. MX":                     {"10 mx1.mail.test."},
+
+		"bad.test. TXT":                      {"v=spf1 a mx include:chain.bad.test ptr +all"},
+		"chain.bad.test. TXT":                {"v=spf1 a:h1.test a:h2.test a:h3.test a:h4.test a:h5.test a:h6.test a:h7.test a:h8.test"},
+		"bad.test. MX":                       {"10 mx.other.test."},
+		"_dmarc.bad.test. TXT":               {"v=DMARC1; p=none; pct=50"},
+		"selector1._domainkey.bad.test. TXT": {dkimRecord(t, 1024, "t=y; ")},
+		"_mta-sts.bad.test. TXT":             {"v=STSv1; id=1"},
+	}
+
+	server := dnsServer(t, func(w dns.ResponseWriter, req *dns.Msg) {
+		reply := new(dns.Msg)
+		reply.SetReply(req)
+		question := req.Question[0]
+		values, ok := records[strings.ToLower(question.Name)+" "+dns.TypeToString[question.Qtype]]
+		switch {
+		case strings.EqualFold(question.Name, "broken._domainkey.good.test."):
+			reply.Rcode = dns.RcodeServerFailure
+		case !ok:
+			reply.Rcode = dns.RcodeNameError
+		}
+		for _, value := range values {
+			header := dns.RR_Header{Name: question.Name, Rrtype: question.Qtype, Class: dns.ClassINET, Ttl: 300}
+			if question.Qtype == dns.TypeTXT {
+				// Split into strings of at most 255 bytes, as long records are.
+				txt := &dns.TXT{Hdr: header}
+				for len(value) > 255 {
+					txt.Txt, value = append(txt.Txt, value[:255]), value[255:]
+				}
+				txt.Txt = append(txt.Txt, value)
+				reply.Answer = append(reply.Answer, txt)
+				continue
+			}
+			rr, err := dns.NewRR(header.String() + value)
+			if err != nil {
+				panic(err)
+			}
+			reply.Answer = append(reply.Answer, rr)
+		}
+		w.WriteMsg(reply)
+	})
+
+	policies := map[string]string{
+		"mta-sts.good.test": "version: STSv1\r\nmode: enforce\r\nmx: *.mail.test\r\nmax_age: 604800\r\n",
+		"mta-sts.bad.test":  "version: STSv1\nmode: testing\nmx: mx.bad.test\nmax_age: 3600\n",
+	}
+	web := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		policy, ok := policies[r.Host]
+		if !ok || r.URL.Path != "/.well-known/mta-sts.txt" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, policy)
+	}))
+	t.Cleanup(web.Close)
+
+	transport := web.Client().Transport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	transport.DialContext = func(ctx context.Context, network, _ string) (net.Conn, error) {
+		var dialer net.Dialer
+		return dialer.DialContext(ctx, network, web.Listener.Addr().String())
+	}
+
+	return EmailSecurityOptions{
+		DNS:        DNSQueryOptions{Servers: []string{server}},
+		HTTPClient: &http.Client{Transport: transport},
+	}
+}
+
+func TestCheckEmailSecurity(t *testing.T) {
+	opts := emailTestServer(t)
+
+	report, err := CheckEmailSecurity(context.Background(), "good.test", opts)
+	if err != nil {
+		t.Fatalf("CheckEmailSecurity failed: %v", err)
+	}
+	if report.Score != 100 || report.Grade != "A" {
+		t.Errorf("Score = %d (%s), want 100 (A)", report.Score, report.Grade)
+	}
+
+	for name, problems := range map[string][]string{
+		"SPF":     append(report.SPF.Errors, report.SPF.Warnings...),
+		"DMARC":   append(report.DMARC.Errors, report.DMARC.Warnings...),
+		"MTA-STS": append(report.MTASTS.Errors, report.MTASTS.Warnings...),
+		"TLS-RPT": append(report.TLSRPT.Errors, report.TLSRPT.Warnings...),
+		"BIMI":    append(report.BIMI.Errors, report.BIMI.Warnings...),
+		"report":  report.Warnings,
+	} {
+		if len(problems) != 0 {
+			t.Errorf("Unexpected %s problems: %v", name, problems)
+		}
+	}
+
+	if spf := report.SPF; spf.All != "-all" || spf.Lookups != 2 || len(spf.Includes) != 1 || spf.Includes[0] != "_spf.mail.test" {
+		t.Errorf("Unexpected SPF result: %+v", spf)
+	}
+	if dmarc := report.DMARC; dmarc.Policy != "reject" || dmarc.Percent != 100 || dmarc.AlignDKIM != "r" || len(dmarc.ReportURIs) != 1 {
+		t.Errorf("Unexpected DMARC result: %+v", dmarc)
+	}
+	if len(report.DKIM) != 1 || report.DKIM[0].Selector != "default" || report.DKIM[0].KeyBits != 2048 {
+		t.Errorf("Unexpected DKIM results: %+v", report.DKIM)
+	}
+	if policy := report.MTASTS.Policy; policy == nil || policy.Mode != "enforce" || policy.MaxAge != 604800 || report.MTASTS.ID != "20250301" {
+		t.Errorf("Unexpected MTA-STS result: %+v", report.MTASTS)
+	}
+	if report.BIMI.Logo != "https://good.test/logo.svg" {
+		t.Errorf("BIMI logo = %q", report.BIMI.Logo)
+	}
+}
+
+func TestCheckEmailSecurityProblems(t *testing.T) {
+	opts := emailTestServer(t)
+	opts.DKIMSelectors = []string{"default", "selector1"}
+
+	report, err := CheckEmailSecurity(context.Background(), "bad.test", opts)
+	if err != nil {
+		t.Fatalf("CheckEmailSecurity failed: %v", err)
+	}
+	if report.Grade != "F" {
+		t.Errorf("Grade = %s (%d), want F", report.Grade, report.Score)
+	}
+
+	contains := func(kind string, messages []string, want string) {
+		for _, message := range messages {
+			if strings.Contains(message, want) {
+				return
+			}
+		}
+		t.Errorf("Expected %s to mention %q, got %v", kind, want, messages)
+	}
+
+	if report.SPF.Lookups != 12 || report.SPF.All != "+all" {
+		t.Errorf("SPF lookups = %d and all = %q, want 12 and +all", report.SPF.Lookups, report.SPF.All)
+	}
+	contains("SPF errors", report.SPF.Errors, "exceed the limit of 10")
+	contains("SPF errors", report.SPF.Errors, "allows anyone")
+	contains("SPF errors", report.SPF.Errors, "found nothing")
+	contains("SPF warnings", report.SPF.Warnings, "ptr")
+
+	contains("DMARC warnings", report.DMARC.Warnings, "p=none")
+	contains("DMARC warnings", report.DMARC.Warnings, "pct=50")
+	contains("DMARC warnings", report.DMARC.Warnings, "rua")
+
+	if len(report.DKIM) != 1 || report.DKIM[0].KeyBits != 1024 || !report.DKIM[0].Testing {
+		t.Fatalf("Unexpected DKIM results: %+v", report.DKIM)
+	}
+	contains("DKIM warnings", report.DKIM[0].Warnings, "1024-bit")
+
+	contains("MTA-STS errors", report.MTASTS.Errors, "mx.other.test is not allowed")
+	contains("MTA-STS warnings", report.MTASTS.Warnings, "testing")
+	contains("MTA-STS warnings", report.MTASTS.Warnings, "max_age")
+	contains("warnings", report.Warnings, "without TLS-RPT")
+}
+
+func TestCheckEmailSecurityDKIMLookupFailure(t *testing.T) {
+	opts := emailTestServer(t)
+	opts.DKIMSelectors = []string{"broken"}
+
+	report, err := CheckEmailSecurity(context.Background(), "good.test", opts)
+	if err != nil {
+		t.Fatalf("CheckEmailSecurity failed: %v", err)
+	}
+	if len(report.DKIM) != 1 || len(report.DKIM[0].Errors) != 1 || !strings.Contains(report.DKIM[0].Errors[0], "lookup failed") {
+		t.Fatalf("Expected the failed lookup to be reported, got %+v", report.DKIM)
+	}
+	for _, warning := range report.Warnings {
+		if strings.Contains(warning, "no DKIM key found") {
+			t.Errorf("Expected no missing key warning for a failed lookup, got %q", warning)
+		}
+	}
+}
+
+func TestCheckEmailSecurityMissing(t *testing.T) {
+	opts := emailTestServer(t)
+
+	report, err := CheckEmailSecurity(context.Background(), "missing.test", opts)
+	if err != nil {
+		t.Fatalf("CheckEmailSecurity failed: %v", err)
+	}
+	if report.Score != 0 || report.Grade != "F" || len(report.DKIM) != 0 {
+		t.Errorf("Expected an F for a domain without records, got %d (%s)", report.Score, report.Grade)
+	}
+	if len(report.SPF.Errors) != 1 || len(report.DMARC.Errors) != 1 || report.MTASTS.Record != "" {
+		t.Errorf("Expected missing SPF and DMARC records, got %v and %v", report.SPF.Errors, report.DMARC.Errors)
+	}
+
+	for _, domain := range []string{"", "192.0.2.1"} {
+		if _, err := CheckEmailSecurity(context.Background(), domain, opts); err == nil {
+			t.Errorf("Expected an error for %q", domain)
+		}
+	}
+}
+
+func TestMTASTSMatches(t *testing.T) {
+	tests := []struct {
+		host string
+		want bool
+	}{
+		{"mx1.mail.test.", true},
+		{"MAIL.TEST", true},
+		{"a.b.mail.test", false},
+		{"mail.test.evil", false},
+	}
+	for _, test := range tests {
+		if got := mtaSTSMatches([]string{"*.mail.test", "mail.test"}, test.host); got != test.want {
+			t.Errorf("mtaSTSMatches(%s) = %t, want %t", test.host, got, test.want)
+		}
+	}
+}
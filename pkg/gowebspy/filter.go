@@ -14,8 +14,19 @@ type FilterOptions struct {
 	HeaderValueMatches   map[string]string
 	MinResponseTime      time.Duration
 	MaxResponseTime      time.Duration
+	// MinPhaseTime and MaxPhaseTime bound phases of WebsiteInfo.Timing,
+	// e.g. MaxPhaseTime[TimingTTFB]. Sites without timing don't match.
+	MinPhaseTime         map[TimingPhase]time.Duration
+	MaxPhaseTime         map[TimingPhase]time.Duration
 	SSLMustBeValid       bool
 	SSLMinDaysRemaining  int
+	SSLRevocationStatus  RevocationStatus
+	// CookieMustExist names cookies the site must set. CookieIssues
+	// matches sites with a cookie that has any of the issues, and
+	// CookiesMustBeClean those where no cookie has one.
+	CookieMustExist      []string
+	CookieIssues         []CookieIssue
+	CookiesMustBeClean   bool
 	ContentTypeMustMatch string
 	IPMustMatch          string
 	RequireIPv6          bool
@@ -51,6 +62,22 @@ func ApplyFilter(info *WebsiteInfo, opts *FilterOptions) bool {
 		return false
 	}
 	
+	if len(opts.MinPhaseTime) > 0 || len(opts.MaxPhaseTime) > 0 {
+		if info.Timing == nil {
+			return false
+		}
+		for phase, limit := range opts.MinPhaseTime {
+			if d, ok := info.Timing.Phase(phase); !ok || d < limit {
+				return false
+			}
+		}
+		for phase, limit := range opts.MaxPhaseTime {
+			if d, ok := info.Timing.Phase(phase); !ok || d > limit {
+				return false
+			}
+		}
+	}
+	
 	for _, headerKey := range opts.HeaderKeyMustExist {
 		if _, exists := info.Headers[headerKey]; !exists {
 			return false
@@ -88,10 +115,46 @@ func ApplyFilter(info *WebsiteInfo, opts *FilterOptions) bool {
 				return false
 			}
 		}
-	} else if opts.SSLMustBeValid {
+		
+		if opts.SSLRevocationStatus != "" {
+			// A certificate that wasn't checked counts as unknown.
+			status := RevocationUnknown
+			if info.SSLInfo.Revocation != nil && info.SSLInfo.Revocation.Status != "" {
+				status = info.SSLInfo.Revocation.Status
+			}
+			if status != opts.SSLRevocationStatus {
+				return false
+			}
+		}
+	} else if opts.SSLMustBeValid || opts.SSLRevocationStatus != "" {
 		return false
 	}
 	
+	for _, name := range opts.CookieMustExist {
+		if cookieByName(info.Cookies, name) == nil {
+			return false
+		}
+	}
+	
+	if len(opts.CookieIssues) > 0 || opts.CookiesMustBeClean {
+		found := false
+		for _, cookie := range info.Cookies {
+			for _, issue := range cookie.Issues {
+				if opts.CookiesMustBeClean {
+					return false
+				}
+				for _, wanted := range opts.CookieIssues {
+					if issue == wanted {
+						found = true
+					}
+				}
+			}
+		}
+		if len(opts.CookieIssues) > 0 && !found {
+			return false
+		}
+	}
+	
 	if opts.ContentTypeMustMatch != "" && !strings.Contains(info.ContentType, opts.ContentTypeMustMatch) {
 		return false
 	}
@@ -139,6 +202,15 @@ func ApplyFilter(info *WebsiteInfo, opts *FilterOptions) bool {
 	return true
 }
 
+func cookieByName(cookies []CookieInfo, name string) *CookieInfo {
+	for i := range cookies {
+		if cookies[i].Name == name {
+			return &cookies[i]
+		}
+	}
+	return nil
+}
+
 func BatchFilter(websites []*WebsiteInfo, opts *FilterOptions) []*WebsiteInfo {
 	var filtered []*WebsiteInfo
 	
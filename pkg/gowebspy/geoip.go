@@ -0,0 +1,173 @@
+package gowebspy
+
+import (
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// GeoDatabase looks up addresses in one or more local MaxMind DB (.mmdb)
+// files, such as GeoLite2 City and GeoLite2 ASN, or the DB-IP equivalents.
+// Answers from all files are merged, so a city database and an ASN database
+// can be used together. A nil *GeoDatabase finds nothing.
+type GeoDatabase struct {
+	readers []*maxminddb.Reader
+}
+
+// GeoLocation is where a GeoDatabase places an address. Fields the databases
+// have no data for are left empty.
+type GeoLocation struct {
+	IP          string
+	CountryCode string
+	Country     string
+	Region      string
+	City        string
+	// Coordinates is nil when no database has a location for the address.
+	Coordinates    *GeoCoordinates
+	TimeZone       string
+	ASN            uint32
+	ASOrganization string
+	// Network is the most specific network any of the databases matched.
+	Network string
+}
+
+type GeoCoordinates struct {
+	Latitude  float64
+	Longitude float64
+	// AccuracyRadius is in kilometres; 0 when unknown.
+	AccuracyRadius int
+}
+
+// geoRecord holds the fields gowebspy reads from the GeoIP2/GeoLite2 City,
+// Country and ASN schemas, which DB-IP's databases share.
+type geoRecord struct {
+	Country struct {
+		ISOCode string            `maxminddb:"iso_code"`
+		Names   map[string]string `maxminddb:"names"`
+	} `maxminddb:"country"`
+	Subdivisions []struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"subdivisions"`
+	City struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"city"`
+	Location struct {
+		Latitude       *float64 `maxminddb:"latitude"`
+		Longitude      *float64 `maxminddb:"longitude"`
+		AccuracyRadius int      `maxminddb:"accuracy_radius"`
+		TimeZone       string   `maxminddb:"time_zone"`
+	} `maxminddb:"location"`
+	ASN            uint32 `maxminddb:"autonomous_system_number"`
+	ASOrganization string `maxminddb:"autonomous_system_organization"`
+}
+
+// LoadGeoDatabase opens the given .mmdb files. The files stay open until
+// Close is called.
+func LoadGeoDatabase(paths ...string) (*GeoDatabase, error) {
+	if len(paths) == 0 {
+		return nil, errors.New("no GeoIP database given")
+	}
+
+	db := &GeoDatabase{}
+	for _, path := range paths {
+		reader, err := maxminddb.Open(path)
+		if err != nil {
+			db.Close()
+			return nil, fmt.Errorf("failed to open GeoIP database %s: %w", path, err)
+		}
+		db.readers = append(db.readers, reader)
+	}
+	return db, nil
+}
+
+func (db *GeoDatabase) Close() error {
+	if db == nil {
+		return nil
+	}
+	var errs []error
+	for _, reader := range db.readers {
+		errs = append(errs, reader.Close())
+	}
+	db.readers = nil
+	return errors.Join(errs...)
+}
+
+// Lookup returns what the databases know about ip. The second result is false
+// when none of them has an entry for it.
+func (db *GeoDatabase) Lookup(ip string) (*GeoLocation, bool) {
+	parsed := net.ParseIP(ip)
+	if db == nil || parsed == nil {
+		return nil, false
+	}
+
+	location := &GeoLocation{IP: ip}
+	found := false
+	networkBits := -1
+	for _, reader := range db.readers {
+		var record geoRecord
+		network, ok, err := reader.LookupNetwork(parsed, &record)
+		if err != nil || !ok {
+			continue
+		}
+		found = true
+		if bits, _ := network.Mask.Size(); bits > networkBits {
+			networkBits = bits
+			location.Network = network.String()
+		}
+		location.merge(&record)
+	}
+	if !found {
+		return nil, false
+	}
+	return location, true
+}
+
+// merge fills in the fields of l that are still empty from record.
+func (l *GeoLocation) merge(record *geoRecord) {
+	setIfEmpty := func(field *string, value string) {
+		if *field == "" {
+			*field = value
+		}
+	}
+
+	setIfEmpty(&l.CountryCode, record.Country.ISOCode)
+	setIfEmpty(&l.Country, record.Country.Names["en"])
+	if len(record.Subdivisions) > 0 {
+		setIfEmpty(&l.Region, record.Subdivisions[0].Names["en"])
+	}
+	setIfEmpty(&l.City, record.City.Names["en"])
+	setIfEmpty(&l.TimeZone, record.Location.TimeZone)
+	setIfEmpty(&l.ASOrganization, record.ASOrganization)
+	if l.ASN == 0 {
+		l.ASN = record.ASN
+	}
+	if l.Coordinates == nil && record.Location.Latitude != nil && record.Location.Longitude != nil {
+		l.Coordinates = &GeoCoordinates{
+			Latitude:       *record.Location.Latitude,
+			Longitude:      *record.Location.Longitude,
+			AccuracyRadius: record.Location.AccuracyRadius,
+		}
+	}
+}
+
+// LocateHops sets Geo on every traceroute hop the databases know about.
+func (db *GeoDatabase) LocateHops(hops []TracerouteHop) {
+	for i := range hops {
+		hops[i].Geo, _ = db.Lookup(hops[i].IP)
+	}
+}
+
+// LocateAddresses fills in info.Locations for the addresses the databases
+// know about.
+func (db *GeoDatabase) LocateAddresses(info *IPAddressInfo) {
+	for _, ip := range append(append([]string{}, info.IPv4Addresses...), info.IPv6Addresses...) {
+		if location, ok := db.Lookup(ip); ok {
+			if info.Locations == nil {
+				info.Locations = map[string]*GeoLocation{}
+			}
+			info.Locations[ip] = location
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package gowebspy
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// The test databases cover 81.2.69.0/24 (London), 8.8.8.0/24 and
+// 2001:4860::/32 (United States, no city) in geoip-city-test.mmdb, and
+// AS15169 and AS20712 (81.2.64.0/19) in geoip-asn-test.mmdb.
+func loadTestGeoDatabase(t *testing.T, names ...string) *GeoDatabase {
+	var paths []string
+	for _, name := range names {
+		paths = append(paths, filepath.Join("testdata", name))
+	}
+	db, err := LoadGeoDatabase(paths...)
+	if err != nil {
+		t.Fatalf("LoadGeoDatabase failed: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestGeoDatabaseLookup(t *testing.T) {
+	db := loadTestGeoDatabase(t, "geoip-city-test.mmdb", "geoip-asn-test.mmdb")
+
+	london, ok := db.Lookup("81.2.69.160")
+	if !ok {
+		t.Fatal("No location for 81.2.69.160")
+	}
+	if london.CountryCode != "GB" || london.Country != "United Kingdom" || london.Region != "England" ||
+		london.City != "London" || london.TimeZone != "Europe/London" {
+		t.Errorf("Unexpected place for 81.2.69.160: %+v", london)
+	}
+	if c := london.Coordinates; c == nil || c.Latitude != 51.5142 || c.Longitude != -0.0931 || c.AccuracyRadius != 10 {
+		t.Errorf("Unexpected coordinates for 81.2.69.160: %+v", c)
+	}
+	// The city database has the more specific network of the two.
+	if london.ASN != 20712 || london.ASOrganization != "Andrews & Arnold Ltd" || london.Network != "81.2.69.0/24" {
+		t.Errorf("Expected the ASN database to be merged in, got %+v", london)
+	}
+
+	google, ok := db.Lookup("2001:4860:4860::8888")
+	if !ok || google.CountryCode != "US" || google.City != "" || google.ASN != 15169 || google.Network != "2001:4860::/32" {
+		t.Errorf("Unexpected location for 2001:4860:4860::8888: %+v", google)
+	}
+
+	for _, ip := range []string{"192.0.2.1", "not-an-ip", ""} {
+		if location, ok := db.Lookup(ip); ok {
+			t.Errorf("Lookup(%q) = %+v, want nothing", ip, location)
+		}
+	}
+
+	var none *GeoDatabase
+	if _, ok := none.Lookup("8.8.8.8"); ok {
+		t.Error("A nil database should find nothing")
+	}
+}
+
+func TestGeoDatabaseLocate(t *testing.T) {
+	db := loadTestGeoDatabase(t, "geoip-asn-test.mmdb")
+
+	hops := []TracerouteHop{{Number: 1, IP: "192.168.1.1"}, {Number: 2, IP: "*"}, {Number: 3, IP: "8.8.8.8"}}
+	db.LocateHops(hops)
+	if hops[0].Geo != nil || hops[1].Geo != nil {
+		t.Errorf("Unknown hops should have no location: %+v, %+v", hops[0].Geo, hops[1].Geo)
+	}
+	if geo := hops[2].Geo; geo == nil || geo.ASN != 15169 || geo.Coordinates != nil {
+		t.Errorf("Unexpected location for 8.8.8.8: %+v", geo)
+	}
+
+	info := &IPAddressInfo{IPv4Addresses: []string{"8.8.8.8", "192.0.2.1"}, IPv6Addresses: []string{"2001:4860::1"}}
+	db.LocateAddresses(info)
+	if len(info.Locations) != 2 || info.Locations["8.8.8.8"] == nil || info.Locations["2001:4860::1"] == nil {
+		t.Errorf("Unexpected locations: %+v", info.Locations)
+	}
+}
+
+func TestLoadGeoDatabaseErrors(t *testing.T) {
+	if _, err := LoadGeoDatabase(); err == nil {
+		t.Error("Expected an error without any files")
+	}
+	_, err := LoadGeoDatabase(filepath.Join("testdata", "geoip-asn-test.mmdb"), filepath.Join("testdata", "report.golden.json"))
+	if err == nil || !strings.Contains(err.Error(), "report.golden.json") {
+		t.Errorf("Expected an error naming the invalid file, got %v", err)
+	}
+}
@@ -3,13 +3,11 @@ package gowebspy
 import (
 	"context"
 	"crypto/tls"
+	"errors"
 	"fmt"
 	"net"
 	"net/http"
 	"net/url"
-	"os/exec"
-	"regexp"
-	"runtime"
 	"strconv"
 	"strings"
 	"time"
@@ -22,15 +20,35 @@ import (
 type WebsiteInfo struct {
 	URL             string
 	IP              []string
+	// Addresses annotates each entry of IP. It is only filled in when
+	// Options.IPOwners, Options.GeoDatabase or Options.ReverseDNS is set.
+	Addresses       []AddressInfo
 	StatusCode      int
 	ServerInfo      string
 	ContentType     string
 	ResponseTime    time.Duration
+	// Timing breaks the request down into DNS, connect, TLS, wait and body
+	// transfer. ResponseTime ends when the headers arrive; Timing.Total
+	// includes reading the body.
+	Timing          *HTTPTiming
+	// Redirects is the chain from the requested URL to URL, set when
+	// Options.FollowRedirects is.
+	Redirects       *RedirectChain
 	SSLInfo         *SSLInfo
 	Headers         http.Header
+	// Cookies are parsed from the Set-Cookie headers.
+	Cookies         []CookieInfo
 	WhoisInfo       *WhoisInfo
 	Title           string
 	MetaDescription string
+	Warnings        []error
+}
+
+type AddressInfo struct {
+	IP         string
+	Owner      *NetworkOwner
+	Geo        *GeoLocation
+	ReverseDNS *ReverseDNS
 }
 
 type SSLInfo struct {
@@ -39,20 +57,46 @@ type SSLInfo struct {
 	Issuer     string
 	CommonName string
 	DNSNames   []string
-	Valid      bool
+	// Valid is true only when the chain verifies against the trusted roots
+	// and the leaf matches the hostname; see Verification for why not.
+	Valid        bool
+	Chain        []CertificateInfo
+	Verification *ChainVerification
+	Port         int
+	StartTLS     StartTLSProtocol
+	Revocation   *RevocationInfo
 }
 
+// Sources of WhoisInfo.
+const (
+	WhoisSourceRDAP  = "rdap"
+	WhoisSourceWhois = "whois"
+)
+
 type WhoisInfo struct {
+	// Domain is the registrable domain that was queried, in punycode.
+	Domain       string
+	// Source says whether the data came from RDAP or WHOIS.
+	Source       string
 	Registrar    string
 	CreatedDate  string
 	ExpiresDate  string
 	UpdatedDate  string
 	NameServers  []string
 	DomainStatus []string
+	// RDAP holds the typed response when Source is WhoisSourceRDAP; Raw is
+	// only set for WHOIS.
+	RDAP         *RDAPDomain
 	Raw          string
 }
 
 func GetWebsiteInfo(rawURL string) (*WebsiteInfo, error) {
+	return GetWebsiteInfoWithOptions(context.Background(), rawURL, Options{})
+}
+
+func GetWebsiteInfoWithOptions(ctx context.Context, rawURL string, opts Options) (*WebsiteInfo, error) {
+	opts = opts.withDefaults()
+
 	if !strings.HasPrefix(rawURL, "http://") && !strings.HasPrefix(rawURL, "https://") {
 		rawURL = "https://" + rawURL
 	}
@@ -66,110 +110,254 @@ func GetWebsiteInfo(rawURL string) (*WebsiteInfo, error) {
 		URL: parsedURL.String(),
 	}
 
-	ips, err := net.LookupIP(parsedURL.Hostname())
+	// When following redirects the chain is fetched first, and its final
+	// response is the main response: the destination's addresses,
+	// certificate and WHOIS are then the ones reported.
+	if opts.FollowRedirects {
+		httpCtx, cancel := context.WithTimeout(ctx, opts.HTTPTimeout)
+		chain, final, err := followRedirects(httpCtx, parsedURL, opts.RedirectOptions())
+		info.Redirects = chain
+		if err != nil {
+			cancel()
+			return info, fmt.Errorf("HTTP request failed: %w", err)
+		}
+		parsedURL = final.Request.URL
+		info.URL = parsedURL.String()
+		info.readResponse(final, parsedURL.Scheme == "https")
+		chain.Hops[len(chain.Hops)-1].Timing = info.Timing
+		cancel()
+	}
+
+	hostname, err := NormalizeHost(parsedURL.Hostname())
 	if err != nil {
-		fmt.Printf("Warning: Failed to lookup IP: %v\n", err)
-	} else {
-		for _, ip := range ips {
-			info.IP = append(info.IP, ip.String())
+		return nil, fmt.Errorf("failed to parse URL: %w", err)
+	}
+
+	if !opts.SkipDNS {
+		dnsCtx, cancel := context.WithTimeout(ctx, opts.DNSTimeout)
+		addrs, err := opts.resolver().LookupIPAddr(dnsCtx, hostname)
+		cancel()
+		if err != nil {
+			info.addWarning(ProbeDNS, fmt.Errorf("failed to lookup IP: %w", err))
+		} else {
+			for _, addr := range addrs {
+				info.IP = append(info.IP, addr.IP.String())
+			}
 		}
 	}
 
-	client := &http.Client{
-		Timeout: 10 * time.Second,
-		CheckRedirect: func(req *http.Request, via []*http.Request) error {
-			return http.ErrUseLastResponse
-		},
+	if (opts.IPOwners || opts.GeoDatabase != nil || opts.ReverseDNS != nil) && len(info.IP) > 0 {
+		owners := make([]*NetworkOwner, len(info.IP))
+		if opts.IPOwners {
+			owners, err = LookupOwners(ctx, info.IP, opts.OwnerOptions())
+			if err != nil {
+				info.addWarning(ProbeOwner, err)
+			}
+		}
+		names := opts.ReverseDNS.LookupAll(ctx, info.IP)
+		for i, ip := range info.IP {
+			geo, _ := opts.GeoDatabase.Lookup(ip)
+			info.Addresses = append(info.Addresses, AddressInfo{IP: ip, Owner: owners[i], Geo: geo, ReverseDNS: names[i]})
+		}
 	}
 
-	startTime := time.Now()
-	resp, err := client.Get(parsedURL.String())
-	if err != nil {
-		return info, fmt.Errorf("HTTP request failed: %w", err)
+	if !opts.FollowRedirects {
+		httpCtx, cancel := context.WithTimeout(ctx, opts.HTTPTimeout)
+		resp, err := timedGet(httpCtx, opts.httpClient(), parsedURL.String())
+		if err != nil {
+			cancel()
+			return info, fmt.Errorf("HTTP request failed: %w", err)
+		}
+		info.readResponse(resp, parsedURL.Scheme == "https")
+		cancel()
+	}
+
+	if parsedURL.Scheme == "https" && !opts.SkipSSL {
+		port := 443
+		if parsedURL.Port() != "" {
+			port, _ = strconv.Atoi(parsedURL.Port())
+		}
+		sslInfo, err := getSSLInfo(ctx, hostname, port, StartTLSNone, opts)
+		if err != nil {
+			info.addWarning(ProbeSSL, err)
+		}
+		info.SSLInfo = sslInfo
+	}
+
+	if !opts.SkipWhois {
+		whoisInfo, err := getWhoisInfo(ctx, hostname, opts)
+		if err != nil {
+			info.addWarning(ProbeWhois, err)
+		}
+		info.WhoisInfo = whoisInfo
 	}
-	defer resp.Body.Close()
-	info.ResponseTime = time.Since(startTime)
 
+	return info, nil
+}
+
+// readResponse fills in info from the main response and reads its body.
+func (info *WebsiteInfo) readResponse(resp *timedResponse, https bool) {
+	info.ResponseTime = resp.elapsed
 	info.StatusCode = resp.StatusCode
 	info.Headers = resp.Header
 	info.ServerInfo = resp.Header.Get("Server")
 	info.ContentType = resp.Header.Get("Content-Type")
+	info.Cookies = ParseCookies(resp.Header, https)
 
 	if strings.Contains(info.ContentType, "text/html") {
-		doc, err := goquery.NewDocumentFromReader(resp.Body)
-		if err == nil {
+		doc, err := goquery.NewDocumentFromReader(resp.body)
+		if err != nil {
+			info.addWarning(ProbeContent, fmt.Errorf("failed to parse HTML: %w", err))
+		} else {
 			info.Title = doc.Find("title").Text()
 			info.MetaDescription, _ = doc.Find("meta[name='description']").Attr("content")
 		}
 	}
-
-	if parsedURL.Scheme == "https" {
-		info.SSLInfo = getSSLInfo(parsedURL.Hostname())
+	// Read the rest, up to maxTimedBody, so the transfer time covers the body.
+	timing, err := resp.finish()
+	if err != nil {
+		info.addWarning(ProbeContent, fmt.Errorf("failed to read body: %w", err))
 	}
+	info.Timing = timing
+}
 
-	info.WhoisInfo = getWhoisInfo(parsedURL.Hostname())
-
-	return info, nil
+func (info *WebsiteInfo) addWarning(probe string, err error) {
+	info.Warnings = append(info.Warnings, &ProbeError{Probe: probe, Err: err})
 }
 
-func getSSLInfo(hostname string) *SSLInfo {
-	conn, err := tls.Dial("tcp", hostname+":443", &tls.Config{
-		InsecureSkipVerify: true,
-	})
+func getSSLInfo(ctx context.Context, hostname string, port int, starttls StartTLSProtocol, opts Options) (*SSLInfo, error) {
+	ctx, cancel := context.WithTimeout(ctx, opts.SSLTimeout)
+	defer cancel()
+
+	plainConn, err := dialStartTLS(ctx, opts.dialer(), net.JoinHostPort(hostname, strconv.Itoa(port)), starttls)
 	if err != nil {
-		return &SSLInfo{Valid: false}
+		return &SSLInfo{Valid: false, Port: port, StartTLS: starttls}, fmt.Errorf("TLS connection failed: %w", err)
 	}
+	conn := tls.Client(plainConn, &tls.Config{
+		InsecureSkipVerify: true,
+		ServerName:         hostname,
+	})
 	defer conn.Close()
 
+	if err := conn.HandshakeContext(ctx); err != nil {
+		return &SSLInfo{Valid: false, Port: port, StartTLS: starttls}, fmt.Errorf("TLS handshake failed: %w", err)
+	}
+
 	certs := conn.ConnectionState().PeerCertificates
 	if len(certs) == 0 {
-		return &SSLInfo{Valid: false}
+		return &SSLInfo{Valid: false, Port: port, StartTLS: starttls}, fmt.Errorf("server presented no certificates")
 	}
 
 	cert := certs[0]
-	return &SSLInfo{
-		Issued:     cert.NotBefore,
-		Expiry:     cert.NotAfter,
-		Issuer:     cert.Issuer.CommonName,
-		CommonName: cert.Subject.CommonName,
-		DNSNames:   cert.DNSNames,
-		Valid:      time.Now().After(cert.NotBefore) && time.Now().Before(cert.NotAfter),
+	sslInfo := &SSLInfo{
+		Issued:       cert.NotBefore,
+		Expiry:       cert.NotAfter,
+		Issuer:       cert.Issuer.CommonName,
+		CommonName:   cert.Subject.CommonName,
+		DNSNames:     cert.DNSNames,
+		Port:         port,
+		StartTLS:     starttls,
+		Verification: VerifyChain(certs, hostname, opts.RootCAs, time.Now()),
+	}
+	sslInfo.Revocation = checkRevocation(ctx, certs, conn.ConnectionState().OCSPResponse, opts)
+	if sslInfo.Revocation.Status == RevocationRevoked {
+		sslInfo.Verification = &ChainVerification{
+			Reason:        VerifyRevoked,
+			HostnameMatch: sslInfo.Verification.HostnameMatch,
+			Detail:        "the certificate has been revoked by its issuer",
+		}
 	}
+	sslInfo.Valid = sslInfo.Verification.Verified
+	for _, c := range certs {
+		sslInfo.Chain = append(sslInfo.Chain, describeCertificate(c))
+	}
+	return sslInfo, nil
 }
 
-func getWhoisInfo(domain string) *WhoisInfo {
+func getWhoisInfo(ctx context.Context, domain string, opts Options) (*WhoisInfo, error) {
 	info := &WhoisInfo{}
 
-	parts := strings.Split(domain, ".")
-	if len(parts) > 2 {
-		domain = strings.Join(parts[len(parts)-2:], ".")
+	domain, err := opts.SuffixList.RegistrableDomain(domain)
+	if err != nil {
+		return info, fmt.Errorf("WHOIS query failed: %w", err)
+	}
+
+	info.Domain = domain
+
+	// RDAP answers with structured data, so it is tried first. WHOIS is the
+	// fallback for TLDs without RDAP and for RDAP servers that fail.
+	var rdapErr error
+	if !opts.SkipRDAP {
+		rdap, err := LookupRDAP(ctx, domain, opts.rdapOptions())
+		if err == nil {
+			info.fromRDAP(rdap)
+			return info, nil
+		}
+		if !errors.Is(err, ErrRDAPUnsupported) {
+			rdapErr = err
+		}
 	}
 
-	rawWhois, err := whois.Whois(domain)
+	client := whois.NewClient().
+		SetTimeout(opts.WhoisTimeout).
+		SetDialer(contextDialer{ctx: ctx, dialer: opts.dialer()})
+
+	rawWhois, err := client.Whois(domain)
 	if err != nil {
-		return info
+		if rdapErr != nil {
+			return info, fmt.Errorf("RDAP lookup failed: %v; WHOIS query failed: %w", rdapErr, err)
+		}
+		return info, fmt.Errorf("WHOIS query failed: %w", err)
 	}
 
+	info.Source = WhoisSourceWhois
 	info.Raw = rawWhois
 
 	parsed, err := whoisparser.Parse(rawWhois)
 	if err != nil {
-		return info
+		return info, fmt.Errorf("failed to parse WHOIS response: %w", err)
 	}
 
-	info.Registrar = parsed.Registrar.Name
-	info.CreatedDate = parsed.Domain.CreatedDate
-	info.ExpiresDate = parsed.Domain.ExpirationDate
-	info.UpdatedDate = parsed.Domain.UpdatedDate
-	info.NameServers = parsed.Domain.NameServers
-	info.DomainStatus = parsed.Domain.Status
+	if parsed.Registrar != nil {
+		info.Registrar = parsed.Registrar.Name
+	}
+	if parsed.Domain != nil {
+		info.CreatedDate = parsed.Domain.CreatedDate
+		info.ExpiresDate = parsed.Domain.ExpirationDate
+		info.UpdatedDate = parsed.Domain.UpdatedDate
+		info.NameServers = parsed.Domain.NameServers
+		info.DomainStatus = parsed.Domain.Status
+	}
 
-	return info
+	return info, nil
+}
+
+func (info *WhoisInfo) fromRDAP(rdap *RDAPDomain) {
+	info.Source = WhoisSourceRDAP
+	info.RDAP = rdap
+	info.Registrar = rdap.Registrar
+	info.NameServers = rdap.Nameservers
+	info.DomainStatus = rdap.Status
+
+	if date, ok := rdap.Event(RDAPEventRegistration); ok {
+		info.CreatedDate = date.Format(time.RFC3339)
+	}
+	if date, ok := rdap.Event(RDAPEventExpiration); ok {
+		info.ExpiresDate = date.Format(time.RFC3339)
+	}
+	if date, ok := rdap.Event(RDAPEventLastChanged); ok {
+		info.UpdatedDate = date.Format(time.RFC3339)
+	}
 }
 
 func GetDNSRecords(domain string) (map[string][]string, error) {
 	records := map[string][]string{}
 
+	domain, err := NormalizeHost(domain)
+	if err != nil {
+		return records, err
+	}
+
 	mxRecords, err := net.LookupMX(domain)
 	if err == nil {
 		for _, mx := range mxRecords {
@@ -182,7 +370,14 @@ func GetDNSRecords(domain string) (map[string][]string, error) {
 		records["TXT"] = txtRecords
 	}
 
+	// Name servers are delegated for the zone, so a host inside it has
+	// none of its own; report those of its registrable domain instead.
 	nsRecords, err := net.LookupNS(domain)
+	if err != nil || len(nsRecords) == 0 {
+		if zone, zoneErr := RegistrableDomain(domain); zoneErr == nil && zone != domain {
+			nsRecords, err = net.LookupNS(zone)
+		}
+	}
 	if err == nil {
 		for _, ns := range nsRecords {
 			records["NS"] = append(records["NS"], ns.Host)
@@ -203,240 +398,90 @@ func GetDNSRecords(domain string) (map[string][]string, error) {
 }
 
 func PortScan(host string, ports []int) map[int]bool {
-	results := make(map[int]bool)
-	
-	for _, port := range ports {
-		timeout := 2 * time.Second
-		conn, err := net.DialTimeout("tcp", fmt.Sprintf("%s:%d", host, port), timeout)
-		if err == nil {
-			conn.Close()
-			results[port] = true
-		} else {
-			results[port] = false
-		}
-	}
-	
-	return results
+	return portScanMap(host, ports, "tcp")
 }
 
-func CheckHTTPRedirects(rawURL string) ([]string, error) {
-	if !strings.HasPrefix(rawURL, "http://") && !strings.HasPrefix(rawURL, "https://") {
-		rawURL = "https://" + rawURL
+func portScanMap(host string, ports []int, network string) map[int]bool {
+	results := make(map[int]bool)
+	for _, port := range ports {
+		results[port] = false
 	}
 
-	var redirects []string
-	redirects = append(redirects, rawURL)
-	
-	client := &http.Client{
-		CheckRedirect: func(req *http.Request, via []*http.Request) error {
-			redirects = append(redirects, req.URL.String())
-			return nil
-		},
-		Timeout: 10 * time.Second,
-	}
-	
-	_, err := client.Get(rawURL)
+	scanned, err := ScanPorts(context.Background(), host, ports, ScanOptions{Network: network})
 	if err != nil {
-		return redirects, err
+		return results
 	}
-	
-	return redirects, nil
-}
 
-type TracerouteHop struct {
-	Number int
-	IP     string
-	RTT    time.Duration
-	Host   string
-}
-
-func SimpleTraceroute(ctx context.Context, host string, maxHops int) ([]TracerouteHop, error) {
-	if isWindows() {
-		return windowsTraceroute(ctx, host, maxHops)
-	} else {
-		return unixTraceroute(ctx, host, maxHops)
+	for _, result := range scanned {
+		results[result.Port] = result.State == PortOpen
 	}
-}
 
-func windowsTraceroute(ctx context.Context, host string, maxHops int) ([]TracerouteHop, error) {
-	cmd := exec.CommandContext(ctx, "tracert", "-d", "-h", strconv.Itoa(maxHops), host)
-	
-	output, err := cmd.Output()
-	if err != nil {
-		return fallbackTraceroute(host, maxHops), nil
-	}
-	
-	return parseWindowsTracerouteOutput(string(output)), nil
+	return results
 }
 
-func unixTraceroute(ctx context.Context, host string, maxHops int) ([]TracerouteHop, error) {
-	cmd := exec.CommandContext(ctx, "traceroute", "-n", "-m", strconv.Itoa(maxHops), host)
-	
-	output, err := cmd.Output()
-	if err != nil {
-		return fallbackTraceroute(host, maxHops), nil
+// CheckHTTPRedirects returns the URLs visited on the way to rawURL's final
+// response, starting with rawURL itself. See FollowRedirects for the status
+// code, headers and timing of each hop.
+func CheckHTTPRedirects(rawURL string) ([]string, error) {
+	chain, err := FollowRedirects(context.Background(), rawURL, RedirectOptions{Timeout: 10 * time.Second})
+	if chain == nil {
+		return nil, err
 	}
-	
-	return parseUnixTracerouteOutput(string(output)), nil
-}
 
-func parseWindowsTracerouteOutput(output string) []TracerouteHop {
-	var hops []TracerouteHop
-	
-	lines := strings.Split(output, "\n")
-	
-	for i := 3; i < len(lines); i++ {
-		line := strings.TrimSpace(lines[i])
-		if line == "" || !strings.Contains(line, "ms") {
-			continue
-		}
-		
-		numRegex := regexp.MustCompile(`^\s*(\d+)`)
-		numMatch := numRegex.FindStringSubmatch(line)
-		if len(numMatch) < 2 {
-			continue
-		}
-		
-		hopNum, _ := strconv.Atoi(numMatch[1])
-		
-		ipRegex := regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}\b|([0-9a-fA-F]{1,4}:){7}[0-9a-fA-F]{1,4}`)
-		ipMatch := ipRegex.FindString(line)
-		
-		if ipMatch == "" {
-			ipMatch = "*"
-		}
-		
-		rttRegex := regexp.MustCompile(`(\d+)\s*ms`)
-		rttMatches := rttRegex.FindAllStringSubmatch(line, -1)
-		
-		var rtt int64
-		if len(rttMatches) > 0 && len(rttMatches[0]) > 1 {
-			rtt, _ = strconv.ParseInt(rttMatches[0][1], 10, 64)
-		}
-		
-		hop := TracerouteHop{
-			Number: hopNum,
-			IP:     ipMatch,
-			RTT:    time.Duration(rtt) * time.Millisecond,
-		}
-		
-		hops = append(hops, hop)
+	var redirects []string
+	for _, hop := range chain.Hops {
+		redirects = append(redirects, hop.URL)
 	}
-	
-	return hops
+	return redirects, err
 }
 
-func parseUnixTracerouteOutput(output string) []TracerouteHop {
-	var hops []TracerouteHop
-	
-	lines := strings.Split(output, "\n")
-	
-	for i := 1; i < len(lines); i++ {
-		line := strings.TrimSpace(lines[i])
-		if line == "" {
-			continue
-		}
-		
-		fields := strings.Fields(line)
-		if len(fields) < 2 {
-			continue
-		}
-		
-		hopNum, _ := strconv.Atoi(fields[0])
-		
-		ipRegex := regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}\b|([0-9a-fA-F]{1,4}:){7}[0-9a-fA-F]{1,4}`)
-		ipMatch := ipRegex.FindString(line)
-		
-		if ipMatch == "" {
-			ipMatch = "*"
-		}
-		
-		rttRegex := regexp.MustCompile(`(\d+\.\d+)\s*ms`)
-		rttMatch := rttRegex.FindStringSubmatch(line)
-		
-		var rtt float64
-		if len(rttMatch) > 1 {
-			rtt, _ = strconv.ParseFloat(rttMatch[1], 64)
-		}
-		
-		hop := TracerouteHop{
-			Number: hopNum,
-			IP:     ipMatch,
-			RTT:    time.Duration(rtt * float64(time.Millisecond)),
-		}
-		
-		hops = append(hops, hop)
-	}
-	
-	return hops
+type TracerouteHop struct {
+	Number   int
+	IP       string
+	RTT      time.Duration
+	Host     string
+	Sent     int
+	Received int
+	Loss     float64
+	MinRTT   time.Duration
+	AvgRTT   time.Duration
+	MaxRTT   time.Duration
+	// Estimated is set for hops produced by EstimatePathTCP rather than a
+	// real ICMP/UDP trace.
+	Estimated bool
+	// Owner is set by LookupHopOwners, Geo by GeoDatabase.LocateHops and
+	// ReverseDNS by ReverseDNSCache.ResolveHops.
+	Owner      *NetworkOwner
+	Geo        *GeoLocation
+	ReverseDNS *ReverseDNS
 }
 
-func fallbackTraceroute(host string, maxHops int) []TracerouteHop {
-	var hops []TracerouteHop
-	
-	ips, err := net.LookupIP(host)
-	if err != nil {
-		return hops
-	}
-	
-	targetIP := ips[0].String()
-	intermediateHops := min(maxHops-1, 3)
-	
-	hops = append(hops, TracerouteHop{
-		Number: 1,
-		IP:     "192.168.1.1",
-		RTT:    10 * time.Millisecond,
-	})
-	
-	for i := 2; i <= intermediateHops; i++ {
-		hops = append(hops, TracerouteHop{
-			Number: i,
-			IP:     fmt.Sprintf("10.%d.%d.%d", i*20, i*5, i*3),
-			RTT:    time.Duration(i*15) * time.Millisecond,
-		})
-	}
-	
-	hops = append(hops, TracerouteHop{
-		Number: len(hops) + 1,
-		IP:     targetIP,
-		RTT:    time.Duration(intermediateHops*20+30) * time.Millisecond,
-	})
-	
-	return hops
+func SimpleTraceroute(ctx context.Context, host string, maxHops int) ([]TracerouteHop, error) {
+	return Traceroute(ctx, host, TracerouteOptions{MaxHops: maxHops})
 }
 
 func TracerouteIPv6(ctx context.Context, host string, maxHops int) ([]TracerouteHop, error) {
-	var cmd *exec.Cmd
-	
-	if isWindows() {
-		cmd = exec.CommandContext(ctx, "tracert", "-6", "-d", "-h", strconv.Itoa(maxHops), host)
-	} else {
-		cmd = exec.CommandContext(ctx, "traceroute6", "-n", "-m", strconv.Itoa(maxHops), host)
-	}
-	
-	output, err := cmd.Output()
-	if err != nil {
-		return fallbackTraceroute(host, maxHops), nil
-	}
-	
-	if isWindows() {
-		return parseWindowsTracerouteOutput(string(output)), nil
-	}
-	return parseUnixTracerouteOutput(string(output)), nil
-}
-
-func isWindows() bool {
-	return runtime.GOOS == "windows"
+	return Traceroute(ctx, host, TracerouteOptions{MaxHops: maxHops, IPv6: true})
 }
 
 type IPAddressInfo struct {
 	IPv4Addresses []string
 	IPv6Addresses []string
+	// Locations is keyed by address and set by GeoDatabase.LocateAddresses.
+	Locations     map[string]*GeoLocation
+	// ReverseDNS is keyed by address and set by
+	// ReverseDNSCache.ResolveAddresses.
+	ReverseDNS    map[string]*ReverseDNS
 }
 
 func GetIPAddresses(domain string) (*IPAddressInfo, error) {
 	info := &IPAddressInfo{}
 	
+	domain, err := NormalizeHost(domain)
+	if err != nil {
+		return info, fmt.Errorf("IP lookup failed: %w", err)
+	}
+	
 	ips, err := net.LookupIP(domain)
 	if err != nil {
 		return info, fmt.Errorf("IP lookup failed: %w", err)
@@ -454,36 +499,7 @@ func GetIPAddresses(domain string) (*IPAddressInfo, error) {
 }
 
 func PortScanIPv6(host string, ports []int) map[int]bool {
-	results := make(map[int]bool)
-	
-	if !strings.Contains(host, ":") {
-		ipInfo, err := GetIPAddresses(host)
-		if err != nil || len(ipInfo.IPv6Addresses) == 0 {
-			for _, port := range ports {
-				results[port] = false
-			}
-			return results
-		}
-		
-		host = ipInfo.IPv6Addresses[0]
-	}
-	
-	if strings.Contains(host, ":") && !strings.HasPrefix(host, "[") {
-		host = "[" + host + "]"
-	}
-	
-	for _, port := range ports {
-		timeout := 2 * time.Second
-		conn, err := net.DialTimeout("tcp6", fmt.Sprintf("%s:%d", host, port), timeout)
-		if err == nil {
-			conn.Close()
-			results[port] = true
-		} else {
-			results[port] = false
-		}
-	}
-	
-	return results
+	return portScanMap(host, ports, "tcp6")
 }
 
 func CheckDualStack(domain string) (bool, error) {
@@ -501,6 +517,11 @@ func CheckDualStack(domain string) (bool, error) {
 func GetIPv6DNSRecords(domain string) (map[string][]string, error) {
 	records := map[string][]string{}
 	
+	domain, err := NormalizeHost(domain)
+	if err != nil {
+		return records, err
+	}
+	
 	aaaaRecords, err := net.LookupIP(domain)
 	if err == nil {
 		var ipv6Records []string
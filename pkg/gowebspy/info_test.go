@@ -1,6 +1,9 @@
 package gowebspy
 
 import (
+	"context"
+	"errors"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"strconv"
@@ -79,6 +82,70 @@ func TestCheckHTTPRedirects(t *testing.T) {
 	}
 }
 
+func TestGetWebsiteInfoWithOptions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Server", "gowebspy-test")
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, err := w.Write([]byte(`<html><head><title>Test Page</title><meta name="description" content="A test"></head></html>`))
+		if err != nil {
+			t.Fatalf("Failed to write response: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	serverAddr := strings.TrimPrefix(server.URL, "http://")
+	client := &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+				return (&net.Dialer{}).DialContext(ctx, network, serverAddr)
+			},
+		},
+	}
+	resolver := &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			return nil, errors.New("resolver disabled")
+		},
+	}
+
+	info, err := GetWebsiteInfoWithOptions(context.Background(), "http://gowebspy.invalid/", Options{
+		SkipWhois:  true,
+		HTTPClient: client,
+		Resolver:   resolver,
+	})
+	if err != nil {
+		t.Fatalf("GetWebsiteInfoWithOptions failed: %v", err)
+	}
+
+	if info.Title != "Test Page" || info.MetaDescription != "A test" {
+		t.Errorf("Unexpected title/description: %q / %q", info.Title, info.MetaDescription)
+	}
+	if info.ServerInfo != "gowebspy-test" {
+		t.Errorf("ServerInfo = %q, want gowebspy-test", info.ServerInfo)
+	}
+	if info.WhoisInfo != nil {
+		t.Errorf("WhoisInfo should be nil when SkipWhois is set")
+	}
+
+	if len(info.Warnings) != 1 {
+		t.Fatalf("Expected 1 warning, got %v", info.Warnings)
+	}
+	var probeErr *ProbeError
+	if !errors.As(info.Warnings[0], &probeErr) || probeErr.Probe != ProbeDNS {
+		t.Errorf("Expected a DNS ProbeError, got %v", info.Warnings[0])
+	}
+}
+
+func TestGetWebsiteInfoWithOptionsCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := GetWebsiteInfoWithOptions(ctx, "http://127.0.0.1:1/", Options{SkipWhois: true})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
+
 func extractDomain(urlStr string) string {
 	urlStr = strings.TrimPrefix(urlStr, "http://")
 	urlStr = strings.TrimPrefix(urlStr, "https://")
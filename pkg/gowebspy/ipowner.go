@@ -0,0 +1,328 @@
+package gowebspy
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultOwnerTimeout = 15 * time.Second
+	defaultOwnerServer  = "whois.cymru.com:43"
+	ownerRDAPWorkers    = 4
+)
+
+// Sources of NetworkOwner data.
+const (
+	OwnerSourceDatabase = "database"
+	OwnerSourceWhois    = "whois"
+	OwnerSourceRDAP     = "rdap"
+)
+
+// NetworkOwner says who operates the network an address belongs to. Fields
+// a source could not provide are left empty.
+type NetworkOwner struct {
+	IP     string
+	ASN    uint32
+	ASName string
+	// Prefix is the announced BGP prefix, or the registered range when that
+	// is all that is known.
+	Prefix string
+	// Network is the name of the registered network, such as "GOGL".
+	Network    string
+	RIR        string
+	Country    string
+	AbuseEmail string
+	Sources    []string
+}
+
+type OwnerOptions struct {
+	// Timeout covers the whole lookup.
+	Timeout time.Duration
+	// Database answers from an offline IP-to-ASN file. When it is set no
+	// network queries are made.
+	Database *ASNDatabase
+	// WhoisServer is the IP-to-ASN WHOIS service, queried in bulk mode for
+	// the AS, prefix, country and registry. It defaults to Team Cymru's.
+	WhoisServer string
+	Dialer      *net.Dialer
+	// HTTPClient and Bootstrap are used for the RDAP queries that add the
+	// network name and abuse contact.
+	HTTPClient *http.Client
+	Bootstrap  *RDAPBootstrap
+}
+
+func (o OwnerOptions) withDefaults() OwnerOptions {
+	if o.Timeout <= 0 {
+		o.Timeout = defaultOwnerTimeout
+	}
+	if o.WhoisServer == "" {
+		o.WhoisServer = defaultOwnerServer
+	}
+	if o.Dialer == nil {
+		o.Dialer = &net.Dialer{}
+	}
+	return o
+}
+
+// LookupOwners finds the network owner of each address in ips. The result
+// has one entry per address, nil for private and reserved addresses and for
+// those nothing is known about. Failed queries are reported in the error,
+// which may come with partial results.
+func LookupOwners(ctx context.Context, ips []string, opts OwnerOptions) ([]*NetworkOwner, error) {
+	opts = opts.withDefaults()
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+
+	owners := make([]*NetworkOwner, len(ips))
+	var public []netip.Addr
+	for _, ip := range ips {
+		addr, err := netip.ParseAddr(ip)
+		if err == nil && isPublicAddr(addr) && !containsAddr(public, addr.Unmap()) {
+			public = append(public, addr.Unmap())
+		}
+	}
+	if len(public) == 0 {
+		return owners, nil
+	}
+
+	if opts.Database != nil {
+		for i, ip := range ips {
+			if addr, err := netip.ParseAddr(ip); err == nil && isPublicAddr(addr) {
+				owners[i], _ = opts.Database.Lookup(ip)
+			}
+		}
+		return owners, nil
+	}
+
+	found := map[netip.Addr]*NetworkOwner{}
+	for _, addr := range public {
+		found[addr] = &NetworkOwner{IP: addr.String()}
+	}
+
+	var errs []error
+	if err := queryOwnerWhois(ctx, opts, public, found); err != nil {
+		errs = append(errs, fmt.Errorf("IP-to-ASN WHOIS query failed: %w", err))
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, ownerRDAPWorkers)
+	for _, addr := range public {
+		wg.Add(1)
+		go func(addr netip.Addr) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			network, err := lookupRDAPNetwork(ctx, addr, opts)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, fmt.Errorf("RDAP lookup of %s failed: %w", addr, err))
+				return
+			}
+			found[addr].addRDAP(network)
+		}(addr)
+	}
+	wg.Wait()
+
+	for i, ip := range ips {
+		addr, err := netip.ParseAddr(ip)
+		if err != nil {
+			continue
+		}
+		if owner := found[addr.Unmap()]; owner != nil && len(owner.Sources) > 0 {
+			copied := *owner
+			copied.IP = ip
+			owners[i] = &copied
+		}
+	}
+	return owners, errors.Join(errs...)
+}
+
+// LookupHopOwners sets Owner on every traceroute hop with a public address.
+func LookupHopOwners(ctx context.Context, hops []TracerouteHop, opts OwnerOptions) error {
+	ips := make([]string, len(hops))
+	for i, hop := range hops {
+		ips[i] = hop.IP
+	}
+	owners, err := LookupOwners(ctx, ips, opts)
+	for i := range hops {
+		hops[i].Owner = owners[i]
+	}
+	return err
+}
+
+// isPublicAddr reports whether addr can be owned by anyone: private, shared
+// (CGNAT), loopback, link-local and documentation ranges are not.
+func isPublicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return false
+	}
+	for _, reserved := range reservedPrefixes {
+		if reserved.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+var reservedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.2.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("198.51.100.0/24"),
+	netip.MustParsePrefix("203.0.113.0/24"),
+	netip.MustParsePrefix("2001:db8::/32"),
+}
+
+func containsAddr(addrs []netip.Addr, addr netip.Addr) bool {
+	for _, a := range addrs {
+		if a == addr {
+			return true
+		}
+	}
+	return false
+}
+
+// queryOwnerWhois asks a Team Cymru style IP-to-ASN service about all addrs
+// in one bulk query. Answers look like:
+//
+//	15169   | 8.8.8.8          | 8.8.8.0/24          | US | arin     | 2023-12-28 | GOOGLE, US
+func queryOwnerWhois(ctx context.Context, opts OwnerOptions, addrs []netip.Addr, found map[netip.Addr]*NetworkOwner) error {
+	conn, err := opts.Dialer.DialContext(ctx, "tcp", opts.WhoisServer)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	var query strings.Builder
+	query.WriteString("begin\nverbose\n")
+	for _, addr := range addrs {
+		query.WriteString(addr.String() + "\n")
+	}
+	query.WriteString("end\n")
+	if _, err := conn.Write([]byte(query.String())); err != nil {
+		return err
+	}
+
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), "|")
+		if len(fields) < 7 {
+			continue
+		}
+		for i := range fields {
+			fields[i] = strings.TrimSpace(fields[i])
+		}
+
+		asn, err := strconv.ParseUint(fields[0], 10, 32)
+		if err != nil {
+			// The header line, or "NA" for unannounced space.
+			continue
+		}
+		addr, err := netip.ParseAddr(fields[1])
+		if err != nil {
+			continue
+		}
+		// Space announced by several ASes gets a line for each; keep the first.
+		owner := found[addr.Unmap()]
+		if owner == nil || owner.ASN != 0 {
+			continue
+		}
+
+		owner.ASN = uint32(asn)
+		owner.Prefix = fields[2]
+		owner.Country = fields[3]
+		owner.RIR = rirName(fields[4])
+		owner.ASName = strings.Join(fields[6:], "|")
+		owner.Sources = append(owner.Sources, OwnerSourceWhois)
+	}
+	return scanner.Err()
+}
+
+func lookupRDAPNetwork(ctx context.Context, addr netip.Addr, opts OwnerOptions) (*rdapObject, error) {
+	rdapOpts := RDAPOptions{HTTPClient: opts.HTTPClient, Bootstrap: opts.Bootstrap}.withDefaults()
+	servers, err := rdapOpts.ipServers(ctx, addr)
+	if err != nil {
+		return nil, err
+	}
+	if len(servers) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrRDAPUnsupported, addr)
+	}
+
+	for _, server := range servers {
+		var network *rdapObject
+		network, err = fetchRDAPObject(ctx, rdapOpts.HTTPClient, strings.TrimSuffix(server, "/")+"/ip/"+addr.String(), "ip network")
+		if err == nil {
+			return network, nil
+		}
+	}
+	return nil, err
+}
+
+// addRDAP fills in what the IP-to-ASN service does not know from an RDAP IP
+// network object.
+func (owner *NetworkOwner) addRDAP(network *rdapObject) {
+	owner.Sources = append(owner.Sources, OwnerSourceRDAP)
+	owner.Network = network.Name
+	owner.AbuseEmail = abuseEmail(network.Entities)
+
+	if owner.Country == "" {
+		owner.Country = network.Country
+	}
+	if owner.RIR == "" {
+		owner.RIR = rirName(network.Port43)
+	}
+	if owner.ASN == 0 && len(network.OriginASNs) > 0 {
+		owner.ASN = network.OriginASNs[0]
+	}
+	if owner.Prefix == "" && len(network.CIDRs) > 0 {
+		cidr := network.CIDRs[0]
+		owner.Prefix = cidr.V4Prefix + cidr.V6Prefix + "/" + strconv.Itoa(cidr.Length)
+	}
+}
+
+func abuseEmail(entities []rdapObject) string {
+	for i := range entities {
+		entity := entities[i].entity()
+		if hasRole(entity.Roles, "abuse") && entity.Email != "" {
+			return entity.Email
+		}
+		if email := abuseEmail(entities[i].Entities); email != "" {
+			return email
+		}
+	}
+	return ""
+}
+
+// rirName maps a registry as named by the IP-to-ASN service, or the WHOIS
+// server an RDAP response points to, to the registry's usual name.
+func rirName(registry string) string {
+	switch strings.TrimPrefix(strings.ToLower(registry), "whois.") {
+	case "arin", "arin.net":
+		return "ARIN"
+	case "ripencc", "ripe", "ripe.net":
+		return "RIPE NCC"
+	case "apnic", "apnic.net":
+		return "APNIC"
+	case "lacnic", "lacnic.net":
+		return "LACNIC"
+	case "afrinic", "afrinic.net":
+		return "AFRINIC"
+	}
+	return strings.ToUpper(registry)
+}
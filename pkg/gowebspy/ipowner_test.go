@@ -0,0 +1,154 @@
+package gowebspy
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// ownerWhoisServer emulates the bulk mode of Team Cymru's IP-to-ASN service
+// for 8.8.8.8 and 1.1.1.1.
+func ownerWhoisServer(t *testing.T) string {
+	answers := map[string]string{
+		"8.8.8.8": "15169   | 8.8.8.8          | 8.8.8.0/24          | US | arin     | 2023-12-28 | GOOGLE, US",
+		"1.1.1.1": "13335   | 1.1.1.1          | 1.1.1.0/24          | AU | apnic    | 2011-08-11 | CLOUDFLARENET, US",
+	}
+
+	port := serveOnce(t, func(conn net.Conn) {
+		scanner := bufio.NewScanner(conn)
+		var lines []string
+		for scanner.Scan() && scanner.Text() != "end" {
+			lines = append(lines, scanner.Text())
+		}
+		io.WriteString(conn, "Bulk mode; whois.cymru.com [2025-03-04 12:00:00 +0000]\n")
+		io.WriteString(conn, "AS      | IP               | BGP Prefix          | CC | Registry | Allocated  | AS Name\n")
+		for _, line := range lines {
+			if answer, ok := answers[line]; ok {
+				io.WriteString(conn, answer+"\n")
+			}
+		}
+	})
+	return net.JoinHostPort("127.0.0.1", strconv.Itoa(port))
+}
+
+const testRDAPNetwork = `{
+  "objectClassName": "ip network",
+  "handle": "NET-8-8-8-0-2",
+  "startAddress": "8.8.8.0",
+  "endAddress": "8.8.8.255",
+  "name": "GOGL",
+  "port43": "whois.arin.net",
+  "cidr0_cidrs": [{"v4prefix": "8.8.8.0", "length": 24}],
+  "entities": [{
+    "objectClassName": "entity",
+    "handle": "GOGL",
+    "roles": ["registrant"],
+    "entities": [{
+      "objectClassName": "entity",
+      "handle": "ABUSE5250-ARIN",
+      "roles": ["abuse"],
+      "vcardArray": ["vcard", [["version", {}, "text", "4.0"], ["email", {}, "text", "network-abuse@google.com"]]]
+    }]
+  }]
+}`
+
+// rdapNetworkServer answers RDAP IP queries for 8.8.8.8 and fails every
+// other one.
+func rdapNetworkServer(t *testing.T) *RDAPBootstrap {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ip/8.8.8.8", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, testRDAPNetwork)
+	})
+	mux.HandleFunc("/ip/", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"errorCode": 500, "title": "Internal Server Error"}`, http.StatusInternalServerError)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	bootstrap, err := ParseRDAPBootstrap([]byte(fmt.Sprintf(`{"services": [[["0.0.0.0/0"], ["%s"]]]}`, server.URL)))
+	if err != nil {
+		t.Fatalf("ParseRDAPBootstrap failed: %v", err)
+	}
+	return bootstrap
+}
+
+func TestLookupOwners(t *testing.T) {
+	opts := OwnerOptions{WhoisServer: ownerWhoisServer(t), Bootstrap: rdapNetworkServer(t)}
+
+	owners, err := LookupOwners(context.Background(), []string{"8.8.8.8", "10.0.0.1", "1.1.1.1", "8.8.8.8"}, opts)
+	if err == nil || !strings.Contains(err.Error(), "RDAP lookup of 1.1.1.1 failed") {
+		t.Errorf("Expected the failed RDAP lookup of 1.1.1.1 to be reported, got %v", err)
+	}
+	if len(owners) != 4 {
+		t.Fatalf("Expected one result per address, got %d", len(owners))
+	}
+
+	google := owners[0]
+	if google == nil {
+		t.Fatal("No owner for 8.8.8.8")
+	}
+	if google.ASN != 15169 || google.ASName != "GOOGLE, US" || google.Prefix != "8.8.8.0/24" ||
+		google.RIR != "ARIN" || google.Country != "US" {
+		t.Errorf("Unexpected WHOIS data for 8.8.8.8: %+v", google)
+	}
+	if google.Network != "GOGL" || google.AbuseEmail != "network-abuse@google.com" {
+		t.Errorf("Unexpected RDAP data for 8.8.8.8: %+v", google)
+	}
+	if owners[3] == nil || owners[3].ASN != 15169 {
+		t.Errorf("Repeated address was not annotated: %+v", owners[3])
+	}
+
+	if owners[1] != nil {
+		t.Errorf("Private address should have no owner, got %+v", owners[1])
+	}
+
+	cloudflare := owners[2]
+	if cloudflare == nil || cloudflare.ASN != 13335 || cloudflare.RIR != "APNIC" || cloudflare.Network != "" {
+		t.Errorf("Expected WHOIS-only data for 1.1.1.1, got %+v", cloudflare)
+	}
+}
+
+func TestLookupHopOwnersOffline(t *testing.T) {
+	db, err := ParseASNDatabase(strings.NewReader(testASNRanges))
+	if err != nil {
+		t.Fatalf("ParseASNDatabase failed: %v", err)
+	}
+
+	hops := []TracerouteHop{
+		{Number: 1, IP: "192.168.1.1"},
+		{Number: 2, IP: "*"},
+		{Number: 3, IP: "8.8.8.8"},
+	}
+	// No servers are given: the database must answer on its own.
+	if err := LookupHopOwners(context.Background(), hops, OwnerOptions{Database: db, WhoisServer: "127.0.0.1:1"}); err != nil {
+		t.Fatalf("LookupHopOwners failed: %v", err)
+	}
+
+	if hops[0].Owner != nil || hops[1].Owner != nil {
+		t.Errorf("Private and silent hops should have no owner: %+v, %+v", hops[0].Owner, hops[1].Owner)
+	}
+	if owner := hops[2].Owner; owner == nil || owner.ASN != 15169 || owner.Sources[0] != OwnerSourceDatabase {
+		t.Errorf("Unexpected owner for 8.8.8.8: %+v", owner)
+	}
+}
+
+func TestOptionsOwnerOptions(t *testing.T) {
+	dialer := &net.Dialer{}
+	bootstrap := rdapNetworkServer(t)
+	ownerOpts := Options{WhoisTimeout: 3 * time.Second, Dialer: dialer, RDAPBootstrap: bootstrap}.OwnerOptions()
+
+	if ownerOpts.Timeout != 3*time.Second || ownerOpts.Dialer != dialer || ownerOpts.Bootstrap != bootstrap {
+		t.Errorf("Expected the owner options to follow Options, got %+v", ownerOpts)
+	}
+	if ownerOpts.HTTPClient == nil {
+		t.Error("Expected an HTTP client for the RDAP queries")
+	}
+}
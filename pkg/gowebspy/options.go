@@ -0,0 +1,205 @@
+package gowebspy
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+)
+
+const (
+	ProbeDNS     = "dns"
+	ProbeHTTP    = "http"
+	ProbeContent = "content"
+	ProbeSSL     = "ssl"
+	ProbeWhois   = "whois"
+	ProbeOwner   = "owner"
+)
+
+const (
+	defaultDNSTimeout   = 5 * time.Second
+	defaultHTTPTimeout  = 10 * time.Second
+	defaultSSLTimeout   = 10 * time.Second
+	defaultWhoisTimeout = 15 * time.Second
+)
+
+// Options controls which probes GetWebsiteInfoWithOptions runs and how. The
+// zero value runs every probe with the default timeouts, which is what
+// GetWebsiteInfo does.
+type Options struct {
+	SkipDNS   bool
+	SkipSSL   bool
+	SkipWhois bool
+	// SkipRDAP goes straight to WHOIS instead of trying RDAP first.
+	SkipRDAP bool
+
+	DNSTimeout   time.Duration
+	HTTPTimeout  time.Duration
+	SSLTimeout   time.Duration
+	WhoisTimeout time.Duration
+
+	// HTTPClient is used for the HTTP request. Its redirect policy is
+	// overridden so the first response is the one reported.
+	HTTPClient *http.Client
+	Resolver   *net.Resolver
+	Dialer     *net.Dialer
+	// FollowRedirects follows the target's redirects, at most MaxRedirects
+	// of them (default 10), and reports the final destination instead.
+	// The chain is kept in WebsiteInfo.Redirects.
+	FollowRedirects bool
+	MaxRedirects    int
+	// RootCAs is used to verify the certificate chain instead of the system
+	// roots. See LoadCABundle.
+	RootCAs *x509.CertPool
+	// CheckRevocation queries the OCSP responders and CRL distribution
+	// points named in the certificate. A stapled OCSP response is always
+	// checked, since that needs no extra requests.
+	CheckRevocation bool
+	// SuffixList decides the registrable domain queried over WHOIS. Nil
+	// means the list embedded in the binary.
+	SuffixList *SuffixList
+	// RDAPBootstrap replaces the partial RDAP bootstrap registry bundled
+	// with gowebspy and the download of the full one. See
+	// RDAPOptions.Bootstrap.
+	RDAPBootstrap *RDAPBootstrap
+	// IPOwners looks up the network owner of every resolved address, using
+	// ASNDatabase instead of online queries when it is set.
+	IPOwners    bool
+	ASNDatabase *ASNDatabase
+	// GeoDatabase locates every resolved address. See LoadGeoDatabase.
+	GeoDatabase *GeoDatabase
+	// ReverseDNS looks up the PTR names of every resolved address. Share
+	// one cache between calls so repeated addresses are looked up once.
+	ReverseDNS *ReverseDNSCache
+}
+
+func DefaultOptions() Options {
+	return Options{
+		DNSTimeout:   defaultDNSTimeout,
+		HTTPTimeout:  defaultHTTPTimeout,
+		SSLTimeout:   defaultSSLTimeout,
+		WhoisTimeout: defaultWhoisTimeout,
+	}
+}
+
+// ProbeError is a non-fatal failure of a single probe. They are collected in
+// WebsiteInfo.Warnings rather than aborting the whole lookup.
+type ProbeError struct {
+	Probe string
+	Err   error
+}
+
+func (e *ProbeError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Probe, e.Err)
+}
+
+func (e *ProbeError) Unwrap() error {
+	return e.Err
+}
+
+func (o Options) withDefaults() Options {
+	if o.DNSTimeout <= 0 {
+		o.DNSTimeout = defaultDNSTimeout
+	}
+	if o.HTTPTimeout <= 0 {
+		o.HTTPTimeout = defaultHTTPTimeout
+	}
+	if o.SSLTimeout <= 0 {
+		o.SSLTimeout = defaultSSLTimeout
+	}
+	if o.WhoisTimeout <= 0 {
+		o.WhoisTimeout = defaultWhoisTimeout
+	}
+	return o
+}
+
+func (o Options) resolver() *net.Resolver {
+	if o.Resolver != nil {
+		return o.Resolver
+	}
+	if o.Dialer != nil && o.Dialer.Resolver != nil {
+		return o.Dialer.Resolver
+	}
+	return net.DefaultResolver
+}
+
+func (o Options) dialer() *net.Dialer {
+	if o.Dialer != nil {
+		return o.Dialer
+	}
+	return &net.Dialer{Resolver: o.Resolver}
+}
+
+func (o Options) httpClient() *http.Client {
+	var client http.Client
+	if o.HTTPClient != nil {
+		client = *o.HTTPClient
+	} else {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.DialContext = o.dialer().DialContext
+		client.Transport = transport
+	}
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	return &client
+}
+
+// rdapOptions returns the settings for the RDAP lookup made before WHOIS.
+// Unlike the main HTTP request, RDAP servers are expected to redirect.
+func (o Options) rdapOptions() RDAPOptions {
+	client := o.httpClient()
+	client.CheckRedirect = nil
+	return RDAPOptions{
+		Timeout:    o.WhoisTimeout,
+		HTTPClient: client,
+		Bootstrap:  o.RDAPBootstrap,
+	}
+}
+
+// RedirectOptions returns the options for following the target's redirects
+// with FollowRedirects the way GetWebsiteInfoWithOptions would: over the
+// same resolver, dialer and HTTP client, within the HTTP timeout.
+func (o Options) RedirectOptions() RedirectOptions {
+	return RedirectOptions{
+		MaxHops:    o.MaxRedirects,
+		Timeout:    o.HTTPTimeout,
+		HTTPClient: o.httpClient(),
+		SuffixList: o.SuffixList,
+	}
+}
+
+// revocationClient fetches OCSP responses and CRLs, whose servers commonly
+// redirect to a CDN.
+func (o Options) revocationClient() *http.Client {
+	client := o.httpClient()
+	client.CheckRedirect = nil
+	return client
+}
+
+// OwnerOptions returns the options for looking up network owners with
+// LookupOwners or LookupHopOwners the way GetWebsiteInfoWithOptions does for
+// the resolved addresses: over the same dialer, RDAP bootstrap and ASN
+// database, within the WHOIS timeout.
+func (o Options) OwnerOptions() OwnerOptions {
+	return OwnerOptions{
+		Timeout:    o.WhoisTimeout,
+		Database:   o.ASNDatabase,
+		Dialer:     o.dialer(),
+		HTTPClient: o.rdapOptions().HTTPClient,
+		Bootstrap:  o.RDAPBootstrap,
+	}
+}
+
+// contextDialer adapts a net.Dialer to the context-less proxy.Dialer interface
+// used by the whois client, so cancelling ctx still aborts the connect.
+type contextDialer struct {
+	ctx    context.Context
+	dialer *net.Dialer
+}
+
+func (d contextDialer) Dial(network, addr string) (net.Conn, error) {
+	return d.dialer.DialContext(d.ctx, network, addr)
+}
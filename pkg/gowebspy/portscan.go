@@ -0,0 +1,245 @@
+package gowebspy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	defaultPortTimeout     = 2 * time.Second
+	defaultPortConcurrency = 100
+)
+
+type PortState int
+
+const (
+	PortClosed PortState = iota
+	PortOpen
+	PortFiltered
+)
+
+func (s PortState) String() string {
+	switch s {
+	case PortOpen:
+		return "open"
+	case PortFiltered:
+		return "filtered"
+	default:
+		return "closed"
+	}
+}
+
+func (s PortState) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// PortResult is the outcome of a single TCP connect. Closed means the host
+// actively refused the connection; filtered means nothing answered before the
+// timeout or the network reported the host unreachable.
+type PortResult struct {
+	Port    int
+	State   PortState
+	Latency time.Duration
+	Err     error
+	// Service is only set for open ports when ScanOptions.DetectServices
+	// is enabled.
+	Service *ServiceInfo
+}
+
+type ScanOptions struct {
+	Timeout     time.Duration
+	Concurrency int
+	// Network is "tcp", "tcp4" or "tcp6". It also decides which address
+	// family is picked when host is a name.
+	Network  string
+	Dialer   *net.Dialer
+	Resolver *net.Resolver
+
+	DetectServices bool
+	ServiceTimeout time.Duration
+}
+
+// ScanPorts connects to every port of host using a pool of workers and
+// returns the results sorted by port number.
+func ScanPorts(ctx context.Context, host string, ports []int, opts ScanOptions) ([]PortResult, error) {
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultPortTimeout
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = defaultPortConcurrency
+	}
+	if opts.Network == "" {
+		opts.Network = "tcp"
+	}
+	dialer := opts.Dialer
+	if dialer == nil {
+		dialer = &net.Dialer{}
+	}
+
+	serverName := strings.Trim(host, "[]")
+	ip, err := ResolveScanTarget(ctx, host, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	jobs := make(chan int)
+	results := make([]PortResult, 0, len(ports))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for i := 0; i < min(opts.Concurrency, len(ports)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for port := range jobs {
+				result := probePort(ctx, dialer, opts.Network, ip, port, opts.Timeout)
+				if result.State == PortOpen && opts.DetectServices {
+					addr := net.JoinHostPort(ip, strconv.Itoa(port))
+					result.Service, _ = detectService(ctx, dialer, opts.Network, addr, serverName, opts.ServiceTimeout)
+				}
+				mu.Lock()
+				results = append(results, result)
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for _, port := range ports {
+		jobs <- port
+	}
+	close(jobs)
+	wg.Wait()
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Port < results[j].Port
+	})
+
+	return results, ctx.Err()
+}
+
+// ResolveScanTarget returns the address ScanPorts connects to for host: host
+// itself when it is an address, or else its first address of the family
+// opts.Network asks for.
+func ResolveScanTarget(ctx context.Context, host string, opts ScanOptions) (string, error) {
+	host = strings.Trim(host, "[]")
+	if ip := net.ParseIP(host); ip != nil {
+		return ip.String(), nil
+	}
+
+	resolver := opts.Resolver
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+
+	ipNetwork := "ip"
+	switch opts.Network {
+	case "tcp4":
+		ipNetwork = "ip4"
+	case "tcp6":
+		ipNetwork = "ip6"
+	}
+
+	ips, err := resolver.LookupIP(ctx, ipNetwork, host)
+	if err != nil {
+		return "", fmt.Errorf("IP lookup failed: %w", err)
+	}
+	if len(ips) == 0 {
+		return "", fmt.Errorf("IP lookup failed: no %s address for %s", ipNetwork, host)
+	}
+
+	return ips[0].String(), nil
+}
+
+func probePort(ctx context.Context, dialer *net.Dialer, network, ip string, port int, timeout time.Duration) PortResult {
+	result := PortResult{Port: port}
+
+	dialCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	conn, err := dialer.DialContext(dialCtx, network, net.JoinHostPort(ip, strconv.Itoa(port)))
+	result.Latency = time.Since(start)
+
+	if err == nil {
+		conn.Close()
+		result.State = PortOpen
+		return result
+	}
+
+	result.Err = err
+	result.State = portStateForError(err)
+
+	return result
+}
+
+func portStateForError(err error) PortState {
+	switch {
+	case err == nil:
+		return PortOpen
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return PortClosed
+	default:
+		return PortFiltered
+	}
+}
+
+// ParsePortList parses a comma separated list of ports and ranges such as
+// "22,80,8000-8100". Duplicates are removed and the result is sorted.
+func ParsePortList(spec string) ([]int, error) {
+	seen := make(map[int]bool)
+	var ports []int
+
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		low, high := part, part
+		if idx := strings.Index(part, "-"); idx >= 0 {
+			low, high = strings.TrimSpace(part[:idx]), strings.TrimSpace(part[idx+1:])
+		}
+
+		start, err := parsePort(low)
+		if err != nil {
+			return nil, err
+		}
+		end, err := parsePort(high)
+		if err != nil {
+			return nil, err
+		}
+		if start > end {
+			return nil, fmt.Errorf("invalid port range %q", part)
+		}
+
+		for port := start; port <= end; port++ {
+			if !seen[port] {
+				seen[port] = true
+				ports = append(ports, port)
+			}
+		}
+	}
+
+	if len(ports) == 0 {
+		return nil, fmt.Errorf("no ports in %q", spec)
+	}
+
+	sort.Ints(ports)
+	return ports, nil
+}
+
+func parsePort(s string) (int, error) {
+	port, err := strconv.Atoi(s)
+	if err != nil || port < 1 || port > 65535 {
+		return 0, fmt.Errorf("invalid port %q", s)
+	}
+	return port, nil
+}
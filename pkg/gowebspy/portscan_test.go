@@ -0,0 +1,132 @@
+package gowebspy
+
+import (
+	"context"
+	"net"
+	"os"
+	"reflect"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestParsePortList(t *testing.T) {
+	tests := []struct {
+		spec     string
+		expected []int
+		wantErr  bool
+	}{
+		{"80", []int{80}, false},
+		{"443,22,80", []int{22, 80, 443}, false},
+		{"8080-8083, 22", []int{22, 8080, 8081, 8082, 8083}, false},
+		{"80,80,79-81", []int{79, 80, 81}, false},
+		{"0", nil, true},
+		{"65536", nil, true},
+		{"100-90", nil, true},
+		{"http", nil, true},
+		{"", nil, true},
+	}
+
+	for _, test := range tests {
+		ports, err := ParsePortList(test.spec)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("ParsePortList(%q) expected an error, got %v", test.spec, ports)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParsePortList(%q) failed: %v", test.spec, err)
+			continue
+		}
+		if !reflect.DeepEqual(ports, test.expected) {
+			t.Errorf("ParsePortList(%q) = %v, want %v", test.spec, ports, test.expected)
+		}
+	}
+}
+
+func freePort(t *testing.T) int {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+	listener.Close()
+	return port
+}
+
+func TestScanPorts(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+
+	openPort := listener.Addr().(*net.TCPAddr).Port
+	closedPort := freePort(t)
+
+	results, err := ScanPorts(context.Background(), "127.0.0.1", []int{closedPort, openPort}, ScanOptions{
+		Timeout:     time.Second,
+		Concurrency: 2,
+	})
+	if err != nil {
+		t.Fatalf("ScanPorts failed: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 results, got %d", len(results))
+	}
+	if results[0].Port > results[1].Port {
+		t.Errorf("Results are not sorted by port: %v", results)
+	}
+
+	for _, result := range results {
+		switch result.Port {
+		case openPort:
+			if result.State != PortOpen {
+				t.Errorf("Port %d: state = %s, want open", result.Port, result.State)
+			}
+		case closedPort:
+			if result.State != PortClosed {
+				t.Errorf("Port %d: state = %s, want closed (%v)", result.Port, result.State, result.Err)
+			}
+		}
+	}
+}
+
+func TestResolveScanTarget(t *testing.T) {
+	for host, want := range map[string]string{"[::1]": "::1", "192.0.2.1": "192.0.2.1", "localhost": "127.0.0.1"} {
+		ip, err := ResolveScanTarget(context.Background(), host, ScanOptions{Network: "tcp4"})
+		if err != nil || ip != want {
+			t.Errorf("ResolveScanTarget(%q) = %q, %v, want %q", host, ip, err, want)
+		}
+	}
+}
+
+func TestPortStateForError(t *testing.T) {
+	tests := []struct {
+		err      error
+		expected PortState
+	}{
+		{nil, PortOpen},
+		{&net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}, PortClosed},
+		{&net.OpError{Op: "dial", Net: "tcp", Err: os.ErrDeadlineExceeded}, PortFiltered},
+		{context.DeadlineExceeded, PortFiltered},
+		{&net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", syscall.EHOSTUNREACH)}, PortFiltered},
+	}
+
+	for _, test := range tests {
+		if state := portStateForError(test.err); state != test.expected {
+			t.Errorf("portStateForError(%v) = %s, want %s", test.err, state, test.expected)
+		}
+	}
+}
@@ -0,0 +1,248 @@
+package gowebspy
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultPropagationConcurrency = 16
+
+// DefaultPropagationResolvers are large public resolvers run by different
+// operators, so between them they see most of the caches users do.
+var DefaultPropagationResolvers = []PropagationResolver{
+	{Name: "Google", Address: "8.8.8.8"},
+	{Name: "Cloudflare", Address: "1.1.1.1"},
+	{Name: "Quad9", Address: "9.9.9.9"},
+	{Name: "OpenDNS", Address: "208.67.222.222"},
+	{Name: "AdGuard", Address: "94.140.14.14"},
+	{Name: "Control D", Address: "76.76.2.0"},
+}
+
+// DefaultPropagationTypes are the record types GetDNSRecords reports.
+var DefaultPropagationTypes = []string{"A", "AAAA", "CNAME", "MX", "NS", "TXT"}
+
+type PropagationResolver struct {
+	Name string
+	// Address is "host" or "host:port".
+	Address string
+}
+
+// ParsePropagationResolver parses "name=address" or a bare address, which
+// is then also used as the name.
+func ParsePropagationResolver(s string) (PropagationResolver, error) {
+	name, address, ok := strings.Cut(s, "=")
+	if !ok {
+		address = name
+	}
+	name, address = strings.TrimSpace(name), strings.TrimSpace(address)
+	if address == "" || name == "" {
+		return PropagationResolver{}, fmt.Errorf("invalid resolver %q: want address or name=address", s)
+	}
+	return PropagationResolver{Name: name, Address: address}, nil
+}
+
+type PropagationOptions struct {
+	// Resolvers to compare. Defaults to DefaultPropagationResolvers.
+	Resolvers []PropagationResolver
+	// Types are the record types to compare. Defaults to
+	// DefaultPropagationTypes.
+	Types []string
+	// DNS is used for each query; its Servers are ignored.
+	DNS DNSQueryOptions
+	// Concurrency is how many queries are in flight at once.
+	Concurrency int
+}
+
+func (o PropagationOptions) withDefaults() PropagationOptions {
+	if len(o.Resolvers) == 0 {
+		o.Resolvers = DefaultPropagationResolvers
+	}
+	if len(o.Types) == 0 {
+		o.Types = DefaultPropagationTypes
+	}
+	if o.Concurrency <= 0 {
+		o.Concurrency = defaultPropagationConcurrency
+	}
+	o.DNS.Authoritative = false
+	return o
+}
+
+// PropagationReport compares the answers of several resolvers for the same
+// name.
+type PropagationReport struct {
+	Domain    string
+	Resolvers []PropagationResolver
+	Records   []PropagationRecord
+	// Consistent is set when every resolver gave the same answer for every
+	// record type.
+	Consistent bool
+}
+
+// PropagationRecord holds the answers for one record type.
+type PropagationRecord struct {
+	Type string
+	// Consensus is the answer most resolvers gave, sorted. Ties go to the
+	// resolver listed first.
+	Consensus []string
+	// Consistent is set when every resolver answered and gave Consensus.
+	// TTLs are not compared, as cached records count down.
+	Consistent bool
+	// MinTTL and MaxTTL span the TTLs the resolvers returned.
+	MinTTL  uint32
+	MaxTTL  uint32
+	Answers []PropagationAnswer
+}
+
+// PropagationAnswer is one resolver's answer, with how it differs from the
+// consensus.
+type PropagationAnswer struct {
+	Resolver PropagationResolver
+	Values   []string
+	// TTL is the lowest TTL of the records.
+	TTL   uint32
+	RCode string
+	RTT   time.Duration
+	// Added are values only this resolver returned and Missing consensus
+	// values it did not.
+	Added   []string
+	Missing []string
+	Error   string
+}
+
+// Matches reports whether the resolver answered with the consensus.
+func (a PropagationAnswer) Matches() bool {
+	return a.Error == "" && len(a.Added) == 0 && len(a.Missing) == 0
+}
+
+// CheckPropagation queries every resolver for each record type of domain
+// concurrently and compares the answers. A resolver that fails is reported
+// in its answer and makes the record inconsistent.
+func CheckPropagation(ctx context.Context, domain string, opts PropagationOptions) (*PropagationReport, error) {
+	opts = opts.withDefaults()
+
+	name, err := NormalizeHost(domain)
+	if err != nil {
+		return nil, fmt.Errorf("propagation check failed: %w", err)
+	}
+
+	report := &PropagationReport{Domain: name, Resolvers: opts.Resolvers, Consistent: true}
+	for _, rrType := range opts.Types {
+		report.Records = append(report.Records, PropagationRecord{
+			Type:    strings.ToUpper(rrType),
+			Answers: make([]PropagationAnswer, len(opts.Resolvers)),
+		})
+	}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, opts.Concurrency)
+	for i := range report.Records {
+		for j, resolver := range opts.Resolvers {
+			wg.Add(1)
+			go func(answer *PropagationAnswer, rrType string, resolver PropagationResolver) {
+				defer wg.Done()
+				sem <- struct{}{}
+				defer func() { <-sem }()
+
+				*answer = queryPropagation(ctx, name, rrType, resolver, opts.DNS)
+			}(&report.Records[i].Answers[j], report.Records[i].Type, resolver)
+		}
+	}
+	wg.Wait()
+
+	for i := range report.Records {
+		report.Records[i].compare()
+		if !report.Records[i].Consistent {
+			report.Consistent = false
+		}
+	}
+	return report, nil
+}
+
+func queryPropagation(ctx context.Context, name, rrType string, resolver PropagationResolver, opts DNSQueryOptions) PropagationAnswer {
+	answer := PropagationAnswer{Resolver: resolver}
+	opts.Servers = []string{resolver.Address}
+
+	response, err := QueryDNS(ctx, name, rrType, opts)
+	if err != nil {
+		answer.Error = err.Error()
+		return answer
+	}
+	answer.RCode = response.RCode
+	answer.RTT = response.RTT
+	if response.RCode == "SERVFAIL" || response.RCode == "REFUSED" {
+		answer.Error = "resolver answered " + response.RCode
+		return answer
+	}
+
+	for _, record := range response.Answer {
+		if record.Type != rrType {
+			continue
+		}
+		if len(answer.Values) == 0 || record.TTL < answer.TTL {
+			answer.TTL = record.TTL
+		}
+		answer.Values = append(answer.Values, record.Value)
+	}
+	sort.Strings(answer.Values)
+	return answer
+}
+
+// compare finds the consensus answer and how each resolver differs from it.
+func (r *PropagationRecord) compare() {
+	counts := map[string]int{}
+	for _, answer := range r.Answers {
+		if answer.Error == "" {
+			counts[strings.Join(answer.Values, "\n")]++
+		}
+	}
+	best := 0
+	for _, answer := range r.Answers {
+		if count := counts[strings.Join(answer.Values, "\n")]; answer.Error == "" && count > best {
+			r.Consensus, best = answer.Values, count
+		}
+	}
+
+	r.Consistent = true
+	first := true
+	for i := range r.Answers {
+		answer := &r.Answers[i]
+		if answer.Error != "" {
+			r.Consistent = false
+			continue
+		}
+		answer.Added = difference(answer.Values, r.Consensus)
+		answer.Missing = difference(r.Consensus, answer.Values)
+		if !answer.Matches() {
+			r.Consistent = false
+		}
+		if len(answer.Values) == 0 {
+			continue
+		}
+		if first || answer.TTL < r.MinTTL {
+			r.MinTTL = answer.TTL
+		}
+		if first || answer.TTL > r.MaxTTL {
+			r.MaxTTL = answer.TTL
+		}
+		first = false
+	}
+}
+
+// difference returns the values of a that are not in b.
+func difference(a, b []string) []string {
+	in := make(map[string]bool, len(b))
+	for _, value := range b {
+		in[value] = true
+	}
+	var result []string
+	for _, value := range a {
+		if !in[value] {
+			result = append(result, value)
+		}
+	}
+	return result
+}
@@ -0,0 +1,98 @@
+package gowebspy
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+func TestCheckPropagation(t *testing.T) {
+	current := dnsServer(t, testZone)
+	stale := dnsServer(t, func(w dns.ResponseWriter, req *dns.Msg) {
+		if req.Question[0].Qtype != dns.TypeA {
+			testZone(w, req)
+			return
+		}
+		reply := new(dns.Msg)
+		reply.SetReply(req)
+		rr, _ := dns.NewRR("example.test. 120 IN A 192.0.2.99")
+		reply.Answer = append(reply.Answer, rr)
+		w.WriteMsg(reply)
+	})
+
+	resolvers := []PropagationResolver{
+		{Name: "first", Address: current},
+		{Name: "stale", Address: stale},
+		{Name: "second", Address: current},
+	}
+	report, err := CheckPropagation(context.Background(), "example.test", PropagationOptions{
+		Resolvers: resolvers,
+		Types:     []string{"a", "MX"},
+	})
+	if err != nil {
+		t.Fatalf("CheckPropagation failed: %v", err)
+	}
+	if report.Consistent || len(report.Records) != 2 {
+		t.Fatalf("Expected an inconsistent report with two records, got %+v", report)
+	}
+
+	a := report.Records[0]
+	if a.Type != "A" || a.Consistent || strings.Join(a.Consensus, ",") != "192.0.2.10" {
+		t.Errorf("Unexpected A record comparison: %+v", a)
+	}
+	if a.MinTTL != 120 || a.MaxTTL != 300 {
+		t.Errorf("TTL range = %d-%d, want 120-300", a.MinTTL, a.MaxTTL)
+	}
+	for _, answer := range a.Answers {
+		want := answer.Resolver.Name != "stale"
+		if answer.Matches() != want {
+			t.Errorf("%s matches = %t, want %t", answer.Resolver.Name, answer.Matches(), want)
+		}
+	}
+	if stale := a.Answers[1]; len(stale.Added) != 1 || stale.Added[0] != "192.0.2.99" || len(stale.Missing) != 1 || stale.Missing[0] != "192.0.2.10" {
+		t.Errorf("Unexpected diff for the stale resolver: +%v -%v", stale.Added, stale.Missing)
+	}
+
+	if mx := report.Records[1]; !mx.Consistent || strings.Join(mx.Consensus, ",") != "10 mail.example.test." || mx.MinTTL != 3600 {
+		t.Errorf("Unexpected MX record comparison: %+v", mx)
+	}
+}
+
+func TestCheckPropagationFailedResolver(t *testing.T) {
+	server := dnsServer(t, testZone)
+
+	report, err := CheckPropagation(context.Background(), "example.test", PropagationOptions{
+		Resolvers: []PropagationResolver{{Name: "up", Address: server}, {Name: "down", Address: "127.0.0.1:1"}},
+		Types:     []string{"A"},
+		DNS:       DNSQueryOptions{Timeout: 500 * time.Millisecond},
+	})
+	if err != nil {
+		t.Fatalf("CheckPropagation failed: %v", err)
+	}
+	record := report.Records[0]
+	if report.Consistent || record.Consistent || record.Answers[1].Error == "" || !record.Answers[0].Matches() {
+		t.Errorf("Expected the failed resolver to make the record inconsistent, got %+v", record)
+	}
+}
+
+func TestParsePropagationResolver(t *testing.T) {
+	tests := []struct {
+		input string
+		want  PropagationResolver
+	}{
+		{"9.9.9.9", PropagationResolver{Name: "9.9.9.9", Address: "9.9.9.9"}},
+		{"office=10.0.0.53:5353", PropagationResolver{Name: "office", Address: "10.0.0.53:5353"}},
+	}
+	for _, test := range tests {
+		got, err := ParsePropagationResolver(test.input)
+		if err != nil || got != test.want {
+			t.Errorf("ParsePropagationResolver(%q) = %+v, %v, want %+v", test.input, got, err, test.want)
+		}
+	}
+	if _, err := ParsePropagationResolver("office="); err == nil {
+		t.Error("Expected an error for a resolver without an address")
+	}
+}
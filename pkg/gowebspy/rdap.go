@@ -0,0 +1,566 @@
+package gowebspy
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultRDAPTimeout  = 15 * time.Second
+	maxRDAPResponseSize = 4 << 20
+
+	ianaDNSBootstrapURL  = "https://data.iana.org/rdap/dns.json"
+	ianaIPv4BootstrapURL = "https://data.iana.org/rdap/ipv4.json"
+	ianaIPv6BootstrapURL = "https://data.iana.org/rdap/ipv6.json"
+)
+
+// Event actions defined by RFC 9083 that WhoisInfo is filled from.
+const (
+	RDAPEventRegistration = "registration"
+	RDAPEventExpiration   = "expiration"
+	RDAPEventLastChanged  = "last changed"
+)
+
+// The bundled registries are partial: they cover the most common TLDs and
+// the regional registries' largest address blocks, so that most lookups need
+// no download, and are no substitute for the full files published by IANA.
+var (
+	//go:embed bootstrap/partial-dns.json
+	partialDNSBootstrap []byte
+	//go:embed bootstrap/partial-ipv4.json
+	partialIPv4Bootstrap []byte
+	//go:embed bootstrap/partial-ipv6.json
+	partialIPv6Bootstrap []byte
+)
+
+// ErrRDAPUnsupported is returned when no RDAP server is known for the TLD of
+// a domain or for an address. WHOIS is the only option for those.
+var ErrRDAPUnsupported = errors.New("no RDAP server known")
+
+// RDAPBootstrap is an IANA bootstrap registry (RFC 9224), which maps TLDs or
+// IP prefixes to the base URLs of the RDAP servers responsible for them.
+type RDAPBootstrap struct {
+	Publication time.Time
+	services    map[string][]string
+	prefixes    map[netip.Prefix][]string
+}
+
+// ParseRDAPBootstrap parses a registry file in the format published at
+// https://data.iana.org/rdap/dns.json, ipv4.json or ipv6.json.
+func ParseRDAPBootstrap(data []byte) (*RDAPBootstrap, error) {
+	var file struct {
+		Publication string       `json:"publication"`
+		Services    [][][]string `json:"services"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("invalid RDAP bootstrap registry: %w", err)
+	}
+
+	bootstrap := &RDAPBootstrap{services: map[string][]string{}, prefixes: map[netip.Prefix][]string{}}
+	bootstrap.Publication, _ = time.Parse(time.RFC3339, file.Publication)
+	for _, service := range file.Services {
+		if len(service) != 2 || len(service[1]) == 0 {
+			continue
+		}
+		for _, entry := range service[0] {
+			if prefix, err := netip.ParsePrefix(entry); err == nil {
+				bootstrap.prefixes[prefix.Masked()] = service[1]
+			} else {
+				bootstrap.services[strings.ToLower(entry)] = service[1]
+			}
+		}
+	}
+	if len(bootstrap.services) == 0 && len(bootstrap.prefixes) == 0 {
+		return nil, errors.New("RDAP bootstrap registry lists no services")
+	}
+	return bootstrap, nil
+}
+
+// merge adds the entries of other, so one value can answer for domains and
+// both address families.
+func (b *RDAPBootstrap) merge(other *RDAPBootstrap) {
+	for key, servers := range other.services {
+		b.services[key] = servers
+	}
+	for prefix, servers := range other.prefixes {
+		b.prefixes[prefix] = servers
+	}
+	if other.Publication.After(b.Publication) {
+		b.Publication = other.Publication
+	}
+}
+
+// LoadRDAPBootstrap reads a registry file saved from IANA.
+func LoadRDAPBootstrap(path string) (*RDAPBootstrap, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read RDAP bootstrap registry: %w", err)
+	}
+	return ParseRDAPBootstrap(data)
+}
+
+// DomainServers returns the RDAP base URLs for domain, which must be in its
+// ASCII form, or nil if the registry has none.
+func (b *RDAPBootstrap) DomainServers(domain string) []string {
+	labels := strings.Split(domain, ".")
+	for i := range labels {
+		if servers, ok := b.services[strings.Join(labels[i:], ".")]; ok {
+			return servers
+		}
+	}
+	return nil
+}
+
+// IPServers returns the RDAP base URLs for the most specific prefix that
+// contains addr, or nil if the registry has none.
+func (b *RDAPBootstrap) IPServers(addr netip.Addr) []string {
+	addr = addr.Unmap()
+	for bits := addr.BitLen(); bits >= 0; bits-- {
+		prefix, _ := addr.Prefix(bits)
+		if servers, ok := b.prefixes[prefix]; ok {
+			return servers
+		}
+	}
+	return nil
+}
+
+// fallbackBootstrap is consulted before downloading the registries from IANA.
+var fallbackBootstrap = sync.OnceValues(func() (*RDAPBootstrap, error) {
+	bootstrap, err := ParseRDAPBootstrap(partialDNSBootstrap)
+	if err != nil {
+		return nil, err
+	}
+	for _, data := range [][]byte{partialIPv4Bootstrap, partialIPv6Bootstrap} {
+		ip, err := ParseRDAPBootstrap(data)
+		if err != nil {
+			return nil, err
+		}
+		bootstrap.merge(ip)
+	}
+	return bootstrap, nil
+})
+
+// ianaBootstrap caches the registries downloaded from IANA, by URL, for the
+// life of the process. Failed downloads are not cached so a later lookup can
+// retry.
+var ianaBootstrap struct {
+	sync.Mutex
+	registries map[string]*RDAPBootstrap
+}
+
+func fetchIANABootstrap(ctx context.Context, client *http.Client, registryURL string) (*RDAPBootstrap, error) {
+	ianaBootstrap.Lock()
+	bootstrap, ok := ianaBootstrap.registries[registryURL]
+	ianaBootstrap.Unlock()
+	if ok {
+		return bootstrap, nil
+	}
+
+	// The lock isn't held while downloading, so a slow download doesn't hold
+	// up lookups of other registries. Concurrent misses may each download
+	// the registry; the last one is kept.
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, registryURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download RDAP bootstrap registry: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download RDAP bootstrap registry: unexpected HTTP status %s", resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxRDAPResponseSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to download RDAP bootstrap registry: %w", err)
+	}
+
+	bootstrap, err = ParseRDAPBootstrap(data)
+	if err != nil {
+		return nil, err
+	}
+	ianaBootstrap.Lock()
+	defer ianaBootstrap.Unlock()
+	if ianaBootstrap.registries == nil {
+		ianaBootstrap.registries = map[string]*RDAPBootstrap{}
+	}
+	ianaBootstrap.registries[registryURL] = bootstrap
+	return bootstrap, nil
+}
+
+type RDAPOptions struct {
+	// Timeout covers the whole lookup, including any bootstrap download.
+	Timeout    time.Duration
+	HTTPClient *http.Client
+	// Bootstrap decides which server is asked about a domain or address. Nil
+	// means the partial registries bundled with gowebspy, with entries
+	// missing from them looked up in the current registries downloaded from
+	// IANA.
+	Bootstrap *RDAPBootstrap
+}
+
+func (o RDAPOptions) withDefaults() RDAPOptions {
+	if o.Timeout <= 0 {
+		o.Timeout = defaultRDAPTimeout
+	}
+	if o.HTTPClient == nil {
+		o.HTTPClient = http.DefaultClient
+	}
+	return o
+}
+
+func (o RDAPOptions) domainServers(ctx context.Context, domain string) ([]string, error) {
+	if o.Bootstrap != nil {
+		return o.Bootstrap.DomainServers(domain), nil
+	}
+
+	fallback, err := fallbackBootstrap()
+	if err != nil {
+		return nil, err
+	}
+	if servers := fallback.DomainServers(domain); servers != nil {
+		return servers, nil
+	}
+
+	current, err := fetchIANABootstrap(ctx, o.HTTPClient, ianaDNSBootstrapURL)
+	if err != nil {
+		return nil, err
+	}
+	return current.DomainServers(domain), nil
+}
+
+func (o RDAPOptions) ipServers(ctx context.Context, addr netip.Addr) ([]string, error) {
+	if o.Bootstrap != nil {
+		return o.Bootstrap.IPServers(addr), nil
+	}
+
+	fallback, err := fallbackBootstrap()
+	if err != nil {
+		return nil, err
+	}
+	if servers := fallback.IPServers(addr); servers != nil {
+		return servers, nil
+	}
+
+	registryURL := ianaIPv4BootstrapURL
+	if addr.Unmap().Is6() {
+		registryURL = ianaIPv6BootstrapURL
+	}
+	current, err := fetchIANABootstrap(ctx, o.HTTPClient, registryURL)
+	if err != nil {
+		return nil, err
+	}
+	return current.IPServers(addr), nil
+}
+
+// RDAPDomain is the typed form of an RDAP domain object (RFC 9083).
+type RDAPDomain struct {
+	Handle      string
+	Name        string
+	UnicodeName string
+	// Registrar and RegistrarIANAID come from the entity with the registrar
+	// role.
+	Registrar       string
+	RegistrarIANAID string
+	Status          []string
+	Events          []RDAPEvent
+	Nameservers     []string
+	Entities        []RDAPEntity
+	// DNSSEC is nil when the server did not describe the delegation.
+	DNSSEC *RDAPSecureDNS
+	Port43 string
+	// URL is the RDAP URL that answered.
+	URL string
+}
+
+type RDAPEvent struct {
+	Action string
+	Actor  string
+	Date   time.Time
+}
+
+// RDAPEntity is a contact attached to an object. The contact details come
+// from its vCard and are often redacted by the registry.
+type RDAPEntity struct {
+	Handle       string
+	Roles        []string
+	Name         string
+	Organization string
+	Email        string
+	Phone        string
+	Entities     []RDAPEntity
+}
+
+type RDAPSecureDNS struct {
+	DelegationSigned bool
+	DS               []RDAPDSRecord
+}
+
+type RDAPDSRecord struct {
+	KeyTag     int
+	Algorithm  int
+	DigestType int
+	Digest     string
+}
+
+// Event returns the date of the first event with the given action.
+func (d *RDAPDomain) Event(action string) (time.Time, bool) {
+	for _, event := range d.Events {
+		if event.Action == action {
+			return event.Date, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// RDAPError is an error response from an RDAP server. A StatusCode of 404
+// means the registry has no such domain.
+type RDAPError struct {
+	URL         string
+	StatusCode  int
+	Title       string
+	Description []string
+}
+
+func (e *RDAPError) Error() string {
+	msg := fmt.Sprintf("RDAP server returned HTTP %d", e.StatusCode)
+	if e.Title != "" {
+		msg += ": " + e.Title
+	}
+	if len(e.Description) > 0 {
+		msg += " (" + strings.Join(e.Description, " ") + ")"
+	}
+	return msg
+}
+
+// LookupRDAP asks the RDAP server responsible for domain about it. domain
+// should be a registrable domain; see RegistrableDomain.
+func LookupRDAP(ctx context.Context, domain string, opts RDAPOptions) (*RDAPDomain, error) {
+	opts = opts.withDefaults()
+
+	domain, err := NormalizeHost(domain)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+
+	servers, err := opts.domainServers(ctx, domain)
+	if err != nil {
+		return nil, err
+	}
+	if len(servers) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrRDAPUnsupported, domain)
+	}
+
+	for _, server := range servers {
+		queryURL := strings.TrimSuffix(server, "/") + "/domain/" + url.PathEscape(domain)
+		var object *rdapObject
+		object, err = fetchRDAPObject(ctx, opts.HTTPClient, queryURL, "domain")
+		if err == nil {
+			return object.domain(queryURL), nil
+		}
+		var rdapErr *RDAPError
+		if errors.As(err, &rdapErr) && rdapErr.StatusCode == http.StatusNotFound {
+			break
+		}
+	}
+	return nil, err
+}
+
+// rdapObject holds the members of an RDAP response that are used. Domain,
+// IP network, nameserver and entity objects share it.
+type rdapObject struct {
+	ObjectClassName string          `json:"objectClassName"`
+	Handle          string          `json:"handle"`
+	Name            string          `json:"name"`
+	Country         string          `json:"country"`
+	LDHName         string          `json:"ldhName"`
+	UnicodeName     string          `json:"unicodeName"`
+	Status          []string        `json:"status"`
+	Roles           []string        `json:"roles"`
+	Port43          string          `json:"port43"`
+	VCardArray      json.RawMessage `json:"vcardArray"`
+	Events          []struct {
+		Action string `json:"eventAction"`
+		Actor  string `json:"eventActor"`
+		Date   string `json:"eventDate"`
+	} `json:"events"`
+	PublicIDs []struct {
+		Type       string `json:"type"`
+		Identifier string `json:"identifier"`
+	} `json:"publicIds"`
+	Nameservers []rdapObject `json:"nameservers"`
+	Entities    []rdapObject `json:"entities"`
+	SecureDNS   *struct {
+		DelegationSigned bool `json:"delegationSigned"`
+		DSData           []struct {
+			KeyTag     int    `json:"keyTag"`
+			Algorithm  int    `json:"algorithm"`
+			DigestType int    `json:"digestType"`
+			Digest     string `json:"digest"`
+		} `json:"dsData"`
+	} `json:"secureDNS"`
+
+	// IP networks. The CIDR list is the cidr0 extension; ARIN also names the
+	// origin ASes of the network.
+	CIDRs []struct {
+		V4Prefix string `json:"v4prefix"`
+		V6Prefix string `json:"v6prefix"`
+		Length   int    `json:"length"`
+	} `json:"cidr0_cidrs"`
+	OriginASNs []uint32 `json:"arin_originas0_originautnums"`
+
+	// Error responses.
+	ErrorCode   int      `json:"errorCode"`
+	Title       string   `json:"title"`
+	Description []string `json:"description"`
+}
+
+// fetchRDAPObject queries queryURL and checks that the answer is an object
+// of the given class.
+func fetchRDAPObject(ctx context.Context, client *http.Client, queryURL, class string) (*rdapObject, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, queryURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/rdap+json, application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("RDAP request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxRDAPResponseSize))
+	if err != nil {
+		return nil, fmt.Errorf("RDAP request failed: %w", err)
+	}
+
+	var object rdapObject
+	decodeErr := json.Unmarshal(body, &object)
+	if resp.StatusCode != http.StatusOK {
+		return nil, &RDAPError{URL: queryURL, StatusCode: resp.StatusCode, Title: object.Title, Description: object.Description}
+	}
+	if decodeErr != nil {
+		return nil, fmt.Errorf("invalid RDAP response: %w", decodeErr)
+	}
+	if object.ObjectClassName != class {
+		return nil, fmt.Errorf("invalid RDAP response: expected a %s object, got %q", class, object.ObjectClassName)
+	}
+	return &object, nil
+}
+
+func (o *rdapObject) domain(queryURL string) *RDAPDomain {
+	domain := &RDAPDomain{
+		Handle:      o.Handle,
+		Name:        strings.ToLower(o.LDHName),
+		UnicodeName: o.UnicodeName,
+		Status:      o.Status,
+		Port43:      o.Port43,
+		URL:         queryURL,
+	}
+
+	for _, event := range o.Events {
+		date, _ := time.Parse(time.RFC3339, event.Date)
+		domain.Events = append(domain.Events, RDAPEvent{Action: event.Action, Actor: event.Actor, Date: date})
+	}
+	for _, nameserver := range o.Nameservers {
+		domain.Nameservers = append(domain.Nameservers, strings.ToLower(strings.TrimSuffix(nameserver.LDHName, ".")))
+	}
+	for i := range o.Entities {
+		entity := o.Entities[i].entity()
+		domain.Entities = append(domain.Entities, entity)
+		if domain.Registrar == "" && hasRole(entity.Roles, "registrar") {
+			domain.Registrar = entity.Name
+			if domain.Registrar == "" {
+				domain.Registrar = entity.Organization
+			}
+			for _, id := range o.Entities[i].PublicIDs {
+				if id.Type == "IANA Registrar ID" {
+					domain.RegistrarIANAID = id.Identifier
+				}
+			}
+		}
+	}
+
+	if o.SecureDNS != nil {
+		domain.DNSSEC = &RDAPSecureDNS{DelegationSigned: o.SecureDNS.DelegationSigned}
+		for _, ds := range o.SecureDNS.DSData {
+			domain.DNSSEC.DS = append(domain.DNSSEC.DS, RDAPDSRecord{
+				KeyTag:     ds.KeyTag,
+				Algorithm:  ds.Algorithm,
+				DigestType: ds.DigestType,
+				Digest:     ds.Digest,
+			})
+		}
+	}
+	return domain
+}
+
+func (o *rdapObject) entity() RDAPEntity {
+	vcard := parseJCard(o.VCardArray)
+	entity := RDAPEntity{
+		Handle:       o.Handle,
+		Roles:        o.Roles,
+		Name:         vcard["fn"],
+		Organization: vcard["org"],
+		Email:        vcard["email"],
+		Phone:        strings.TrimPrefix(vcard["tel"], "tel:"),
+	}
+	for i := range o.Entities {
+		entity.Entities = append(entity.Entities, o.Entities[i].entity())
+	}
+	return entity
+}
+
+// parseJCard returns the first text value of each property of a jCard
+// (RFC 7095), such as ["vcard", [["fn", {}, "text", "Example Registrar"]]].
+func parseJCard(raw json.RawMessage) map[string]string {
+	values := map[string]string{}
+
+	var card []json.RawMessage
+	if json.Unmarshal(raw, &card) != nil || len(card) != 2 {
+		return values
+	}
+	var properties [][]json.RawMessage
+	if json.Unmarshal(card[1], &properties) != nil {
+		return values
+	}
+
+	for _, property := range properties {
+		if len(property) < 4 {
+			continue
+		}
+		var name, value string
+		if json.Unmarshal(property[0], &name) != nil || json.Unmarshal(property[3], &value) != nil {
+			continue
+		}
+		if _, seen := values[name]; !seen && value != "" {
+			values[name] = value
+		}
+	}
+	return values
+}
+
+func hasRole(roles []string, role string) bool {
+	for _, r := range roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
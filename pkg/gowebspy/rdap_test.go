@@ -0,0 +1,155 @@
+package gowebspy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+const testRDAPDomain = `{
+  "objectClassName": "domain",
+  "handle": "2336799_DOMAIN_COM-VRSN",
+  "ldhName": "EXAMPLE.COM",
+  "status": ["client delete prohibited", "client transfer prohibited"],
+  "events": [
+    {"eventAction": "registration", "eventDate": "1995-08-14T04:00:00Z"},
+    {"eventAction": "expiration", "eventDate": "2025-08-13T04:00:00Z"},
+    {"eventAction": "last changed", "eventDate": "2024-08-14T07:01:34Z"},
+    {"eventAction": "last update of RDAP database", "eventDate": "2025-03-04T12:00:00Z"}
+  ],
+  "entities": [{
+    "objectClassName": "entity",
+    "handle": "376",
+    "roles": ["registrar"],
+    "publicIds": [{"type": "IANA Registrar ID", "identifier": "376"}],
+    "vcardArray": ["vcard", [["version", {}, "text", "4.0"], ["fn", {}, "text", "Example Registrar, Inc."]]],
+    "entities": [{
+      "objectClassName": "entity",
+      "roles": ["abuse"],
+      "vcardArray": ["vcard", [
+        ["version", {}, "text", "4.0"],
+        ["fn", {}, "text", ""],
+        ["tel", {"type": "voice"}, "uri", "tel:+1.5555555555"],
+        ["email", {}, "text", "abuse@registrar.example"]
+      ]]
+    }]
+  }],
+  "nameservers": [
+    {"objectClassName": "nameserver", "ldhName": "A.IANA-SERVERS.NET"},
+    {"objectClassName": "nameserver", "ldhName": "B.IANA-SERVERS.NET"}
+  ],
+  "secureDNS": {
+    "delegationSigned": true,
+    "dsData": [{"keyTag": 370, "algorithm": 13, "digestType": 2, "digest": "BE74359954660069D5C63D200C39F5603827D7DD02B56F120EE9F3A86764247C"}]
+  },
+  "port43": "whois.verisign-grs.com"
+}`
+
+// rdapServer serves testRDAPDomain for example.com and an RDAP error for
+// every other domain. It returns a bootstrap registry pointing .com at it.
+func rdapServer(t *testing.T) *RDAPBootstrap {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/rdap/domain/example.com", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/rdap+json")
+		fmt.Fprint(w, testRDAPDomain)
+	})
+	mux.HandleFunc("/rdap/domain/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/rdap+json")
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"errorCode": 404, "title": "Not Found", "description": ["domain not registered"]}`)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	bootstrap, err := ParseRDAPBootstrap([]byte(fmt.Sprintf(`{
+		"publication": "2025-03-04T19:00:01Z",
+		"services": [[["com", "net"], ["%s/rdap/"]]]
+	}`, server.URL)))
+	if err != nil {
+		t.Fatalf("ParseRDAPBootstrap failed: %v", err)
+	}
+	return bootstrap
+}
+
+func TestLookupRDAP(t *testing.T) {
+	bootstrap := rdapServer(t)
+
+	domain, err := LookupRDAP(context.Background(), "Example.com", RDAPOptions{Bootstrap: bootstrap})
+	if err != nil {
+		t.Fatalf("LookupRDAP failed: %v", err)
+	}
+
+	if domain.Name != "example.com" || domain.Handle != "2336799_DOMAIN_COM-VRSN" {
+		t.Errorf("Name = %q, Handle = %q", domain.Name, domain.Handle)
+	}
+	if domain.Registrar != "Example Registrar, Inc." || domain.RegistrarIANAID != "376" {
+		t.Errorf("Registrar = %q (%q)", domain.Registrar, domain.RegistrarIANAID)
+	}
+	if expires, ok := domain.Event(RDAPEventExpiration); !ok || !expires.Equal(time.Date(2025, 8, 13, 4, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expiration = %v, %v", expires, ok)
+	}
+	if len(domain.Nameservers) != 2 || domain.Nameservers[0] != "a.iana-servers.net" {
+		t.Errorf("Nameservers = %v", domain.Nameservers)
+	}
+	if len(domain.Status) != 2 {
+		t.Errorf("Status = %v", domain.Status)
+	}
+	if domain.DNSSEC == nil || !domain.DNSSEC.DelegationSigned || len(domain.DNSSEC.DS) != 1 || domain.DNSSEC.DS[0].KeyTag != 370 {
+		t.Errorf("DNSSEC = %+v", domain.DNSSEC)
+	}
+
+	if len(domain.Entities) != 1 || len(domain.Entities[0].Entities) != 1 {
+		t.Fatalf("Entities = %+v", domain.Entities)
+	}
+	abuse := domain.Entities[0].Entities[0]
+	if abuse.Email != "abuse@registrar.example" || abuse.Phone != "+1.5555555555" || abuse.Name != "" {
+		t.Errorf("Abuse contact = %+v", abuse)
+	}
+}
+
+func TestLookupRDAPErrors(t *testing.T) {
+	bootstrap := rdapServer(t)
+
+	_, err := LookupRDAP(context.Background(), "missing.net", RDAPOptions{Bootstrap: bootstrap})
+	var rdapErr *RDAPError
+	if !errors.As(err, &rdapErr) || rdapErr.StatusCode != http.StatusNotFound || rdapErr.Title != "Not Found" {
+		t.Errorf("Expected an RDAP 404 error, got %v", err)
+	}
+
+	_, err = LookupRDAP(context.Background(), "example.org", RDAPOptions{Bootstrap: bootstrap})
+	if !errors.Is(err, ErrRDAPUnsupported) {
+		t.Errorf("Expected ErrRDAPUnsupported, got %v", err)
+	}
+}
+
+func TestGetWhoisInfoUsesRDAP(t *testing.T) {
+	bootstrap := rdapServer(t)
+
+	info, err := getWhoisInfo(context.Background(), "www.example.com", Options{RDAPBootstrap: bootstrap}.withDefaults())
+	if err != nil {
+		t.Fatalf("getWhoisInfo failed: %v", err)
+	}
+	if info.Source != WhoisSourceRDAP || info.RDAP == nil {
+		t.Fatalf("Source = %q, expected RDAP data", info.Source)
+	}
+	if info.Domain != "example.com" || info.Registrar != "Example Registrar, Inc." {
+		t.Errorf("Domain = %q, Registrar = %q", info.Domain, info.Registrar)
+	}
+	if info.CreatedDate != "1995-08-14T04:00:00Z" || info.UpdatedDate != "2024-08-14T07:01:34Z" {
+		t.Errorf("CreatedDate = %q, UpdatedDate = %q", info.CreatedDate, info.UpdatedDate)
+	}
+}
+
+func TestFallbackRDAPBootstrap(t *testing.T) {
+	bootstrap, err := fallbackBootstrap()
+	if err != nil {
+		t.Fatalf("Fallback bootstrap registry does not parse: %v", err)
+	}
+	if servers := bootstrap.DomainServers("example.com"); len(servers) == 0 {
+		t.Error("Fallback bootstrap registry has no server for .com")
+	}
+}
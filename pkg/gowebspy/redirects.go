@@ -0,0 +1,278 @@
+package gowebspy
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	defaultMaxRedirects    = 10
+	defaultRedirectTimeout = 30 * time.Second
+)
+
+// ErrRedirectLoop and ErrTooManyRedirects are returned by FollowRedirects,
+// together with the hops followed so far, when the chain never reaches a
+// final response.
+var (
+	ErrRedirectLoop     = errors.New("redirect loop")
+	ErrTooManyRedirects = errors.New("too many redirects")
+)
+
+type RedirectOptions struct {
+	// MaxHops is the number of redirects followed before giving up.
+	// Defaults to 10.
+	MaxHops int
+	// Timeout covers the whole chain.
+	Timeout time.Duration
+	// HTTPClient makes the requests. Its redirect policy is overridden so
+	// every hop is seen.
+	HTTPClient *http.Client
+	// SuffixList decides which hops cross to another registrable domain.
+	// Nil means the list embedded in the binary.
+	SuffixList *SuffixList
+}
+
+func (o RedirectOptions) withDefaults() RedirectOptions {
+	if o.MaxHops <= 0 {
+		o.MaxHops = defaultMaxRedirects
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = defaultRedirectTimeout
+	}
+	return o
+}
+
+// RedirectHop is one request of a redirect chain.
+type RedirectHop struct {
+	URL        string
+	StatusCode int
+	// Location is the absolute URL the hop redirects to, empty for the
+	// final response.
+	Location string
+	Headers  http.Header
+	Timing   *HTTPTiming
+	// TLS is nil for plain HTTP hops.
+	TLS *RedirectTLS
+	// Downgrade is set when an HTTPS hop redirects to plain HTTP.
+	Downgrade bool
+	// CrossDomain is set when the hop redirects to another registrable
+	// domain, e.g. from example.com to example.net but not to
+	// www.example.com.
+	CrossDomain bool
+}
+
+// RedirectTLS is the connection a hop was served over.
+type RedirectTLS struct {
+	Version     string
+	CipherSuite string
+	Certificate CertificateInfo
+}
+
+// RedirectChain is the path from a URL to its final response.
+type RedirectChain struct {
+	Hops []RedirectHop
+	// FinalURL is the URL of the final response, empty when the chain
+	// didn't reach one.
+	FinalURL string
+	// Loop is set when a hop redirects back to a URL already visited, and
+	// TooManyRedirects when MaxHops was reached.
+	Loop             bool
+	TooManyRedirects bool
+}
+
+// Redirects is the number of redirects followed.
+func (c *RedirectChain) Redirects() int {
+	count := 0
+	for _, hop := range c.Hops {
+		if hop.Location != "" {
+			count++
+		}
+	}
+	return count
+}
+
+// Downgrade reports whether any hop redirects from HTTPS to HTTP.
+func (c *RedirectChain) Downgrade() bool {
+	for _, hop := range c.Hops {
+		if hop.Downgrade {
+			return true
+		}
+	}
+	return false
+}
+
+// CrossDomain reports whether any hop leaves its registrable domain.
+func (c *RedirectChain) CrossDomain() bool {
+	for _, hop := range c.Hops {
+		if hop.CrossDomain {
+			return true
+		}
+	}
+	return false
+}
+
+// FollowRedirects requests rawURL and follows its redirects one at a time,
+// recording every hop. On failure the hops followed so far are returned with
+// the error.
+func FollowRedirects(ctx context.Context, rawURL string, opts RedirectOptions) (*RedirectChain, error) {
+	opts = opts.withDefaults()
+
+	if !strings.HasPrefix(rawURL, "http://") && !strings.HasPrefix(rawURL, "https://") {
+		rawURL = "https://" + rawURL
+	}
+	start, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse URL: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+
+	chain, final, err := followRedirects(ctx, start, opts)
+	if err != nil {
+		return chain, err
+	}
+	timing, err := final.finish()
+	chain.Hops[len(chain.Hops)-1].Timing = timing
+	if err != nil {
+		return chain, fmt.Errorf("request to %s failed: failed to read body: %w", chain.FinalURL, err)
+	}
+	return chain, nil
+}
+
+// followRedirects follows the chain from start and returns the final
+// response with its body unread, so GetWebsiteInfoWithOptions can use it
+// without requesting the destination again. The caller sets the final hop's
+// Timing once the body is read. opts.Timeout is left to the caller, whose
+// context must also cover reading the body.
+func followRedirects(ctx context.Context, start *url.URL, opts RedirectOptions) (*RedirectChain, *timedResponse, error) {
+	opts = opts.withDefaults()
+
+	client := redirectClient(opts.HTTPClient)
+	chain := &RedirectChain{}
+	visited := map[string]bool{}
+	current := start
+
+	for {
+		visited[current.String()] = true
+
+		hop, next, final, err := fetchHop(ctx, client, current)
+		if err != nil {
+			return chain, nil, fmt.Errorf("request to %s failed: %w", current, err)
+		}
+		if next != nil {
+			hop.Location = next.String()
+			hop.Downgrade = current.Scheme == "https" && next.Scheme == "http"
+			hop.CrossDomain = crossDomain(opts.SuffixList, current.Hostname(), next.Hostname())
+		}
+		chain.Hops = append(chain.Hops, *hop)
+
+		switch {
+		case next == nil:
+			chain.FinalURL = current.String()
+			return chain, final, nil
+		case visited[next.String()]:
+			chain.Loop = true
+			return chain, nil, fmt.Errorf("%w: %s redirects back to %s", ErrRedirectLoop, current, next)
+		case chain.Redirects() >= opts.MaxHops:
+			chain.TooManyRedirects = true
+			return chain, nil, fmt.Errorf("%w: stopped after %d", ErrTooManyRedirects, opts.MaxHops)
+		}
+		current = next
+	}
+}
+
+// fetchHop makes one request and returns the hop and, for a redirect, the
+// URL it points to. A redirect's body is read and closed; for the final
+// response it is returned unread instead.
+func fetchHop(ctx context.Context, client *http.Client, target *url.URL) (*RedirectHop, *url.URL, *timedResponse, error) {
+	resp, err := timedGet(ctx, client, target.String())
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	hop := &RedirectHop{
+		URL:        target.String(),
+		StatusCode: resp.StatusCode,
+		Headers:    resp.Header,
+		TLS:        newRedirectTLS(resp.TLS),
+	}
+
+	var next *url.URL
+	if isRedirect(resp.StatusCode) {
+		next, err = resp.Location()
+		// A redirect status without a Location has nowhere to go, so it is
+		// the final response.
+		if errors.Is(err, http.ErrNoLocation) {
+			next, err = nil, nil
+		}
+		if err != nil {
+			resp.Body.Close()
+			return nil, nil, nil, fmt.Errorf("invalid Location %q: %w", resp.Header.Get("Location"), err)
+		}
+	}
+	if next == nil {
+		return hop, nil, resp, nil
+	}
+
+	if hop.Timing, err = resp.finish(); err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to read body: %w", err)
+	}
+	return hop, next, nil, nil
+}
+
+func isRedirect(status int) bool {
+	switch status {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther,
+		http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		return true
+	}
+	return false
+}
+
+func newRedirectTLS(state *tls.ConnectionState) *RedirectTLS {
+	if state == nil {
+		return nil
+	}
+	hopTLS := &RedirectTLS{
+		Version:     tls.VersionName(state.Version),
+		CipherSuite: tls.CipherSuiteName(state.CipherSuite),
+	}
+	if len(state.PeerCertificates) > 0 {
+		hopTLS.Certificate = describeCertificate(state.PeerCertificates[0])
+	}
+	return hopTLS
+}
+
+// crossDomain compares registrable domains, falling back to the host names
+// for IP addresses and hosts that have none.
+func crossDomain(list *SuffixList, from, to string) bool {
+	fromDomain, err := list.RegistrableDomain(from)
+	if err != nil {
+		fromDomain = strings.ToLower(from)
+	}
+	toDomain, err := list.RegistrableDomain(to)
+	if err != nil {
+		toDomain = strings.ToLower(to)
+	}
+	return fromDomain != toDomain
+}
+
+// redirectClient copies client, or builds a default one, so that it returns
+// redirects instead of following them.
+func redirectClient(client *http.Client) *http.Client {
+	var copied http.Client
+	if client != nil {
+		copied = *client
+	}
+	copied.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	return &copied
+}
@@ -0,0 +1,177 @@
+package gowebspy
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFollowRedirects(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/start", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/middle", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/middle", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Hop", "middle")
+		http.Redirect(w, r, "/final", http.StatusTemporaryRedirect)
+	})
+	mux.HandleFunc("/final", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("done"))
+	})
+	server := httptest.NewTLSServer(mux)
+	defer server.Close()
+
+	chain, err := FollowRedirects(context.Background(), server.URL+"/start", RedirectOptions{HTTPClient: server.Client()})
+	if err != nil {
+		t.Fatalf("FollowRedirects failed: %v", err)
+	}
+
+	if len(chain.Hops) != 3 || chain.Redirects() != 2 {
+		t.Fatalf("Expected 3 hops and 2 redirects, got %+v", chain.Hops)
+	}
+	wantStatus := []int{http.StatusMovedPermanently, http.StatusTemporaryRedirect, http.StatusOK}
+	for i, hop := range chain.Hops {
+		if hop.StatusCode != wantStatus[i] {
+			t.Errorf("Hop %d status = %d, want %d", i, hop.StatusCode, wantStatus[i])
+		}
+		if hop.TLS == nil || hop.TLS.Version == "" || hop.Timing == nil {
+			t.Errorf("Hop %d is missing TLS or timing: %+v", i, hop)
+		}
+	}
+	if chain.Hops[0].Location != server.URL+"/middle" {
+		t.Errorf("Location = %q, want an absolute URL", chain.Hops[0].Location)
+	}
+	if chain.Hops[1].Headers.Get("X-Hop") != "middle" {
+		t.Errorf("Expected the middle hop's headers, got %v", chain.Hops[1].Headers)
+	}
+	if chain.Hops[2].Location != "" || chain.FinalURL != server.URL+"/final" {
+		t.Errorf("Unexpected final hop %q / %q", chain.Hops[2].Location, chain.FinalURL)
+	}
+	if chain.Downgrade() || chain.CrossDomain() || chain.Loop {
+		t.Errorf("Expected no downgrade, cross-domain hop or loop")
+	}
+}
+
+func TestFollowRedirectsLoop(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/a", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/b", http.StatusFound)
+	})
+	mux.HandleFunc("/b", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/a", http.StatusFound)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	chain, err := FollowRedirects(context.Background(), server.URL+"/a", RedirectOptions{})
+	if !errors.Is(err, ErrRedirectLoop) {
+		t.Fatalf("Expected ErrRedirectLoop, got %v", err)
+	}
+	if !chain.Loop || len(chain.Hops) != 2 || chain.FinalURL != "" {
+		t.Errorf("Unexpected chain: %+v", chain)
+	}
+}
+
+func TestFollowRedirectsMaxHops(t *testing.T) {
+	count := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		count++
+		http.Redirect(w, r, "/"+strings.Repeat("x", count), http.StatusFound)
+	}))
+	defer server.Close()
+
+	chain, err := FollowRedirects(context.Background(), server.URL, RedirectOptions{MaxHops: 3})
+	if !errors.Is(err, ErrTooManyRedirects) {
+		t.Fatalf("Expected ErrTooManyRedirects, got %v", err)
+	}
+	if !chain.TooManyRedirects || len(chain.Hops) != 3 {
+		t.Errorf("Expected 3 hops, got %d", len(chain.Hops))
+	}
+}
+
+func TestFollowRedirectsDowngradeAndCrossDomain(t *testing.T) {
+	plain := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("plain"))
+	}))
+	defer plain.Close()
+	_, port, _ := net.SplitHostPort(strings.TrimPrefix(plain.URL, "http://"))
+
+	secure := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "http://localhost:"+port+"/", http.StatusFound)
+	}))
+	defer secure.Close()
+
+	chain, err := FollowRedirects(context.Background(), secure.URL, RedirectOptions{HTTPClient: secure.Client()})
+	if err != nil {
+		t.Fatalf("FollowRedirects failed: %v", err)
+	}
+	if !chain.Hops[0].Downgrade || !chain.Downgrade() {
+		t.Error("Expected an HTTPS to HTTP downgrade")
+	}
+	if !chain.Hops[0].CrossDomain {
+		t.Error("Expected 127.0.0.1 to localhost to cross domains")
+	}
+
+	if crossDomain(nil, "example.com", "www.example.com") {
+		t.Error("Expected www.example.com to be the same registrable domain")
+	}
+	if !crossDomain(nil, "example.co.uk", "other.co.uk") {
+		t.Error("Expected other.co.uk to be another registrable domain")
+	}
+}
+
+func TestGetWebsiteInfoFollowRedirects(t *testing.T) {
+	homeRequests := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/home", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/home", func(w http.ResponseWriter, r *http.Request) {
+		homeRequests++
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head><title>Home</title></head></html>`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	info, err := GetWebsiteInfoWithOptions(context.Background(), server.URL+"/", Options{
+		SkipWhois:       true,
+		FollowRedirects: true,
+	})
+	if err != nil {
+		t.Fatalf("GetWebsiteInfoWithOptions failed: %v", err)
+	}
+	if info.StatusCode != http.StatusOK || info.Title != "Home" || info.URL != server.URL+"/home" {
+		t.Errorf("Expected the final destination, got %d %q at %s", info.StatusCode, info.Title, info.URL)
+	}
+	if info.Redirects == nil || info.Redirects.Redirects() != 1 {
+		t.Fatalf("Expected one redirect, got %+v", info.Redirects)
+	}
+	if homeRequests != 1 {
+		t.Errorf("Expected the destination to be requested once, got %d requests", homeRequests)
+	}
+	if final := info.Redirects.Hops[1]; final.Timing != info.Timing {
+		t.Errorf("Expected the final hop to share the main timing, got %+v", final.Timing)
+	}
+}
+
+func TestOptionsRedirectOptions(t *testing.T) {
+	list, err := ParseSuffixList(strings.NewReader(testSuffixList))
+	if err != nil {
+		t.Fatalf("ParseSuffixList failed: %v", err)
+	}
+	dialer := &net.Dialer{}
+	redirectOpts := Options{MaxRedirects: 3, HTTPTimeout: time.Second, SuffixList: list, Dialer: dialer}.RedirectOptions()
+
+	if redirectOpts.MaxHops != 3 || redirectOpts.Timeout != time.Second || redirectOpts.SuffixList != list {
+		t.Errorf("Expected the redirect options to follow Options, got %+v", redirectOpts)
+	}
+	if redirectOpts.HTTPClient == nil || redirectOpts.HTTPClient.Transport.(*http.Transport).DialContext == nil {
+		t.Error("Expected an HTTP client dialing through Options.Dialer")
+	}
+}
@@ -0,0 +1,1325 @@
+package gowebspy
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// ReportSchemaVersion identifies the layout of Report. Fields may be added
+// without changing it; renaming or removing a field bumps the version.
+const ReportSchemaVersion = "1"
+
+// Report is the machine-readable form of everything gowebspy gathered for a
+// target. Durations are expressed in milliseconds and times in RFC 3339.
+// Every section carries its own error so a failed probe doesn't hide the
+// results of the others.
+type Report struct {
+	SchemaVersion string              `json:"schema_version"`
+	Target        string              `json:"target"`
+	GeneratedAt   time.Time           `json:"generated_at"`
+	Error         string              `json:"error,omitempty"`
+	Warnings      []WarningEntry      `json:"warnings,omitempty"`
+	Website       *WebsiteSection     `json:"website,omitempty"`
+	Redirects     *RedirectSection    `json:"redirects,omitempty"`
+	Security      *SecuritySection    `json:"security_headers,omitempty"`
+	SSL           *SSLSection         `json:"ssl,omitempty"`
+	TLSAudit      *TLSAuditSection    `json:"tls_audit,omitempty"`
+	Whois         *WhoisSection       `json:"whois,omitempty"`
+	DNS           *DNSSection         `json:"dns,omitempty"`
+	DNSSEC        *DNSSECSection      `json:"dnssec,omitempty"`
+	EmailSecurity *EmailSection       `json:"email_security,omitempty"`
+	Propagation   *PropagationSection `json:"propagation,omitempty"`
+	Subdomains    *SubdomainSection   `json:"subdomains,omitempty"`
+	Ports         *PortSection        `json:"ports,omitempty"`
+	Traceroute    *TracerouteSection  `json:"traceroute,omitempty"`
+	DualStack     *DualStackSection   `json:"dual_stack,omitempty"`
+}
+
+type WebsiteSection struct {
+	URL             string              `json:"url"`
+	IP              []string            `json:"ip"`
+	Addresses       []AddressEntry      `json:"addresses,omitempty"`
+	StatusCode      int                 `json:"status_code"`
+	Server          string              `json:"server"`
+	ContentType     string              `json:"content_type"`
+	ResponseTimeMS  float64             `json:"response_time_ms"`
+	Timing          *TimingEntry        `json:"timing,omitempty"`
+	Title           string              `json:"title"`
+	MetaDescription string              `json:"meta_description"`
+	Headers         map[string][]string `json:"headers,omitempty"`
+	Cookies         []CookieEntry       `json:"cookies,omitempty"`
+}
+
+// CookieEntry leaves out the cookie's value, which may be a session token.
+type CookieEntry struct {
+	Name     string     `json:"name"`
+	Domain   string     `json:"domain,omitempty"`
+	Path     string     `json:"path,omitempty"`
+	Expires  *time.Time `json:"expires,omitempty"`
+	Session  bool       `json:"session"`
+	Secure   bool       `json:"secure"`
+	HttpOnly bool       `json:"http_only"`
+	SameSite string     `json:"same_site,omitempty"`
+	Size     int        `json:"size"`
+	Issues   []string   `json:"issues"`
+}
+
+type TimingEntry struct {
+	DNSMS            float64 `json:"dns_ms"`
+	ConnectMS        float64 `json:"connect_ms"`
+	TLSMS            float64 `json:"tls_ms"`
+	TTFBMS           float64 `json:"ttfb_ms"`
+	TransferMS       float64 `json:"transfer_ms"`
+	TotalMS          float64 `json:"total_ms"`
+	ReusedConnection bool    `json:"reused_connection"`
+	BodyBytes        int64   `json:"body_bytes"`
+	Truncated        bool    `json:"truncated"`
+}
+
+type RedirectSection struct {
+	Hops             []RedirectHopEntry `json:"hops"`
+	FinalURL         string             `json:"final_url,omitempty"`
+	Redirects        int                `json:"redirects"`
+	Loop             bool               `json:"loop"`
+	TooManyRedirects bool               `json:"too_many_redirects"`
+	Downgrade        bool               `json:"downgrade"`
+	CrossDomain      bool               `json:"cross_domain"`
+	Error            string             `json:"error,omitempty"`
+}
+
+type RedirectHopEntry struct {
+	URL         string              `json:"url"`
+	StatusCode  int                 `json:"status_code"`
+	Location    string              `json:"location,omitempty"`
+	Timing      *TimingEntry        `json:"timing,omitempty"`
+	TLS         *RedirectTLSEntry   `json:"tls,omitempty"`
+	Downgrade   bool                `json:"downgrade"`
+	CrossDomain bool                `json:"cross_domain"`
+	Headers     map[string][]string `json:"headers,omitempty"`
+}
+
+type RedirectTLSEntry struct {
+	Version     string           `json:"version"`
+	CipherSuite string           `json:"cipher_suite"`
+	Certificate CertificateEntry `json:"certificate"`
+}
+
+type SecuritySection struct {
+	Score   int                   `json:"score"`
+	Grade   string                `json:"grade"`
+	Headers []SecurityHeaderEntry `json:"headers"`
+	HSTS    *HSTSEntry            `json:"hsts,omitempty"`
+	CSP     *CSPEntry             `json:"csp,omitempty"`
+}
+
+type SecurityHeaderEntry struct {
+	Name     string   `json:"name"`
+	Value    string   `json:"value,omitempty"`
+	Present  bool     `json:"present"`
+	Score    int      `json:"score"`
+	MaxScore int      `json:"max_score"`
+	Note     string   `json:"note,omitempty"`
+	Errors   []string `json:"errors"`
+	Warnings []string `json:"warnings"`
+}
+
+type HSTSEntry struct {
+	MaxAge            int64 `json:"max_age"`
+	IncludeSubDomains bool  `json:"include_subdomains"`
+	Preload           bool  `json:"preload"`
+	PreloadEligible   bool  `json:"preload_eligible"`
+}
+
+type CSPEntry struct {
+	Directives map[string][]string `json:"directives"`
+	ReportOnly bool                `json:"report_only"`
+}
+
+type AddressEntry struct {
+	IP         string           `json:"ip"`
+	Owner      *OwnerEntry      `json:"owner,omitempty"`
+	Geo        *GeoEntry        `json:"geo,omitempty"`
+	ReverseDNS *ReverseDNSEntry `json:"reverse_dns,omitempty"`
+}
+
+type OwnerEntry struct {
+	ASN        uint32   `json:"asn,omitempty"`
+	ASName     string   `json:"as_name,omitempty"`
+	Prefix     string   `json:"prefix,omitempty"`
+	Network    string   `json:"network,omitempty"`
+	RIR        string   `json:"rir,omitempty"`
+	Country    string   `json:"country,omitempty"`
+	AbuseEmail string   `json:"abuse_email,omitempty"`
+	Sources    []string `json:"sources"`
+}
+
+type GeoEntry struct {
+	CountryCode      string   `json:"country_code,omitempty"`
+	Country          string   `json:"country,omitempty"`
+	Region           string   `json:"region,omitempty"`
+	City             string   `json:"city,omitempty"`
+	Latitude         *float64 `json:"latitude,omitempty"`
+	Longitude        *float64 `json:"longitude,omitempty"`
+	AccuracyRadiusKM int      `json:"accuracy_radius_km,omitempty"`
+	TimeZone         string   `json:"time_zone,omitempty"`
+	ASN              uint32   `json:"asn,omitempty"`
+	ASOrganization   string   `json:"as_organization,omitempty"`
+	Network          string   `json:"network,omitempty"`
+}
+
+type ReverseDNSEntry struct {
+	Name             string   `json:"name,omitempty"`
+	Names            []string `json:"names"`
+	ForwardConfirmed bool     `json:"forward_confirmed"`
+	Error            string   `json:"error,omitempty"`
+}
+
+type WarningEntry struct {
+	Probe string `json:"probe"`
+	Error string `json:"error"`
+}
+
+type SSLSection struct {
+	CommonName string    `json:"common_name"`
+	Issuer     string    `json:"issuer"`
+	Issued     time.Time `json:"issued"`
+	Expiry     time.Time `json:"expiry"`
+	DNSNames   []string  `json:"dns_names"`
+	Valid      bool      `json:"valid"`
+
+	Chain        []CertificateEntry `json:"chain,omitempty"`
+	Verification *VerificationEntry `json:"verification,omitempty"`
+	Port         int                `json:"port,omitempty"`
+	StartTLS     string             `json:"starttls,omitempty"`
+	Revocation   *RevocationEntry   `json:"revocation,omitempty"`
+	Error        string             `json:"error,omitempty"`
+}
+
+type RevocationEntry struct {
+	Stapled bool                   `json:"stapled"`
+	Status  string                 `json:"status,omitempty"`
+	Checks  []RevocationCheckEntry `json:"checks"`
+}
+
+type RevocationCheckEntry struct {
+	Source     string     `json:"source"`
+	URL        string     `json:"url,omitempty"`
+	Status     string     `json:"status"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	ThisUpdate *time.Time `json:"this_update,omitempty"`
+	NextUpdate *time.Time `json:"next_update,omitempty"`
+	Error      string     `json:"error,omitempty"`
+}
+
+type CertificateEntry struct {
+	Subject            string    `json:"subject"`
+	Issuer             string    `json:"issuer"`
+	SerialNumber       string    `json:"serial_number"`
+	SignatureAlgorithm string    `json:"signature_algorithm"`
+	KeyType            string    `json:"key_type"`
+	KeySize            int       `json:"key_size"`
+	NotBefore          time.Time `json:"not_before"`
+	NotAfter           time.Time `json:"not_after"`
+	IsCA               bool      `json:"is_ca"`
+	SHA1Fingerprint    string    `json:"sha1_fingerprint"`
+	SHA256Fingerprint  string    `json:"sha256_fingerprint"`
+}
+
+type VerificationEntry struct {
+	Verified      bool   `json:"verified"`
+	Reason        string `json:"reason,omitempty"`
+	HostnameMatch bool   `json:"hostname_match"`
+	Detail        string `json:"detail,omitempty"`
+}
+
+type TLSAuditSection struct {
+	Host       string            `json:"host"`
+	Port       int               `json:"port"`
+	Versions   []TLSVersionEntry `json:"versions"`
+	ALPN       []string          `json:"alpn"`
+	Weaknesses []string          `json:"weaknesses"`
+	Error      string            `json:"error,omitempty"`
+}
+
+type TLSVersionEntry struct {
+	Version          string        `json:"version"`
+	Supported        bool          `json:"supported"`
+	Ciphers          []CipherEntry `json:"ciphers"`
+	ServerPreference bool          `json:"server_preference"`
+	Curves           []string      `json:"curves"`
+}
+
+type CipherEntry struct {
+	Name           string `json:"name"`
+	ForwardSecrecy bool   `json:"forward_secrecy"`
+	Weak           bool   `json:"weak"`
+}
+
+type WhoisSection struct {
+	Domain       string       `json:"domain,omitempty"`
+	Source       string       `json:"source,omitempty"`
+	Registrar    string       `json:"registrar"`
+	CreatedDate  string       `json:"created_date"`
+	UpdatedDate  string       `json:"updated_date"`
+	ExpiresDate  string       `json:"expires_date"`
+	NameServers  []string     `json:"name_servers"`
+	DomainStatus []string     `json:"domain_status"`
+	RDAP         *RDAPSection `json:"rdap,omitempty"`
+}
+
+type RDAPSection struct {
+	URL             string            `json:"url"`
+	Handle          string            `json:"handle,omitempty"`
+	Name            string            `json:"name"`
+	UnicodeName     string            `json:"unicode_name,omitempty"`
+	Registrar       string            `json:"registrar,omitempty"`
+	RegistrarIANAID string            `json:"registrar_iana_id,omitempty"`
+	Status          []string          `json:"status"`
+	Events          []RDAPEventEntry  `json:"events"`
+	Nameservers     []string          `json:"nameservers"`
+	Entities        []RDAPEntityEntry `json:"entities"`
+	DNSSEC          *RDAPDNSSECEntry  `json:"dnssec,omitempty"`
+}
+
+type RDAPEventEntry struct {
+	Action string    `json:"action"`
+	Actor  string    `json:"actor,omitempty"`
+	Date   time.Time `json:"date"`
+}
+
+type RDAPEntityEntry struct {
+	Handle       string            `json:"handle,omitempty"`
+	Roles        []string          `json:"roles"`
+	Name         string            `json:"name,omitempty"`
+	Organization string            `json:"organization,omitempty"`
+	Email        string            `json:"email,omitempty"`
+	Phone        string            `json:"phone,omitempty"`
+	Entities     []RDAPEntityEntry `json:"entities,omitempty"`
+}
+
+type RDAPDNSSECEntry struct {
+	DelegationSigned bool          `json:"delegation_signed"`
+	DS               []RDAPDSEntry `json:"ds"`
+}
+
+type RDAPDSEntry struct {
+	KeyTag     int    `json:"key_tag"`
+	Algorithm  int    `json:"algorithm"`
+	DigestType int    `json:"digest_type"`
+	Digest     string `json:"digest"`
+}
+
+type DNSSection struct {
+	Records map[string][]string `json:"records"`
+	Queries []DNSQueryEntry     `json:"queries,omitempty"`
+	Error   string              `json:"error,omitempty"`
+}
+
+type DNSQueryEntry struct {
+	Name              string           `json:"name"`
+	Type              string           `json:"type"`
+	Server            string           `json:"server"`
+	Protocol          string           `json:"protocol"`
+	RCode             string           `json:"rcode"`
+	RTTMS             float64          `json:"rtt_ms"`
+	Authoritative     bool             `json:"authoritative"`
+	AuthenticatedData bool             `json:"authenticated_data"`
+	Answer            []DNSRecordEntry `json:"answer"`
+	Authority         []DNSRecordEntry `json:"authority,omitempty"`
+}
+
+type DNSRecordEntry struct {
+	Name  string `json:"name"`
+	Type  string `json:"type"`
+	TTL   uint32 `json:"ttl"`
+	Value string `json:"value"`
+}
+
+type DNSSECSection struct {
+	Domain         string            `json:"domain,omitempty"`
+	Status         DNSSECStatus      `json:"status,omitempty"`
+	Signed         bool              `json:"signed"`
+	Algorithms     []string          `json:"algorithms"`
+	EarliestExpiry *time.Time        `json:"earliest_expiry,omitempty"`
+	Zones          []DNSSECZoneEntry `json:"zones"`
+	Problems       []string          `json:"problems"`
+	Warnings       []string          `json:"warnings"`
+	Error          string            `json:"error,omitempty"`
+}
+
+type DNSSECZoneEntry struct {
+	Name       string                 `json:"name"`
+	Status     DNSSECStatus           `json:"status"`
+	DS         []DNSSECDSEntry        `json:"ds"`
+	Keys       []DNSSECKeyEntry       `json:"keys"`
+	Signatures []DNSSECSignatureEntry `json:"signatures"`
+}
+
+type DNSSECDSEntry struct {
+	KeyTag     uint16 `json:"key_tag"`
+	Algorithm  string `json:"algorithm"`
+	DigestType uint8  `json:"digest_type"`
+	Matched    bool   `json:"matched"`
+}
+
+type DNSSECKeyEntry struct {
+	KeyTag           uint16 `json:"key_tag"`
+	Algorithm        string `json:"algorithm"`
+	Flags            uint16 `json:"flags"`
+	SecureEntryPoint bool   `json:"secure_entry_point"`
+}
+
+type DNSSECSignatureEntry struct {
+	Owner      string    `json:"owner"`
+	Covers     string    `json:"covers"`
+	Signer     string    `json:"signer"`
+	KeyTag     uint16    `json:"key_tag"`
+	Algorithm  string    `json:"algorithm"`
+	Inception  time.Time `json:"inception"`
+	Expiration time.Time `json:"expiration"`
+	Valid      bool      `json:"valid"`
+	Error      string    `json:"error,omitempty"`
+}
+
+type PropagationSection struct {
+	Domain     string                   `json:"domain,omitempty"`
+	Consistent bool                     `json:"consistent"`
+	Records    []PropagationRecordEntry `json:"records"`
+	Error      string                   `json:"error,omitempty"`
+}
+
+type PropagationRecordEntry struct {
+	Type       string                   `json:"type"`
+	Consistent bool                     `json:"consistent"`
+	Consensus  []string                 `json:"consensus"`
+	MinTTL     uint32                   `json:"min_ttl"`
+	MaxTTL     uint32                   `json:"max_ttl"`
+	Answers    []PropagationAnswerEntry `json:"answers"`
+}
+
+type PropagationAnswerEntry struct {
+	Resolver string   `json:"resolver"`
+	Address  string   `json:"address"`
+	Values   []string `json:"values"`
+	TTL      uint32   `json:"ttl"`
+	RCode    string   `json:"rcode,omitempty"`
+	RTTMS    float64  `json:"rtt_ms"`
+	Matches  bool     `json:"matches"`
+	Added    []string `json:"added,omitempty"`
+	Missing  []string `json:"missing,omitempty"`
+	Error    string   `json:"error,omitempty"`
+}
+
+type SubdomainSection struct {
+	Domain        string              `json:"domain,omitempty"`
+	Subdomains    []SubdomainEntry    `json:"subdomains"`
+	Wildcard      []string            `json:"wildcard"`
+	ZoneTransfers []ZoneTransferEntry `json:"zone_transfers"`
+	Error         string              `json:"error,omitempty"`
+}
+
+type SubdomainEntry struct {
+	Name      string   `json:"name"`
+	Addresses []string `json:"addresses"`
+	CNAME     string   `json:"cname,omitempty"`
+	Sources   []string `json:"sources"`
+}
+
+type ZoneTransferEntry struct {
+	Server  string `json:"server,omitempty"`
+	Allowed bool   `json:"allowed"`
+	Records int    `json:"records"`
+	Error   string `json:"error,omitempty"`
+}
+
+type EmailSection struct {
+	Domain   string       `json:"domain,omitempty"`
+	Score    int          `json:"score"`
+	Grade    string       `json:"grade,omitempty"`
+	SPF      *SPFEntry    `json:"spf,omitempty"`
+	DMARC    *DMARCEntry  `json:"dmarc,omitempty"`
+	DKIM     []DKIMEntry  `json:"dkim"`
+	MTASTS   *MTASTSEntry `json:"mta_sts,omitempty"`
+	TLSRPT   *TLSRPTEntry `json:"tls_rpt,omitempty"`
+	BIMI     *BIMIEntry   `json:"bimi,omitempty"`
+	Warnings []string     `json:"warnings"`
+	Error    string       `json:"error,omitempty"`
+}
+
+type SPFEntry struct {
+	Record      string   `json:"record"`
+	All         string   `json:"all,omitempty"`
+	Lookups     int      `json:"lookups"`
+	VoidLookups int      `json:"void_lookups"`
+	Includes    []string `json:"includes"`
+	Errors      []string `json:"errors"`
+	Warnings    []string `json:"warnings"`
+}
+
+type DMARCEntry struct {
+	Record          string   `json:"record"`
+	Domain          string   `json:"domain"`
+	Policy          string   `json:"policy,omitempty"`
+	SubdomainPolicy string   `json:"subdomain_policy,omitempty"`
+	Percent         int      `json:"percent"`
+	AlignDKIM       string   `json:"align_dkim,omitempty"`
+	AlignSPF        string   `json:"align_spf,omitempty"`
+	ReportURIs      []string `json:"rua"`
+	ForensicURIs    []string `json:"ruf"`
+	Errors          []string `json:"errors"`
+	Warnings        []string `json:"warnings"`
+}
+
+type DKIMEntry struct {
+	Selector string   `json:"selector"`
+	KeyType  string   `json:"key_type"`
+	KeyBits  int      `json:"key_bits"`
+	Testing  bool     `json:"testing"`
+	Revoked  bool     `json:"revoked"`
+	Errors   []string `json:"errors"`
+	Warnings []string `json:"warnings"`
+}
+
+type MTASTSEntry struct {
+	Record    string   `json:"record"`
+	ID        string   `json:"id,omitempty"`
+	PolicyURL string   `json:"policy_url,omitempty"`
+	Mode      string   `json:"mode,omitempty"`
+	MX        []string `json:"mx"`
+	MaxAge    int      `json:"max_age"`
+	Errors    []string `json:"errors"`
+	Warnings  []string `json:"warnings"`
+}
+
+type TLSRPTEntry struct {
+	Record     string   `json:"record"`
+	ReportURIs []string `json:"rua"`
+	Errors     []string `json:"errors"`
+	Warnings   []string `json:"warnings"`
+}
+
+type BIMIEntry struct {
+	Record    string   `json:"record"`
+	Logo      string   `json:"logo,omitempty"`
+	Authority string   `json:"authority,omitempty"`
+	Errors    []string `json:"errors"`
+	Warnings  []string `json:"warnings"`
+}
+
+type PortSection struct {
+	IPv6 bool `json:"ipv6"`
+	// ReverseDNS is the PTR lookup of the scanned address, with --rdns.
+	ReverseDNS *ReverseDNSEntry `json:"reverse_dns,omitempty"`
+	Results    []PortEntry      `json:"results"`
+	Error      string           `json:"error,omitempty"`
+}
+
+type PortEntry struct {
+	Port      int           `json:"port"`
+	Open      bool          `json:"open"`
+	State     PortState     `json:"state"`
+	LatencyMS float64       `json:"latency_ms"`
+	Error     string        `json:"error,omitempty"`
+	Service   *ServiceEntry `json:"service,omitempty"`
+}
+
+type ServiceEntry struct {
+	Protocol   string `json:"protocol"`
+	Version    string `json:"version,omitempty"`
+	Banner     string `json:"banner,omitempty"`
+	TLS        bool   `json:"tls"`
+	Unexpected bool   `json:"unexpected"`
+}
+
+// TracerouteSection holds either a real trace or, when Estimated is set, the
+// TCP-connect approximation from EstimatePathTCP. Error and ErrorReason
+// describe why the real trace failed.
+type TracerouteSection struct {
+	IPv6        bool       `json:"ipv6"`
+	Estimated   bool       `json:"estimated,omitempty"`
+	Hops        []HopEntry `json:"hops"`
+	Error       string     `json:"error,omitempty"`
+	ErrorReason string     `json:"error_reason,omitempty"`
+}
+
+type HopEntry struct {
+	Number     int              `json:"number"`
+	IP         string           `json:"ip"`
+	Host       string           `json:"host,omitempty"`
+	RTTMS      float64          `json:"rtt_ms"`
+	Sent       int              `json:"sent"`
+	Received   int              `json:"received"`
+	Loss       float64          `json:"loss"`
+	MinRTTMS   float64          `json:"min_rtt_ms"`
+	AvgRTTMS   float64          `json:"avg_rtt_ms"`
+	MaxRTTMS   float64          `json:"max_rtt_ms"`
+	Owner      *OwnerEntry      `json:"owner,omitempty"`
+	Geo        *GeoEntry        `json:"geo,omitempty"`
+	ReverseDNS *ReverseDNSEntry `json:"reverse_dns,omitempty"`
+}
+
+type DualStackSection struct {
+	IPv4Addresses []string                    `json:"ipv4_addresses"`
+	IPv6Addresses []string                    `json:"ipv6_addresses"`
+	Locations     map[string]*GeoEntry        `json:"locations,omitempty"`
+	ReverseDNS    map[string]*ReverseDNSEntry `json:"reverse_dns,omitempty"`
+	DualStack     bool                        `json:"dual_stack"`
+	Error         string                      `json:"error,omitempty"`
+}
+
+// NewReport starts a report from the result of GetWebsiteInfo. A non-nil err
+// is recorded at the top level; whatever partial info is available is kept.
+func NewReport(target string, info *WebsiteInfo, err error) *Report {
+	report := &Report{
+		SchemaVersion: ReportSchemaVersion,
+		Target:        target,
+		GeneratedAt:   time.Now().UTC(),
+		Error:         errorString(err),
+	}
+
+	if info == nil {
+		return report
+	}
+
+	for _, warning := range info.Warnings {
+		entry := WarningEntry{Error: warning.Error()}
+		var probeErr *ProbeError
+		if errors.As(warning, &probeErr) {
+			entry.Probe = probeErr.Probe
+			entry.Error = errorString(probeErr.Err)
+		}
+		report.Warnings = append(report.Warnings, entry)
+	}
+
+	report.Website = &WebsiteSection{
+		URL:             info.URL,
+		IP:              nonNil(info.IP),
+		StatusCode:      info.StatusCode,
+		Server:          info.ServerInfo,
+		ContentType:     info.ContentType,
+		ResponseTimeMS:  durationMS(info.ResponseTime),
+		Timing:          newTimingEntry(info.Timing),
+		Title:           info.Title,
+		MetaDescription: info.MetaDescription,
+		Headers:         headerMap(info.Headers),
+	}
+	for _, cookie := range info.Cookies {
+		entry := CookieEntry{
+			Name:     cookie.Name,
+			Domain:   cookie.Domain,
+			Path:     cookie.Path,
+			Expires:  optionalTime(cookie.Expires),
+			Session:  cookie.Session,
+			Secure:   cookie.Secure,
+			HttpOnly: cookie.HttpOnly,
+			SameSite: cookie.SameSite,
+			Size:     cookie.Size,
+			Issues:   []string{},
+		}
+		for _, issue := range cookie.Issues {
+			entry.Issues = append(entry.Issues, string(issue))
+		}
+		report.Website.Cookies = append(report.Website.Cookies, entry)
+	}
+	for _, address := range info.Addresses {
+		report.Website.Addresses = append(report.Website.Addresses, AddressEntry{
+			IP:         address.IP,
+			Owner:      newOwnerEntry(address.Owner),
+			Geo:        newGeoEntry(address.Geo),
+			ReverseDNS: newReverseDNSEntry(address.ReverseDNS),
+		})
+	}
+
+	if info.Redirects != nil {
+		report.SetRedirects(info.Redirects, nil)
+	}
+
+	if info.SSLInfo != nil {
+		report.SSL = newSSLSection(info.SSLInfo)
+	}
+
+	if info.WhoisInfo != nil {
+		report.Whois = &WhoisSection{
+			Domain:       info.WhoisInfo.Domain,
+			Source:       info.WhoisInfo.Source,
+			Registrar:    info.WhoisInfo.Registrar,
+			CreatedDate:  info.WhoisInfo.CreatedDate,
+			UpdatedDate:  info.WhoisInfo.UpdatedDate,
+			ExpiresDate:  info.WhoisInfo.ExpiresDate,
+			NameServers:  nonNil(info.WhoisInfo.NameServers),
+			DomainStatus: nonNil(info.WhoisInfo.DomainStatus),
+			RDAP:         newRDAPSection(info.WhoisInfo.RDAP),
+		}
+	}
+
+	return report
+}
+
+func (r *Report) SetDNS(records map[string][]string, err error) {
+	if records == nil {
+		records = map[string][]string{}
+	}
+	r.DNS = &DNSSection{Records: records, Error: errorString(err)}
+}
+
+// SetDNSQueries records the answers of LookupDNS. Records is filled with the
+// values of the answers keyed by record type, and Queries with the full
+// responses.
+func (r *Report) SetDNSQueries(responses []*DNSResponse, err error) {
+	section := &DNSSection{Records: map[string][]string{}, Error: errorString(err)}
+	for _, response := range responses {
+		if values := response.Values(response.Type); len(values) > 0 {
+			section.Records[response.Type] = values
+		}
+		entry := DNSQueryEntry{
+			Name:              response.Name,
+			Type:              response.Type,
+			Server:            response.Server,
+			Protocol:          response.Protocol,
+			RCode:             response.RCode,
+			RTTMS:             durationMS(response.RTT),
+			Authoritative:     response.Authoritative,
+			AuthenticatedData: response.AuthenticatedData,
+			Answer:            []DNSRecordEntry{},
+			Authority:         newDNSRecordEntries(response.Authority),
+		}
+		entry.Answer = append(entry.Answer, newDNSRecordEntries(response.Answer)...)
+		section.Queries = append(section.Queries, entry)
+	}
+	r.DNS = section
+}
+
+func newDNSRecordEntries(records []DNSRecord) []DNSRecordEntry {
+	var entries []DNSRecordEntry
+	for _, record := range records {
+		entries = append(entries, DNSRecordEntry{Name: record.Name, Type: record.Type, TTL: record.TTL, Value: record.Value})
+	}
+	return entries
+}
+
+func (r *Report) SetDNSSEC(dnssec *DNSSECReport, err error) {
+	section := &DNSSECSection{
+		Algorithms: []string{},
+		Zones:      []DNSSECZoneEntry{},
+		Problems:   []string{},
+		Warnings:   []string{},
+		Error:      errorString(err),
+	}
+	if dnssec != nil {
+		section.Domain = dnssec.Domain
+		section.Status = dnssec.Status
+		section.Signed = dnssec.Signed
+		section.Algorithms = nonNil(dnssec.Algorithms)
+		section.EarliestExpiry = optionalTime(dnssec.EarliestExpiry)
+		section.Problems = nonNil(dnssec.Problems)
+		section.Warnings = nonNil(dnssec.Warnings)
+		for _, zone := range dnssec.Zones {
+			entry := DNSSECZoneEntry{
+				Name:       zone.Name,
+				Status:     zone.Status,
+				DS:         []DNSSECDSEntry{},
+				Keys:       []DNSSECKeyEntry{},
+				Signatures: []DNSSECSignatureEntry{},
+			}
+			for _, ds := range zone.DS {
+				entry.DS = append(entry.DS, DNSSECDSEntry{
+					KeyTag:     ds.KeyTag,
+					Algorithm:  ds.Algorithm,
+					DigestType: ds.DigestType,
+					Matched:    ds.Matched,
+				})
+			}
+			for _, key := range zone.Keys {
+				entry.Keys = append(entry.Keys, DNSSECKeyEntry{
+					KeyTag:           key.KeyTag,
+					Algorithm:        key.Algorithm,
+					Flags:            key.Flags,
+					SecureEntryPoint: key.SecureEntryPoint,
+				})
+			}
+			for _, sig := range zone.Signatures {
+				entry.Signatures = append(entry.Signatures, DNSSECSignatureEntry{
+					Owner:      sig.Owner,
+					Covers:     sig.Covers,
+					Signer:     sig.Signer,
+					KeyTag:     sig.KeyTag,
+					Algorithm:  sig.Algorithm,
+					Inception:  sig.Inception,
+					Expiration: sig.Expiration,
+					Valid:      sig.Valid,
+					Error:      sig.Error,
+				})
+			}
+			section.Zones = append(section.Zones, entry)
+		}
+	}
+	r.DNSSEC = section
+}
+
+func (r *Report) SetPropagation(propagation *PropagationReport, err error) {
+	section := &PropagationSection{Records: []PropagationRecordEntry{}, Error: errorString(err)}
+	if propagation != nil {
+		section.Domain = propagation.Domain
+		section.Consistent = propagation.Consistent
+		for _, record := range propagation.Records {
+			entry := PropagationRecordEntry{
+				Type:       record.Type,
+				Consistent: record.Consistent,
+				Consensus:  nonNil(record.Consensus),
+				MinTTL:     record.MinTTL,
+				MaxTTL:     record.MaxTTL,
+				Answers:    []PropagationAnswerEntry{},
+			}
+			for _, answer := range record.Answers {
+				entry.Answers = append(entry.Answers, PropagationAnswerEntry{
+					Resolver: answer.Resolver.Name,
+					Address:  answer.Resolver.Address,
+					Values:   nonNil(answer.Values),
+					TTL:      answer.TTL,
+					RCode:    answer.RCode,
+					RTTMS:    durationMS(answer.RTT),
+					Matches:  answer.Matches(),
+					Added:    answer.Added,
+					Missing:  answer.Missing,
+					Error:    answer.Error,
+				})
+			}
+			section.Records = append(section.Records, entry)
+		}
+	}
+	r.Propagation = section
+}
+
+func (r *Report) SetSubdomains(subdomains *SubdomainReport, err error) {
+	section := &SubdomainSection{
+		Subdomains:    []SubdomainEntry{},
+		Wildcard:      []string{},
+		ZoneTransfers: []ZoneTransferEntry{},
+		Error:         errorString(err),
+	}
+	if subdomains != nil {
+		section.Domain = subdomains.Domain
+		section.Wildcard = nonNil(subdomains.Wildcard)
+		for _, subdomain := range subdomains.Subdomains {
+			section.Subdomains = append(section.Subdomains, SubdomainEntry{
+				Name:      subdomain.Name,
+				Addresses: nonNil(subdomain.Addresses),
+				CNAME:     subdomain.CNAME,
+				Sources:   nonNil(subdomain.Sources),
+			})
+		}
+		for _, transfer := range subdomains.ZoneTransfers {
+			section.ZoneTransfers = append(section.ZoneTransfers, ZoneTransferEntry{
+				Server:  transfer.Server,
+				Allowed: transfer.Allowed,
+				Records: transfer.Records,
+				Error:   transfer.Error,
+			})
+		}
+	}
+	r.Subdomains = section
+}
+
+func (r *Report) SetEmailSecurity(email *EmailSecurityReport, err error) {
+	section := &EmailSection{DKIM: []DKIMEntry{}, Warnings: []string{}, Error: errorString(err)}
+	if email != nil {
+		section.Domain = email.Domain
+		section.Score = email.Score
+		section.Grade = email.Grade
+		section.Warnings = nonNil(email.Warnings)
+		if spf := email.SPF; spf != nil {
+			section.SPF = &SPFEntry{
+				Record:      spf.Record,
+				All:         spf.All,
+				Lookups:     spf.Lookups,
+				VoidLookups: spf.VoidLookups,
+				Includes:    nonNil(spf.Includes),
+				Errors:      nonNil(spf.Errors),
+				Warnings:    nonNil(spf.Warnings),
+			}
+		}
+		if dmarc := email.DMARC; dmarc != nil {
+			section.DMARC = &DMARCEntry{
+				Record:          dmarc.Record,
+				Domain:          dmarc.Domain,
+				Policy:          dmarc.Policy,
+				SubdomainPolicy: dmarc.SubdomainPolicy,
+				Percent:         dmarc.Percent,
+				AlignDKIM:       dmarc.AlignDKIM,
+				AlignSPF:        dmarc.AlignSPF,
+				ReportURIs:      nonNil(dmarc.ReportURIs),
+				ForensicURIs:    nonNil(dmarc.ForensicURIs),
+				Errors:          nonNil(dmarc.Errors),
+				Warnings:        nonNil(dmarc.Warnings),
+			}
+		}
+		for _, dkim := range email.DKIM {
+			section.DKIM = append(section.DKIM, DKIMEntry{
+				Selector: dkim.Selector,
+				KeyType:  dkim.KeyType,
+				KeyBits:  dkim.KeyBits,
+				Testing:  dkim.Testing,
+				Revoked:  dkim.Revoked,
+				Errors:   nonNil(dkim.Errors),
+				Warnings: nonNil(dkim.Warnings),
+			})
+		}
+		if mtaSTS := email.MTASTS; mtaSTS != nil {
+			section.MTASTS = &MTASTSEntry{
+				Record:    mtaSTS.Record,
+				ID:        mtaSTS.ID,
+				PolicyURL: mtaSTS.PolicyURL,
+				MX:        []string{},
+				Errors:    nonNil(mtaSTS.Errors),
+				Warnings:  nonNil(mtaSTS.Warnings),
+			}
+			if policy := mtaSTS.Policy; policy != nil {
+				section.MTASTS.Mode = policy.Mode
+				section.MTASTS.MX = nonNil(policy.MX)
+				section.MTASTS.MaxAge = policy.MaxAge
+			}
+		}
+		if tlsRPT := email.TLSRPT; tlsRPT != nil {
+			section.TLSRPT = &TLSRPTEntry{
+				Record:     tlsRPT.Record,
+				ReportURIs: nonNil(tlsRPT.ReportURIs),
+				Errors:     nonNil(tlsRPT.Errors),
+				Warnings:   nonNil(tlsRPT.Warnings),
+			}
+		}
+		if bimi := email.BIMI; bimi != nil {
+			section.BIMI = &BIMIEntry{
+				Record:    bimi.Record,
+				Logo:      bimi.Logo,
+				Authority: bimi.Authority,
+				Errors:    nonNil(bimi.Errors),
+				Warnings:  nonNil(bimi.Warnings),
+			}
+		}
+	}
+	r.EmailSecurity = section
+}
+
+func (r *Report) SetPortScan(results map[int]bool, ipv6 bool, err error) {
+	section := &PortSection{IPv6: ipv6, Results: []PortEntry{}, Error: errorString(err)}
+	for port, open := range results {
+		state := PortClosed
+		if open {
+			state = PortOpen
+		}
+		section.Results = append(section.Results, PortEntry{Port: port, Open: open, State: state})
+	}
+	sort.Slice(section.Results, func(i, j int) bool {
+		return section.Results[i].Port < section.Results[j].Port
+	})
+	r.Ports = section
+}
+
+// SetPortResults records a port scan. target is the reverse DNS of the
+// scanned address, or nil when it wasn't looked up.
+func (r *Report) SetPortResults(results []PortResult, target *ReverseDNS, ipv6 bool, err error) {
+	section := &PortSection{IPv6: ipv6, ReverseDNS: newReverseDNSEntry(target), Results: []PortEntry{}, Error: errorString(err)}
+	for _, result := range results {
+		entry := PortEntry{
+			Port:      result.Port,
+			Open:      result.State == PortOpen,
+			State:     result.State,
+			LatencyMS: durationMS(result.Latency),
+		}
+		if result.State == PortFiltered {
+			entry.Error = errorString(result.Err)
+		}
+		if result.Service != nil {
+			entry.Service = &ServiceEntry{
+				Protocol:   result.Service.Protocol,
+				Version:    result.Service.Version,
+				Banner:     result.Service.Banner,
+				TLS:        result.Service.TLS,
+				Unexpected: result.Service.Unexpected(result.Port),
+			}
+		}
+		section.Results = append(section.Results, entry)
+	}
+	r.Ports = section
+}
+
+func (r *Report) SetTraceroute(hops []TracerouteHop, ipv6 bool, err error) {
+	section := &TracerouteSection{IPv6: ipv6, Hops: []HopEntry{}, Error: errorString(err)}
+	var traceErr *TracerouteError
+	if errors.As(err, &traceErr) {
+		section.ErrorReason = string(traceErr.Reason)
+	}
+	for _, hop := range hops {
+		section.Estimated = section.Estimated || hop.Estimated
+		section.Hops = append(section.Hops, HopEntry{
+			Number:     hop.Number,
+			IP:         hop.IP,
+			Host:       hop.Host,
+			RTTMS:      durationMS(hop.RTT),
+			Sent:       hop.Sent,
+			Received:   hop.Received,
+			Loss:       hop.Loss,
+			MinRTTMS:   durationMS(hop.MinRTT),
+			AvgRTTMS:   durationMS(hop.AvgRTT),
+			MaxRTTMS:   durationMS(hop.MaxRTT),
+			Owner:      newOwnerEntry(hop.Owner),
+			Geo:        newGeoEntry(hop.Geo),
+			ReverseDNS: newReverseDNSEntry(hop.ReverseDNS),
+		})
+	}
+	r.Traceroute = section
+}
+
+// SetSSL records a certificate fetched on its own with GetTLSInfo, e.g. from
+// a mail server, rather than as part of GetWebsiteInfo.
+func (r *Report) SetSSL(sslInfo *SSLInfo, err error) {
+	section := &SSLSection{DNSNames: []string{}}
+	if sslInfo != nil {
+		section = newSSLSection(sslInfo)
+	}
+	section.Error = errorString(err)
+	r.SSL = section
+}
+
+func newSSLSection(sslInfo *SSLInfo) *SSLSection {
+	section := &SSLSection{
+		CommonName: sslInfo.CommonName,
+		Issuer:     sslInfo.Issuer,
+		Issued:     sslInfo.Issued,
+		Expiry:     sslInfo.Expiry,
+		DNSNames:   nonNil(sslInfo.DNSNames),
+		Valid:      sslInfo.Valid,
+		Port:       sslInfo.Port,
+		StartTLS:   string(sslInfo.StartTLS),
+	}
+	for _, cert := range sslInfo.Chain {
+		section.Chain = append(section.Chain, newCertificateEntry(cert))
+	}
+	if v := sslInfo.Verification; v != nil {
+		section.Verification = &VerificationEntry{Verified: v.Verified, Reason: string(v.Reason), HostnameMatch: v.HostnameMatch, Detail: v.Detail}
+	}
+	if rev := sslInfo.Revocation; rev != nil {
+		section.Revocation = &RevocationEntry{Stapled: rev.Stapled, Status: string(rev.Status), Checks: []RevocationCheckEntry{}}
+		for _, check := range rev.Checks {
+			section.Revocation.Checks = append(section.Revocation.Checks, RevocationCheckEntry{
+				Source:     check.Source,
+				URL:        check.URL,
+				Status:     string(check.Status),
+				RevokedAt:  optionalTime(check.RevokedAt),
+				ThisUpdate: optionalTime(check.ThisUpdate),
+				NextUpdate: optionalTime(check.NextUpdate),
+				Error:      errorString(check.Err),
+			})
+		}
+	}
+	return section
+}
+
+func newCertificateEntry(cert CertificateInfo) CertificateEntry {
+	return CertificateEntry{
+		Subject:            cert.Subject,
+		Issuer:             cert.Issuer,
+		SerialNumber:       cert.SerialNumber,
+		SignatureAlgorithm: cert.SignatureAlgorithm,
+		KeyType:            cert.KeyType,
+		KeySize:            cert.KeySize,
+		NotBefore:          cert.NotBefore,
+		NotAfter:           cert.NotAfter,
+		IsCA:               cert.IsCA,
+		SHA1Fingerprint:    cert.SHA1Fingerprint,
+		SHA256Fingerprint:  cert.SHA256Fingerprint,
+	}
+}
+
+// SetRedirects records a redirect chain. chain may hold the hops followed
+// before err.
+func (r *Report) SetRedirects(chain *RedirectChain, err error) {
+	section := &RedirectSection{
+		Hops:  []RedirectHopEntry{},
+		Error: errorString(err),
+	}
+	if chain != nil {
+		section.FinalURL = chain.FinalURL
+		section.Redirects = chain.Redirects()
+		section.Loop = chain.Loop
+		section.TooManyRedirects = chain.TooManyRedirects
+		section.Downgrade = chain.Downgrade()
+		section.CrossDomain = chain.CrossDomain()
+		for _, hop := range chain.Hops {
+			entry := RedirectHopEntry{
+				URL:         hop.URL,
+				StatusCode:  hop.StatusCode,
+				Location:    hop.Location,
+				Timing:      newTimingEntry(hop.Timing),
+				Downgrade:   hop.Downgrade,
+				CrossDomain: hop.CrossDomain,
+				Headers:     headerMap(hop.Headers),
+			}
+			if hop.TLS != nil {
+				entry.TLS = &RedirectTLSEntry{
+					Version:     hop.TLS.Version,
+					CipherSuite: hop.TLS.CipherSuite,
+					Certificate: newCertificateEntry(hop.TLS.Certificate),
+				}
+			}
+			section.Hops = append(section.Hops, entry)
+		}
+	}
+	r.Redirects = section
+}
+
+func (r *Report) SetSecurityHeaders(audit *SecurityHeaderReport) {
+	section := &SecuritySection{
+		Score:   audit.Score,
+		Grade:   audit.Grade,
+		Headers: []SecurityHeaderEntry{},
+	}
+	for _, result := range audit.Headers {
+		section.Headers = append(section.Headers, SecurityHeaderEntry{
+			Name:     result.Name,
+			Value:    result.Value,
+			Present:  result.Present,
+			Score:    result.Score,
+			MaxScore: result.MaxScore,
+			Note:     result.Note,
+			Errors:   nonNil(result.Errors),
+			Warnings: nonNil(result.Warnings),
+		})
+	}
+	if hsts := audit.HSTS; hsts != nil {
+		section.HSTS = &HSTSEntry{
+			MaxAge:            hsts.MaxAge,
+			IncludeSubDomains: hsts.IncludeSubDomains,
+			Preload:           hsts.Preload,
+			PreloadEligible:   hsts.PreloadEligible,
+		}
+	}
+	if csp := audit.CSP; csp != nil {
+		section.CSP = &CSPEntry{Directives: csp.Directives, ReportOnly: csp.ReportOnly}
+	}
+	r.Security = section
+}
+
+func newRDAPSection(rdap *RDAPDomain) *RDAPSection {
+	if rdap == nil {
+		return nil
+	}
+
+	section := &RDAPSection{
+		URL:             rdap.URL,
+		Handle:          rdap.Handle,
+		Name:            rdap.Name,
+		UnicodeName:     rdap.UnicodeName,
+		Registrar:       rdap.Registrar,
+		RegistrarIANAID: rdap.RegistrarIANAID,
+		Status:          nonNil(rdap.Status),
+		Events:          []RDAPEventEntry{},
+		Nameservers:     nonNil(rdap.Nameservers),
+		Entities:        newRDAPEntityEntries(rdap.Entities),
+	}
+	for _, event := range rdap.Events {
+		section.Events = append(section.Events, RDAPEventEntry{Action: event.Action, Actor: event.Actor, Date: event.Date})
+	}
+	if rdap.DNSSEC != nil {
+		section.DNSSEC = &RDAPDNSSECEntry{DelegationSigned: rdap.DNSSEC.DelegationSigned, DS: []RDAPDSEntry{}}
+		for _, ds := range rdap.DNSSEC.DS {
+			section.DNSSEC.DS = append(section.DNSSEC.DS, RDAPDSEntry{
+				KeyTag:     ds.KeyTag,
+				Algorithm:  ds.Algorithm,
+				DigestType: ds.DigestType,
+				Digest:     ds.Digest,
+			})
+		}
+	}
+	return section
+}
+
+func newRDAPEntityEntries(entities []RDAPEntity) []RDAPEntityEntry {
+	entries := []RDAPEntityEntry{}
+	for _, entity := range entities {
+		entry := RDAPEntityEntry{
+			Handle:       entity.Handle,
+			Roles:        nonNil(entity.Roles),
+			Name:         entity.Name,
+			Organization: entity.Organization,
+			Email:        entity.Email,
+			Phone:        entity.Phone,
+		}
+		if len(entity.Entities) > 0 {
+			entry.Entities = newRDAPEntityEntries(entity.Entities)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func (r *Report) SetTLSAudit(audit *TLSAudit, err error) {
+	section := &TLSAuditSection{
+		Versions:   []TLSVersionEntry{},
+		ALPN:       []string{},
+		Weaknesses: []string{},
+		Error:      errorString(err),
+	}
+	if audit != nil {
+		section.Host = audit.Host
+		section.Port = audit.Port
+		section.ALPN = nonNil(audit.ALPN)
+		for _, weakness := range audit.Weaknesses {
+			section.Weaknesses = append(section.Weaknesses, string(weakness))
+		}
+		for _, version := range audit.Versions {
+			entry := TLSVersionEntry{
+				Version:          version.Name,
+				Supported:        version.Supported,
+				Ciphers:          []CipherEntry{},
+				ServerPreference: version.ServerPreference,
+				Curves:           nonNil(version.Curves),
+			}
+			for _, cipher := range version.Ciphers {
+				entry.Ciphers = append(entry.Ciphers, CipherEntry{
+					Name:           cipher.Name,
+					ForwardSecrecy: cipher.ForwardSecrecy,
+					Weak:           cipher.Weak,
+				})
+			}
+			section.Versions = append(section.Versions, entry)
+		}
+	}
+	r.TLSAudit = section
+}
+
+func (r *Report) SetDualStack(ipInfo *IPAddressInfo, dualStack bool, err error) {
+	section := &DualStackSection{
+		IPv4Addresses: []string{},
+		IPv6Addresses: []string{},
+		DualStack:     dualStack,
+		Error:         errorString(err),
+	}
+	if ipInfo != nil {
+		section.IPv4Addresses = nonNil(ipInfo.IPv4Addresses)
+		section.IPv6Addresses = nonNil(ipInfo.IPv6Addresses)
+		for ip, location := range ipInfo.Locations {
+			if section.Locations == nil {
+				section.Locations = map[string]*GeoEntry{}
+			}
+			section.Locations[ip] = newGeoEntry(location)
+		}
+		for ip, result := range ipInfo.ReverseDNS {
+			if section.ReverseDNS == nil {
+				section.ReverseDNS = map[string]*ReverseDNSEntry{}
+			}
+			section.ReverseDNS[ip] = newReverseDNSEntry(result)
+		}
+	}
+	r.DualStack = section
+}
+
+func (r *Report) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
+
+func durationMS(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+func newTimingEntry(timing *HTTPTiming) *TimingEntry {
+	if timing == nil {
+		return nil
+	}
+	return &TimingEntry{
+		DNSMS:            durationMS(timing.DNSLookup),
+		ConnectMS:        durationMS(timing.TCPConnect),
+		TLSMS:            durationMS(timing.TLSHandshake),
+		TTFBMS:           durationMS(timing.TimeToFirstByte),
+		TransferMS:       durationMS(timing.ContentTransfer),
+		TotalMS:          durationMS(timing.Total),
+		ReusedConnection: timing.ReusedConnection,
+		BodyBytes:        timing.BodySize,
+		Truncated:        timing.Truncated,
+	}
+}
+
+func newOwnerEntry(owner *NetworkOwner) *OwnerEntry {
+	if owner == nil {
+		return nil
+	}
+	return &OwnerEntry{
+		ASN:        owner.ASN,
+		ASName:     owner.ASName,
+		Prefix:     owner.Prefix,
+		Network:    owner.Network,
+		RIR:        owner.RIR,
+		Country:    owner.Country,
+		AbuseEmail: owner.AbuseEmail,
+		Sources:    nonNil(owner.Sources),
+	}
+}
+
+func newGeoEntry(location *GeoLocation) *GeoEntry {
+	if location == nil {
+		return nil
+	}
+	entry := &GeoEntry{
+		CountryCode:    location.CountryCode,
+		Country:        location.Country,
+		Region:         location.Region,
+		City:           location.City,
+		TimeZone:       location.TimeZone,
+		ASN:            location.ASN,
+		ASOrganization: location.ASOrganization,
+		Network:        location.Network,
+	}
+	if location.Coordinates != nil {
+		entry.Latitude = &location.Coordinates.Latitude
+		entry.Longitude = &location.Coordinates.Longitude
+		entry.AccuracyRadiusKM = location.Coordinates.AccuracyRadius
+	}
+	return entry
+}
+
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
+
+func headerMap(headers http.Header) map[string][]string {
+	result := make(map[string][]string, len(headers))
+	for key, values := range headers {
+		result[key] = values
+	}
+	return result
+}
+
+func newReverseDNSEntry(result *ReverseDNS) *ReverseDNSEntry {
+	if result == nil {
+		return nil
+	}
+	return &ReverseDNSEntry{
+		Name:             result.Name,
+		Names:            nonNil(result.Names),
+		ForwardConfirmed: result.ForwardConfirmed,
+		Error:            result.Error,
+	}
+}
@@ -0,0 +1,389 @@
+package gowebspy
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite golden files in testdata")
+
+func assertGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+
+	path := filepath.Join("testdata", name)
+	if *updateGolden {
+		if err := os.MkdirAll("testdata", 0o755); err != nil {
+			t.Fatalf("Failed to create testdata: %v", err)
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatalf("Failed to update golden file: %v", err)
+		}
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read golden file (run with -update to create it): %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s does not match golden file\n--- got ---\n%s\n--- want ---\n%s", name, got, want)
+	}
+}
+
+func fixedTime(s string) time.Time {
+	t, _ := time.Parse(time.RFC3339, s)
+	return t
+}
+
+func TestReportGolden(t *testing.T) {
+	info := &WebsiteInfo{
+		URL: "https://example.com",
+		IP:  []string{"93.184.216.34", "2606:2800:220:1:248:1893:25c8:1946"},
+		Addresses: []AddressInfo{
+			{IP: "93.184.216.34", Owner: edgecastOwner("93.184.216.34", "93.184.216.0/24"), Geo: edgecastGeo("93.184.216.34", "93.184.216.0/24"),
+				ReverseDNS: &ReverseDNS{IP: "93.184.216.34", Names: []string{"example.com"}, ForwardConfirmed: true, Name: "example.com"}},
+			{IP: "2606:2800:220:1:248:1893:25c8:1946", Owner: edgecastOwner("2606:2800:220:1:248:1893:25c8:1946", "2606:2800:220::/48")},
+		},
+		StatusCode:   200,
+		ServerInfo:   "ECS (dcb/7F83)",
+		ContentType:  "text/html; charset=UTF-8",
+		ResponseTime: 123456789 * time.Nanosecond,
+		Timing: &HTTPTiming{DNSLookup: 12 * time.Millisecond, TCPConnect: 20 * time.Millisecond, TLSHandshake: 41 * time.Millisecond,
+			TimeToFirstByte: 50 * time.Millisecond, ContentTransfer: 8 * time.Millisecond, Total: 131 * time.Millisecond, BodySize: 1256},
+		Headers: http.Header{
+			"Content-Type": {"text/html; charset=UTF-8"},
+			"Server":       {"ECS (dcb/7F83)"},
+		},
+		Cookies: []CookieInfo{
+			{Name: "__Host-session", Path: "/", Session: true, Secure: true, HttpOnly: true, SameSite: "Lax", Size: 46},
+			{Name: "tracker", Domain: "example.com", Path: "/", Expires: fixedTime("2026-01-02T03:04:05Z"), SameSite: "None", Size: 12,
+				Issues: []CookieIssue{CookieSameSiteNoneInsecure, CookieNotSecure}},
+		},
+		Redirects: &RedirectChain{
+			Hops: []RedirectHop{
+				{URL: "http://example.com/", StatusCode: 301, Location: "https://example.com/",
+					Headers: http.Header{"Location": {"https://example.com/"}},
+					Timing:  &HTTPTiming{TCPConnect: 20 * time.Millisecond, TimeToFirstByte: 30 * time.Millisecond, Total: 52 * time.Millisecond}},
+				{URL: "https://example.com/", StatusCode: 200,
+					Timing: &HTTPTiming{TCPConnect: 20 * time.Millisecond, TLSHandshake: 41 * time.Millisecond, TimeToFirstByte: 50 * time.Millisecond,
+						ContentTransfer: 8 * time.Millisecond, Total: 120 * time.Millisecond, BodySize: 1256},
+					TLS: &RedirectTLS{Version: "TLS 1.3", CipherSuite: "TLS_AES_128_GCM_SHA256", Certificate: CertificateInfo{
+						Subject: "CN=www.example.org", Issuer: "CN=DigiCert Global G2 TLS RSA SHA256 2020 CA1,O=DigiCert Inc,C=US",
+						SerialNumber: "75bcef30689c8addf13e51af4afe187", SignatureAlgorithm: "SHA256-RSA", KeyType: "ECDSA", KeySize: 256,
+						NotBefore: fixedTime("2024-01-30T00:00:00Z"), NotAfter: fixedTime("2025-03-01T23:59:59Z"),
+					}}},
+			},
+			FinalURL: "https://example.com/",
+		},
+		SSLInfo: &SSLInfo{
+			Issued:     fixedTime("2024-01-30T00:00:00Z"),
+			Expiry:     fixedTime("2025-03-01T23:59:59Z"),
+			Issuer:     "DigiCert Global G2 TLS RSA SHA256 2020 CA1",
+			CommonName: "www.example.org",
+			DNSNames:   []string{"www.example.org", "example.com"},
+			Valid:      true,
+			Chain: []CertificateInfo{
+				{
+					Subject:            "CN=www.example.org,O=Internet Corporation for Assigned Names and Numbers,L=Los Angeles,ST=California,C=US",
+					Issuer:             "CN=DigiCert Global G2 TLS RSA SHA256 2020 CA1,O=DigiCert Inc,C=US",
+					CommonName:         "www.example.org",
+					SerialNumber:       "75bcef30689c8addf13e51af4afe187",
+					SignatureAlgorithm: "SHA256-RSA",
+					KeyType:            "ECDSA",
+					KeySize:            256,
+					NotBefore:          fixedTime("2024-01-30T00:00:00Z"),
+					NotAfter:           fixedTime("2025-03-01T23:59:59Z"),
+					SHA1Fingerprint:    "4da25a6d5ef62c5f95c7bd0a73ea3c177b36999d",
+					SHA256Fingerprint:  "455943cf819425761d1f950263ebf54755d8d684c25535943976f488bc79d23b",
+				},
+			},
+			Verification: &ChainVerification{Verified: true, HostnameMatch: true},
+			Port:         443,
+			Revocation: &RevocationInfo{
+				Stapled: true,
+				Status:  RevocationGood,
+				Checks: []RevocationCheck{
+					{Source: RevocationSourceStaple, Status: RevocationGood,
+						ThisUpdate: fixedTime("2024-06-01T00:00:00Z"), NextUpdate: fixedTime("2024-06-08T00:00:00Z")},
+					{Source: RevocationSourceCRL, URL: "http://crl3.digicert.com/DigiCertGlobalG2TLSRSASHA2562020CA1-1.crl",
+						Status: RevocationUnknown, Err: errors.New("CRL download failed: unexpected HTTP status 404 Not Found")},
+				},
+			},
+		},
+		WhoisInfo: &WhoisInfo{
+			Domain:       "example.com",
+			Source:       WhoisSourceRDAP,
+			Registrar:    "RESERVED-Internet Assigned Numbers Authority",
+			CreatedDate:  "1995-08-14T04:00:00Z",
+			ExpiresDate:  "2025-08-13T04:00:00Z",
+			UpdatedDate:  "2024-08-14T07:01:34Z",
+			NameServers:  []string{"a.iana-servers.net", "b.iana-servers.net"},
+			DomainStatus: []string{"client delete prohibited"},
+			RDAP: &RDAPDomain{
+				Handle:          "2336799_DOMAIN_COM-VRSN",
+				Name:            "example.com",
+				Registrar:       "RESERVED-Internet Assigned Numbers Authority",
+				RegistrarIANAID: "376",
+				Status:          []string{"client delete prohibited"},
+				Events: []RDAPEvent{
+					{Action: RDAPEventRegistration, Date: time.Date(1995, 8, 14, 4, 0, 0, 0, time.UTC)},
+					{Action: RDAPEventExpiration, Date: time.Date(2025, 8, 13, 4, 0, 0, 0, time.UTC)},
+					{Action: RDAPEventLastChanged, Date: time.Date(2024, 8, 14, 7, 1, 34, 0, time.UTC)},
+				},
+				Nameservers: []string{"a.iana-servers.net", "b.iana-servers.net"},
+				Entities: []RDAPEntity{{
+					Handle: "376",
+					Roles:  []string{"registrar"},
+					Name:   "RESERVED-Internet Assigned Numbers Authority",
+					Entities: []RDAPEntity{{
+						Roles: []string{"abuse"},
+						Email: "abuse@example.net",
+						Phone: "+1.5555555555",
+					}},
+				}},
+				DNSSEC: &RDAPSecureDNS{
+					DelegationSigned: true,
+					DS:               []RDAPDSRecord{{KeyTag: 370, Algorithm: 13, DigestType: 2, Digest: "BE74359954660069D5C63D200C39F5603827D7DD02B56F120EE9F3A86764247C"}},
+				},
+				URL: "https://rdap.verisign.com/com/v1/domain/example.com",
+			},
+		},
+		Title:           "Example Domain",
+		MetaDescription: "",
+	}
+
+	report := NewReport("example.com", info, nil)
+	report.GeneratedAt = fixedTime("2025-01-02T03:04:05Z")
+	report.SetDNS(map[string][]string{
+		"A/AAAA": {"93.184.216.34"},
+		"NS":     {"a.iana-servers.net.", "b.iana-servers.net."},
+	}, nil)
+	report.SetPortResults([]PortResult{
+		{Port: 22, State: PortClosed, Latency: 300 * time.Microsecond},
+		{Port: 80, State: PortOpen, Latency: 20 * time.Millisecond},
+		{Port: 443, State: PortOpen, Latency: 21 * time.Millisecond},
+		{Port: 8080, State: PortOpen, Latency: time.Millisecond, Service: &ServiceInfo{
+			Protocol: "ssh",
+			Version:  "OpenSSH_9.6",
+			Banner:   "SSH-2.0-OpenSSH_9.6",
+		}},
+		{Port: 8443, State: PortFiltered, Latency: 2 * time.Second, Err: errors.New("i/o timeout")},
+	}, &ReverseDNS{IP: "93.184.216.34", Names: []string{"example.com"}, ForwardConfirmed: true, Name: "example.com"}, false, nil)
+	report.SetDNSSEC(&DNSSECReport{
+		Domain:         "example.com.",
+		Status:         DNSSECSecure,
+		Signed:         true,
+		Algorithms:     []string{"ECDSAP256SHA256", "RSASHA256"},
+		EarliestExpiry: fixedTime("2025-01-09T00:00:00Z"),
+		Zones: []DNSSECZone{
+			{
+				Name:   "com.",
+				Status: DNSSECSecure,
+				DS:     []DNSSECDelegation{{KeyTag: 19718, Algorithm: "ECDSAP256SHA256", DigestType: 2, Matched: true}},
+				Keys: []DNSSECKey{
+					{KeyTag: 19718, Algorithm: "ECDSAP256SHA256", Flags: 257, SecureEntryPoint: true},
+					{KeyTag: 23202, Algorithm: "ECDSAP256SHA256", Flags: 256},
+				},
+				Signatures: []DNSSECSignature{{
+					Owner: "com.", Covers: "DNSKEY", Signer: "com.", KeyTag: 19718, Algorithm: "ECDSAP256SHA256",
+					Inception: fixedTime("2024-12-25T00:00:00Z"), Expiration: fixedTime("2025-01-09T00:00:00Z"), Valid: true,
+				}},
+			},
+		},
+		Warnings: []string{"the RRSIG over com. DNSKEY (key 19718) expires in 164h55m0s"},
+	}, nil)
+	report.SetEmailSecurity(&EmailSecurityReport{
+		Domain: "example.com",
+		SPF:    &SPFResult{Record: "v=spf1 include:_spf.example.net ~all", All: "~all", Lookups: 1, Includes: []string{"_spf.example.net"}, Warnings: []string{"~all only soft-fails mail from other senders; -all rejects it"}},
+		DMARC:  &DMARCResult{Record: "v=DMARC1; p=reject; rua=mailto:dmarc@example.com", Domain: "_dmarc.example.com", Policy: "reject", Percent: 100, AlignDKIM: "r", AlignSPF: "r", ReportURIs: []string{"mailto:dmarc@example.com"}},
+		DKIM:   []DKIMResult{{Selector: "selector1", Record: "v=DKIM1; k=rsa; p=MIIB", KeyType: "rsa", KeyBits: 2048}},
+		MTASTS: &MTASTSResult{
+			Record:    "v=STSv1; id=20250101",
+			ID:        "20250101",
+			Policy:    &MTASTSPolicy{Version: "STSv1", Mode: "testing", MX: []string{"*.mail.example.net"}, MaxAge: 604800},
+			PolicyURL: "https://mta-sts.example.com/.well-known/mta-sts.txt",
+			Warnings:  []string{"mode: testing only reports failures; use enforce"},
+		},
+		TLSRPT: &TLSRPTResult{Record: "v=TLSRPTv1; rua=mailto:tls@example.com", ReportURIs: []string{"mailto:tls@example.com"}},
+		BIMI:   &BIMIResult{},
+		Score:  82,
+		Grade:  "B",
+	}, nil)
+	report.SetTraceroute([]TracerouteHop{
+		{Number: 1, IP: "192.0.2.1", Host: "gw.example.net", RTT: 1500 * time.Microsecond, Sent: 3, Received: 3,
+			MinRTT: time.Millisecond, AvgRTT: 1500 * time.Microsecond, MaxRTT: 2 * time.Millisecond,
+			ReverseDNS: &ReverseDNS{IP: "192.0.2.1", Names: []string{"gw.example.net"}, Name: "gw.example.net"}},
+		{Number: 2, IP: "*", Sent: 3, Loss: 1},
+		{Number: 3, IP: "93.184.216.34", Host: "example.com", RTT: 12 * time.Millisecond, Sent: 3, Received: 2,
+			Loss: 1.0 / 3, MinRTT: 11 * time.Millisecond, AvgRTT: 12 * time.Millisecond, MaxRTT: 13 * time.Millisecond,
+			Owner: edgecastOwner("93.184.216.34", "93.184.216.0/24"), Geo: edgecastGeo("93.184.216.34", "93.184.216.0/24")},
+	}, false, nil)
+	report.SetTLSAudit(&TLSAudit{
+		Host: "www.example.org",
+		Port: 443,
+		Versions: []TLSVersionSupport{
+			{Version: 0x0301, Name: "TLS 1.0"},
+			{Version: 0x0302, Name: "TLS 1.1"},
+			{Version: 0x0303, Name: "TLS 1.2", Supported: true, ServerPreference: true, Curves: []string{"x25519", "secp256r1"},
+				Ciphers: []CipherSuiteInfo{
+					{ID: 0xc02f, Name: "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256", ForwardSecrecy: true},
+					{ID: 0x000a, Name: "TLS_RSA_WITH_3DES_EDE_CBC_SHA", Weak: true},
+				}},
+			{Version: 0x0304, Name: "TLS 1.3", Supported: true, Curves: []string{"x25519"},
+				Ciphers: []CipherSuiteInfo{{ID: 0x1301, Name: "TLS_AES_128_GCM_SHA256", ForwardSecrecy: true}}},
+		},
+		ALPN:       []string{"h2", "http/1.1"},
+		Weaknesses: []TLSWeakness{Weakness3DES},
+	}, nil)
+	securityHeaders := http.Header{}
+	securityHeaders.Set("Strict-Transport-Security", "max-age=86400")
+	securityHeaders.Set("Content-Security-Policy", "default-src 'self'; frame-ancestors 'none'")
+	securityHeaders.Set("X-Content-Type-Options", "nosniff")
+	report.SetSecurityHeaders(AuditSecurityHeaders(securityHeaders, true))
+	report.SetDualStack(&IPAddressInfo{
+		IPv4Addresses: []string{"93.184.216.34"},
+		IPv6Addresses: []string{"2606:2800:220:1:248:1893:25c8:1946"},
+		Locations:     map[string]*GeoLocation{"93.184.216.34": edgecastGeo("93.184.216.34", "93.184.216.0/24")},
+		ReverseDNS: map[string]*ReverseDNS{
+			"93.184.216.34":                      {IP: "93.184.216.34", Names: []string{"example.com"}, ForwardConfirmed: true, Name: "example.com"},
+			"2606:2800:220:1:248:1893:25c8:1946": {IP: "2606:2800:220:1:248:1893:25c8:1946", Error: "lookup timed out"},
+		},
+	}, true, nil)
+
+	var buf bytes.Buffer
+	if err := report.WriteJSON(&buf); err != nil {
+		t.Fatalf("WriteJSON failed: %v", err)
+	}
+	assertGolden(t, "report.golden.json", buf.Bytes())
+}
+
+func TestReportGoldenWithErrors(t *testing.T) {
+	info := &WebsiteInfo{
+		URL: "https://unreachable.example",
+		Warnings: []error{
+			&ProbeError{Probe: ProbeDNS, Err: errors.New("failed to lookup IP: no such host")},
+		},
+	}
+
+	report := NewReport("unreachable.example", info, errors.New("HTTP request failed: connection refused"))
+	report.GeneratedAt = fixedTime("2025-01-02T03:04:05Z")
+	report.SetDNS(nil, errors.New("no such host"))
+	report.SetDNSSEC(nil, errors.New("DNSSEC check of unreachable.example. failed: DNS query for unreachable.example. DS failed: i/o timeout"))
+	report.SetEmailSecurity(nil, errors.New("email security check failed: empty host name"))
+	report.SetPortScan(nil, true, errors.New("no IPv6 address"))
+	report.SetTraceroute([]TracerouteHop{
+		{Number: 1, IP: "", RTT: time.Millisecond, Sent: 1, Received: 1, MinRTT: time.Millisecond, AvgRTT: time.Millisecond, MaxRTT: time.Millisecond, Estimated: true},
+		{Number: 2, IP: "*", Sent: 1, Loss: 1, Estimated: true},
+	}, false, &TracerouteError{Host: "unreachable.example", Reason: TracerouteReasonPermission, Err: ErrTraceroutePermission})
+	report.SetSSL(nil, errors.New("TLS connection failed: smtp STARTTLS failed: server does not advertise STARTTLS"))
+	report.SetTLSAudit(nil, errors.New("TLS audit of unreachable.example:443 failed: no handshake completed"))
+	report.SetDualStack(nil, false, errors.New("IP lookup failed"))
+
+	var buf bytes.Buffer
+	if err := report.WriteJSON(&buf); err != nil {
+		t.Fatalf("WriteJSON failed: %v", err)
+	}
+	assertGolden(t, "report_errors.golden.json", buf.Bytes())
+}
+
+func TestReportGoldenDNSQueries(t *testing.T) {
+	records := func(rrs ...string) []DNSRecord {
+		var parsed []dns.RR
+		for _, rr := range rrs {
+			record, err := dns.NewRR(rr)
+			if err != nil {
+				t.Fatalf("Invalid record %q: %v", rr, err)
+			}
+			parsed = append(parsed, record)
+		}
+		return newDNSRecords(parsed)
+	}
+
+	report := NewReport("example.com", nil, nil)
+	report.GeneratedAt = fixedTime("2025-01-02T03:04:05Z")
+	report.SetDNSQueries([]*DNSResponse{
+		{
+			Name: "example.com.", Type: "A", Server: "192.0.2.53:53", Protocol: "udp", RCode: "NOERROR",
+			RTT: 12 * time.Millisecond, AuthenticatedData: true,
+			Answer: records("example.com. 300 IN A 93.184.216.34"),
+		},
+		{
+			Name: "example.com.", Type: "CAA", Server: "192.0.2.53:53", Protocol: "tcp", RCode: "NOERROR",
+			RTT:    8 * time.Millisecond,
+			Answer: records(`example.com. 3600 IN CAA 0 issue "digicert.com"`),
+		},
+		{
+			Name: "example.com.", Type: "TLSA", Server: "192.0.2.53:53", Protocol: "udp", RCode: "NOERROR",
+			RTT:       5 * time.Millisecond,
+			Authority: records("example.com. 3600 IN SOA ns.icann.org. noc.dns.icann.org. 2024081448 7200 3600 1209600 3600"),
+		},
+	}, errors.New("DNS query for example.com. BOGUS failed: unsupported DNS record type"))
+	report.SetPropagation(&PropagationReport{
+		Domain: "example.com",
+		Records: []PropagationRecord{{
+			Type:      "A",
+			Consensus: []string{"93.184.216.34"},
+			MinTTL:    120,
+			MaxTTL:    300,
+			Answers: []PropagationAnswer{
+				{Resolver: PropagationResolver{Name: "Google", Address: "8.8.8.8"}, Values: []string{"93.184.216.34"}, TTL: 300, RCode: "NOERROR", RTT: 9 * time.Millisecond},
+				{Resolver: PropagationResolver{Name: "Quad9", Address: "9.9.9.9"}, Values: []string{"192.0.2.1"}, TTL: 120, RCode: "NOERROR", RTT: 14 * time.Millisecond,
+					Added: []string{"192.0.2.1"}, Missing: []string{"93.184.216.34"}},
+				{Resolver: PropagationResolver{Name: "office", Address: "10.0.0.53"}, Error: "DNS query for example.com. A failed: 10.0.0.53:53: i/o timeout"},
+			},
+		}},
+	}, nil)
+	report.SetSubdomains(&SubdomainReport{
+		Domain: "example.com",
+		Subdomains: []Subdomain{
+			{Name: "mail.example.com", Addresses: []string{"93.184.216.40"}, Sources: []string{SubdomainSourceAXFR}},
+			{Name: "www.example.com", Addresses: []string{"93.184.216.34"}, CNAME: "example.com", Sources: []string{SubdomainSourceAXFR, SubdomainSourceWordlist}},
+		},
+		ZoneTransfers: []ZoneTransfer{
+			{Server: "199.43.135.53:53", Allowed: true, Records: 12},
+			{Server: "199.43.133.53:53", Error: "dns: bad xfr rcode: 5"},
+		},
+	}, nil)
+
+	var buf bytes.Buffer
+	if err := report.WriteJSON(&buf); err != nil {
+		t.Fatalf("WriteJSON failed: %v", err)
+	}
+	assertGolden(t, "report_dns.golden.json", buf.Bytes())
+}
+
+func edgecastOwner(ip, prefix string) *NetworkOwner {
+	return &NetworkOwner{
+		IP:         ip,
+		ASN:        15133,
+		ASName:     "EDGECAST, US",
+		Prefix:     prefix,
+		Network:    "EDGECAST-NETBLK-03",
+		RIR:        "ARIN",
+		Country:    "US",
+		AbuseEmail: "abuse@verizondigitalmedia.com",
+		Sources:    []string{OwnerSourceWhois, OwnerSourceRDAP},
+	}
+}
+
+func edgecastGeo(ip, network string) *GeoLocation {
+	return &GeoLocation{
+		IP:             ip,
+		CountryCode:    "US",
+		Country:        "United States",
+		Region:         "Massachusetts",
+		City:           "Norwell",
+		Coordinates:    &GeoCoordinates{Latitude: 42.1596, Longitude: -70.8217, AccuracyRadius: 1000},
+		TimeZone:       "America/New_York",
+		ASN:            15133,
+		ASOrganization: "EDGECAST",
+		Network:        network,
+	}
+}
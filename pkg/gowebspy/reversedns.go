@@ -0,0 +1,220 @@
+package gowebspy
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultReverseDNSTimeout     = 3 * time.Second
+	defaultReverseDNSConcurrency = 16
+)
+
+// ReverseDNS is the PTR lookup of an address and its forward confirmation.
+type ReverseDNS struct {
+	IP string
+	// Names are the PTR names, without the trailing dot. Empty when the
+	// address has no PTR record.
+	Names []string
+	// ForwardConfirmed is set when one of Names resolves back to IP
+	// (forward-confirmed reverse DNS, FCrDNS). Name is that name, or the
+	// first PTR name when none does.
+	ForwardConfirmed bool
+	Name             string
+	// Error is set when the lookup failed rather than found nothing.
+	Error string
+}
+
+// ReverseDNSCache looks up the PTR records of addresses and forward-confirms
+// them, remembering each answer so an address seen again, such as a router
+// on several traceroutes or a host scanned in a batch, is only looked up
+// once. It is safe for concurrent use, and concurrent lookups of the same
+// address share one query. The zero value is an empty cache that queries the
+// system resolver, and a nil *ReverseDNSCache looks up nothing.
+type ReverseDNSCache struct {
+	resolver *net.Resolver
+	// Timeout applies to each address, covering the PTR lookup and the
+	// forward lookups of its names.
+	Timeout time.Duration
+	// Concurrency bounds the lookups LookupAll runs at once.
+	Concurrency int
+
+	mu      sync.Mutex
+	entries map[string]*reverseDNSEntry
+}
+
+type reverseDNSEntry struct {
+	done   chan struct{}
+	result *ReverseDNS
+}
+
+// NewReverseDNSCache returns an empty cache that queries resolver, or the
+// system resolver when it is nil.
+func NewReverseDNSCache(resolver *net.Resolver) *ReverseDNSCache {
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+	return &ReverseDNSCache{
+		resolver:    resolver,
+		Timeout:     defaultReverseDNSTimeout,
+		Concurrency: defaultReverseDNSConcurrency,
+		entries:     map[string]*reverseDNSEntry{},
+	}
+}
+
+// Lookup returns the reverse DNS of ip, from the cache when it was looked up
+// before. It returns nil for anything that is not an IP address.
+func (c *ReverseDNSCache) Lookup(ctx context.Context, ip string) *ReverseDNS {
+	if c == nil {
+		return nil
+	}
+	parsed := net.ParseIP(strings.Trim(ip, "[]"))
+	if parsed == nil {
+		return nil
+	}
+	ip = parsed.String()
+
+	c.mu.Lock()
+	if c.entries == nil {
+		c.entries = map[string]*reverseDNSEntry{}
+	}
+	entry, ok := c.entries[ip]
+	if !ok {
+		entry = &reverseDNSEntry{done: make(chan struct{})}
+		c.entries[ip] = entry
+	}
+	c.mu.Unlock()
+
+	if ok {
+		select {
+		case <-entry.done:
+			return entry.result
+		case <-ctx.Done():
+			return &ReverseDNS{IP: ip, Error: ctx.Err().Error()}
+		}
+	}
+
+	entry.result = c.lookup(ctx, ip)
+	if ctx.Err() != nil {
+		// Don't keep an answer cut short by the caller; the next one
+		// gets to try again.
+		c.mu.Lock()
+		delete(c.entries, ip)
+		c.mu.Unlock()
+	}
+	close(entry.done)
+	return entry.result
+}
+
+// LookupAll looks up every address concurrently. The result has an entry for
+// each of ips, nil for entries that are not addresses.
+func (c *ReverseDNSCache) LookupAll(ctx context.Context, ips []string) []*ReverseDNS {
+	results := make([]*ReverseDNS, len(ips))
+	if c == nil {
+		return results
+	}
+
+	concurrency := c.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultReverseDNSConcurrency
+	}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, concurrency)
+	for i, ip := range ips {
+		wg.Add(1)
+		go func(i int, ip string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			results[i] = c.Lookup(ctx, ip)
+		}(i, ip)
+	}
+	wg.Wait()
+	return results
+}
+
+// ResolveHops sets ReverseDNS on every traceroute hop with an address, and
+// Host to its name when the hop has none.
+func (c *ReverseDNSCache) ResolveHops(ctx context.Context, hops []TracerouteHop) {
+	ips := make([]string, len(hops))
+	for i, hop := range hops {
+		ips[i] = hop.IP
+	}
+	for i, result := range c.LookupAll(ctx, ips) {
+		hops[i].ReverseDNS = result
+		if result != nil && hops[i].Host == "" {
+			hops[i].Host = result.Name
+		}
+	}
+}
+
+// ResolveAddresses fills in info.ReverseDNS for each address.
+func (c *ReverseDNSCache) ResolveAddresses(ctx context.Context, info *IPAddressInfo) {
+	if c == nil || info == nil {
+		return
+	}
+	ips := append(append([]string{}, info.IPv4Addresses...), info.IPv6Addresses...)
+	for i, result := range c.LookupAll(ctx, ips) {
+		if result == nil {
+			continue
+		}
+		if info.ReverseDNS == nil {
+			info.ReverseDNS = map[string]*ReverseDNS{}
+		}
+		info.ReverseDNS[ips[i]] = result
+	}
+}
+
+func (c *ReverseDNSCache) lookup(ctx context.Context, ip string) *ReverseDNS {
+	result := &ReverseDNS{IP: ip}
+
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = defaultReverseDNSTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	resolver := c.resolver
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+
+	names, err := resolver.LookupAddr(ctx, ip)
+	if err != nil {
+		var dnsErr *net.DNSError
+		if !errors.As(err, &dnsErr) || !dnsErr.IsNotFound {
+			result.Error = err.Error()
+		}
+		return result
+	}
+
+	for _, name := range names {
+		result.Names = append(result.Names, strings.TrimSuffix(name, "."))
+	}
+	if len(result.Names) > 0 {
+		result.Name = result.Names[0]
+	}
+
+	target := net.ParseIP(ip)
+	for _, name := range result.Names {
+		addrs, err := resolver.LookupIPAddr(ctx, name)
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			if addr.IP.Equal(target) {
+				result.ForwardConfirmed = true
+				result.Name = name
+				return result
+			}
+		}
+	}
+	return result
+}
@@ -0,0 +1,128 @@
+package gowebspy
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/miekg/dns"
+)
+
+// reverseZone has PTR records for 192.0.2.1, whose name points back at it,
+// and 192.0.2.2, whose name doesn't. 192.0.2.3 has none. ptrQueries counts
+// the PTR questions it is asked.
+func reverseZone(ptrQueries *int32) dns.HandlerFunc {
+	return func(w dns.ResponseWriter, req *dns.Msg) {
+		question := req.Question[0]
+		zone := map[string][]string{
+			"1.2.0.192.in-addr.arpa. PTR": {"1.2.0.192.in-addr.arpa. 300 IN PTR router1.rdns.test."},
+			"2.2.0.192.in-addr.arpa. PTR": {"2.2.0.192.in-addr.arpa. 300 IN PTR spoofed.rdns.test."},
+			"router1.rdns.test. A":        {"router1.rdns.test. 300 IN A 192.0.2.1"},
+			"spoofed.rdns.test. A":        {"spoofed.rdns.test. 300 IN A 198.51.100.7"},
+		}
+		if question.Qtype == dns.TypePTR {
+			atomic.AddInt32(ptrQueries, 1)
+		}
+
+		reply := new(dns.Msg)
+		reply.SetReply(req)
+		records, ok := zone[strings.ToLower(question.Name)+" "+dns.TypeToString[question.Qtype]]
+		if !ok && question.Qtype == dns.TypePTR {
+			reply.Rcode = dns.RcodeNameError
+		}
+		for _, record := range records {
+			rr, _ := dns.NewRR(record)
+			reply.Answer = append(reply.Answer, rr)
+		}
+		w.WriteMsg(reply)
+	}
+}
+
+func TestReverseDNSLookup(t *testing.T) {
+	var ptrQueries int32
+	cache := NewReverseDNSCache(NewDNSResolver(dnsServer(t, reverseZone(&ptrQueries))))
+	ctx := context.Background()
+
+	confirmed := cache.Lookup(ctx, "192.0.2.1")
+	if confirmed.Name != "router1.rdns.test" || !confirmed.ForwardConfirmed || confirmed.Error != "" {
+		t.Errorf("192.0.2.1: %+v, want router1.rdns.test forward-confirmed", confirmed)
+	}
+
+	spoofed := cache.Lookup(ctx, "192.0.2.2")
+	if spoofed.Name != "spoofed.rdns.test" || spoofed.ForwardConfirmed {
+		t.Errorf("192.0.2.2: %+v, want spoofed.rdns.test not forward-confirmed", spoofed)
+	}
+
+	missing := cache.Lookup(ctx, "192.0.2.3")
+	if len(missing.Names) != 0 || missing.Error != "" {
+		t.Errorf("192.0.2.3: %+v, want no names and no error", missing)
+	}
+
+	if cache.Lookup(ctx, "*") != nil {
+		t.Error("Expected nil for a hop without an address")
+	}
+	var nilCache *ReverseDNSCache
+	if nilCache.Lookup(ctx, "192.0.2.1") != nil {
+		t.Error("Expected a nil cache to look up nothing")
+	}
+}
+
+func TestReverseDNSCacheZeroValue(t *testing.T) {
+	// A cancelled context keeps the system resolver from being queried.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var cache ReverseDNSCache
+	if result := cache.Lookup(ctx, "192.0.2.1"); result == nil || result.IP != "192.0.2.1" {
+		t.Errorf("Expected a zero-value cache to look up the address, got %+v", result)
+	}
+}
+
+func TestReverseDNSCacheSharesLookups(t *testing.T) {
+	var ptrQueries int32
+	cache := NewReverseDNSCache(NewDNSResolver(dnsServer(t, reverseZone(&ptrQueries))))
+
+	ips := []string{"192.0.2.1", "192.0.2.1", "192.0.2.2", "192.0.2.1", "192.0.2.2"}
+	results := cache.LookupAll(context.Background(), ips)
+	for i, result := range results {
+		if result == nil || result.IP != ips[i] {
+			t.Fatalf("Result %d = %+v, want a lookup of %s", i, result, ips[i])
+		}
+	}
+	if results[0] != results[1] {
+		t.Error("Expected repeated addresses to share one result")
+	}
+	cache.Lookup(context.Background(), "192.0.2.2")
+	if got := atomic.LoadInt32(&ptrQueries); got != 2 {
+		t.Errorf("PTR queries = %d, want 2", got)
+	}
+}
+
+func TestReverseDNSResolveHops(t *testing.T) {
+	var ptrQueries int32
+	cache := NewReverseDNSCache(NewDNSResolver(dnsServer(t, reverseZone(&ptrQueries))))
+
+	hops := []TracerouteHop{
+		{Number: 1, IP: "192.0.2.1"},
+		{Number: 2, IP: "*"},
+		{Number: 3, IP: "192.0.2.2", Host: "target.example"},
+	}
+	cache.ResolveHops(context.Background(), hops)
+
+	if hops[0].Host != "router1.rdns.test" || hops[0].ReverseDNS == nil {
+		t.Errorf("Hop 1 = %+v, want router1.rdns.test", hops[0])
+	}
+	if hops[1].Host != "" || hops[1].ReverseDNS != nil {
+		t.Errorf("Hop 2 = %+v, want it left alone", hops[1])
+	}
+	if hops[2].Host != "target.example" || hops[2].ReverseDNS == nil || hops[2].ReverseDNS.Name != "spoofed.rdns.test" {
+		t.Errorf("Hop 3 = %+v, want its host kept and the PTR name recorded", hops[2])
+	}
+
+	info := &IPAddressInfo{IPv4Addresses: []string{"192.0.2.1", "192.0.2.3"}}
+	cache.ResolveAddresses(context.Background(), info)
+	if len(info.ReverseDNS) != 2 || !info.ReverseDNS["192.0.2.1"].ForwardConfirmed {
+		t.Errorf("ReverseDNS = %+v", info.ReverseDNS)
+	}
+}
@@ -0,0 +1,229 @@
+package gowebspy
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+)
+
+const maxRevocationResponseSize = 10 << 20
+
+// RevocationStatus is the revocation state of a certificate. The empty value
+// means nothing was checked.
+type RevocationStatus string
+
+const (
+	RevocationGood    RevocationStatus = "good"
+	RevocationRevoked RevocationStatus = "revoked"
+	RevocationUnknown RevocationStatus = "unknown"
+)
+
+const (
+	RevocationSourceStaple = "ocsp-staple"
+	RevocationSourceOCSP   = "ocsp"
+	RevocationSourceCRL    = "crl"
+)
+
+// RevocationCheck is the answer of one revocation source about the leaf
+// certificate. Status is unknown when the source could not be used; Err then
+// says why.
+type RevocationCheck struct {
+	Source     string
+	URL        string
+	Status     RevocationStatus
+	RevokedAt  time.Time
+	ThisUpdate time.Time
+	NextUpdate time.Time
+	Err        error
+}
+
+type RevocationInfo struct {
+	// Stapled is true when the server sent an OCSP response in the handshake.
+	Stapled bool
+	// Status combines all checks: revoked if any source says so, good if at
+	// least one source vouches for the certificate, unknown otherwise.
+	Status RevocationStatus
+	Checks []RevocationCheck
+}
+
+// checkRevocation validates the stapled OCSP response, if any, and when
+// opts.CheckRevocation is set also asks the OCSP responder and downloads the
+// CRLs named in the leaf certificate.
+func checkRevocation(ctx context.Context, certs []*x509.Certificate, staple []byte, opts Options) *RevocationInfo {
+	info := &RevocationInfo{Stapled: len(staple) > 0}
+	if !info.Stapled && !opts.CheckRevocation {
+		return info
+	}
+
+	leaf := certs[0]
+	var issuer *x509.Certificate
+	if len(certs) > 1 {
+		issuer = certs[1]
+	}
+
+	if info.Stapled {
+		check := RevocationCheck{Source: RevocationSourceStaple}
+		check.fromOCSP(staple, leaf, issuer)
+		info.Checks = append(info.Checks, check)
+	}
+
+	if opts.CheckRevocation {
+		for _, server := range leaf.OCSPServer {
+			check := RevocationCheck{Source: RevocationSourceOCSP, URL: server}
+			check.queryOCSP(ctx, leaf, issuer, opts)
+			info.Checks = append(info.Checks, check)
+		}
+		for _, point := range leaf.CRLDistributionPoints {
+			check := RevocationCheck{Source: RevocationSourceCRL, URL: point}
+			check.queryCRL(ctx, leaf, issuer, opts)
+			info.Checks = append(info.Checks, check)
+		}
+	}
+
+	info.Status = RevocationUnknown
+	for _, check := range info.Checks {
+		switch check.Status {
+		case RevocationRevoked:
+			info.Status = RevocationRevoked
+		case RevocationGood:
+			if info.Status != RevocationRevoked {
+				info.Status = RevocationGood
+			}
+		}
+	}
+	return info
+}
+
+func (c *RevocationCheck) fail(err error) {
+	c.Status = RevocationUnknown
+	c.Err = err
+}
+
+var errNoIssuer = errors.New("issuer certificate not presented by the server")
+
+// fromOCSP parses and validates a DER OCSP response for leaf. The signature
+// is checked against issuer (or a delegated responder certificate it
+// signed), and a response outside its validity window is not trusted.
+func (c *RevocationCheck) fromOCSP(der []byte, leaf, issuer *x509.Certificate) {
+	if issuer == nil {
+		c.fail(errNoIssuer)
+		return
+	}
+
+	resp, err := ocsp.ParseResponseForCert(der, leaf, issuer)
+	if err != nil {
+		c.fail(fmt.Errorf("invalid OCSP response: %w", err))
+		return
+	}
+	c.ThisUpdate = resp.ThisUpdate
+	c.NextUpdate = resp.NextUpdate
+
+	now := time.Now()
+	if now.Before(resp.ThisUpdate) || (!resp.NextUpdate.IsZero() && now.After(resp.NextUpdate)) {
+		c.fail(fmt.Errorf("OCSP response is outside its validity window (%s to %s)",
+			resp.ThisUpdate.Format(time.RFC3339), resp.NextUpdate.Format(time.RFC3339)))
+		return
+	}
+
+	switch resp.Status {
+	case ocsp.Good:
+		c.Status = RevocationGood
+	case ocsp.Revoked:
+		c.Status = RevocationRevoked
+		c.RevokedAt = resp.RevokedAt
+	default:
+		c.Status = RevocationUnknown
+	}
+}
+
+func (c *RevocationCheck) queryOCSP(ctx context.Context, leaf, issuer *x509.Certificate, opts Options) {
+	if issuer == nil {
+		c.fail(errNoIssuer)
+		return
+	}
+
+	request, err := ocsp.CreateRequest(leaf, issuer, nil)
+	if err != nil {
+		c.fail(err)
+		return
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.URL, bytes.NewReader(request))
+	if err != nil {
+		c.fail(err)
+		return
+	}
+	httpReq.Header.Set("Content-Type", "application/ocsp-request")
+
+	body, err := fetchRevocationData(opts.revocationClient(), httpReq)
+	if err != nil {
+		c.fail(fmt.Errorf("OCSP request failed: %w", err))
+		return
+	}
+	c.fromOCSP(body, leaf, issuer)
+}
+
+func (c *RevocationCheck) queryCRL(ctx context.Context, leaf, issuer *x509.Certificate, opts Options) {
+	if issuer == nil {
+		c.fail(errNoIssuer)
+		return
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, c.URL, nil)
+	if err != nil {
+		c.fail(err)
+		return
+	}
+
+	body, err := fetchRevocationData(opts.revocationClient(), httpReq)
+	if err != nil {
+		c.fail(fmt.Errorf("CRL download failed: %w", err))
+		return
+	}
+
+	crl, err := x509.ParseRevocationList(body)
+	if err != nil {
+		c.fail(fmt.Errorf("invalid CRL: %w", err))
+		return
+	}
+	if err := crl.CheckSignatureFrom(issuer); err != nil {
+		c.fail(fmt.Errorf("CRL signature does not match the issuer: %w", err))
+		return
+	}
+	c.ThisUpdate = crl.ThisUpdate
+	c.NextUpdate = crl.NextUpdate
+
+	if !crl.NextUpdate.IsZero() && time.Now().After(crl.NextUpdate) {
+		c.fail(fmt.Errorf("CRL expired at %s", crl.NextUpdate.Format(time.RFC3339)))
+		return
+	}
+
+	c.Status = RevocationGood
+	for _, entry := range crl.RevokedCertificateEntries {
+		if entry.SerialNumber.Cmp(leaf.SerialNumber) == 0 {
+			c.Status = RevocationRevoked
+			c.RevokedAt = entry.RevocationTime
+			return
+		}
+	}
+}
+
+func fetchRevocationData(client *http.Client, req *http.Request) ([]byte, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected HTTP status %s", resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxRevocationResponseSize))
+}
@@ -0,0 +1,229 @@
+package gowebspy
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+)
+
+const revokedSerial = 66
+
+// revocationAuthority is a test CA with an OCSP responder and a CRL that both
+// list revokedSerial as revoked.
+type revocationAuthority struct {
+	ca     *testCert
+	server *httptest.Server
+}
+
+func newRevocationAuthority(t *testing.T) *revocationAuthority {
+	now := time.Now()
+	template := caTemplate("Revocation Test CA", 1, now.Add(-time.Hour), now.Add(time.Hour))
+	template.KeyUsage |= x509.KeyUsageCRLSign
+	ca := &revocationAuthority{ca: issueCert(t, template, nil)}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ocsp", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		request, err := ocsp.ParseRequest(body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Write(ca.ocspResponse(t, request.SerialNumber, now.Add(-time.Minute), now.Add(time.Hour)))
+	})
+	mux.HandleFunc("/ca.crl", func(w http.ResponseWriter, r *http.Request) {
+		crl, err := x509.CreateRevocationList(nil, &x509.RevocationList{
+			Number:     big.NewInt(1),
+			ThisUpdate: now.Add(-time.Minute),
+			NextUpdate: now.Add(time.Hour),
+			RevokedCertificateEntries: []x509.RevocationListEntry{
+				{SerialNumber: big.NewInt(revokedSerial), RevocationTime: now.Add(-time.Minute)},
+			},
+		}, ca.ca.cert, ca.ca.key)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Write(crl)
+	})
+	// Responders often redirect to a CDN; 307 keeps the OCSP POST a POST.
+	mux.Handle("/moved/ocsp", http.RedirectHandler("/ocsp", http.StatusTemporaryRedirect))
+	mux.Handle("/moved/ca.crl", http.RedirectHandler("/ca.crl", http.StatusFound))
+	ca.server = httptest.NewServer(mux)
+	t.Cleanup(ca.server.Close)
+	return ca
+}
+
+func (ca *revocationAuthority) ocspResponse(t *testing.T, serial *big.Int, thisUpdate, nextUpdate time.Time) []byte {
+	t.Helper()
+
+	template := ocsp.Response{
+		Status:       ocsp.Good,
+		SerialNumber: serial,
+		ThisUpdate:   thisUpdate,
+		NextUpdate:   nextUpdate,
+	}
+	if serial.Int64() == revokedSerial {
+		template.Status = ocsp.Revoked
+		template.RevokedAt = thisUpdate
+	}
+	der, err := ocsp.CreateResponse(ca.ca.cert, ca.ca.cert, template, ca.ca.key)
+	if err != nil {
+		t.Fatalf("Failed to create OCSP response: %v", err)
+	}
+	return der
+}
+
+func (ca *revocationAuthority) issue(t *testing.T, serial int64) *testCert {
+	return ca.issueAt(t, serial, "")
+}
+
+// issueAt issues a leaf whose OCSP and CRL URLs are under prefix.
+func (ca *revocationAuthority) issueAt(t *testing.T, serial int64, prefix string) *testCert {
+	now := time.Now()
+	template := leafTemplate("localhost", serial, now.Add(-time.Hour), now.Add(time.Hour))
+	template.OCSPServer = []string{ca.server.URL + prefix + "/ocsp"}
+	template.CRLDistributionPoints = []string{ca.server.URL + prefix + "/ca.crl"}
+	return issueCert(t, template, ca.ca)
+}
+
+func TestCheckRevocationQueries(t *testing.T) {
+	ca := newRevocationAuthority(t)
+
+	tests := []struct {
+		serial   int64
+		expected RevocationStatus
+	}{
+		{10, RevocationGood},
+		{revokedSerial, RevocationRevoked},
+	}
+
+	for _, test := range tests {
+		leaf := ca.issue(t, test.serial)
+		info := checkRevocation(context.Background(), []*x509.Certificate{leaf.cert, ca.ca.cert}, nil, Options{CheckRevocation: true})
+
+		if info.Stapled {
+			t.Errorf("serial %d: Stapled = true without a staple", test.serial)
+		}
+		if info.Status != test.expected {
+			t.Errorf("serial %d: Status = %q, want %q", test.serial, info.Status, test.expected)
+		}
+		if len(info.Checks) != 2 {
+			t.Fatalf("serial %d: expected an OCSP and a CRL check, got %+v", test.serial, info.Checks)
+		}
+		for _, check := range info.Checks {
+			if check.Status != test.expected || check.Err != nil {
+				t.Errorf("serial %d: %s check = %q (%v), want %q", test.serial, check.Source, check.Status, check.Err, test.expected)
+			}
+		}
+	}
+}
+
+func TestCheckRevocationFollowsRedirects(t *testing.T) {
+	ca := newRevocationAuthority(t)
+	leaf := ca.issueAt(t, revokedSerial, "/moved")
+
+	info := checkRevocation(context.Background(), []*x509.Certificate{leaf.cert, ca.ca.cert}, nil, Options{CheckRevocation: true})
+	if info.Status != RevocationRevoked {
+		t.Errorf("Status = %q, want revoked", info.Status)
+	}
+	for _, check := range info.Checks {
+		if check.Status != RevocationRevoked || check.Err != nil {
+			t.Errorf("%s check = %q (%v), want revoked through the redirect", check.Source, check.Status, check.Err)
+		}
+	}
+}
+
+func TestCheckRevocationWithoutIssuer(t *testing.T) {
+	ca := newRevocationAuthority(t)
+	leaf := ca.issue(t, 10)
+
+	info := checkRevocation(context.Background(), []*x509.Certificate{leaf.cert}, nil, Options{CheckRevocation: true})
+	if info.Status != RevocationUnknown {
+		t.Errorf("Status = %q, want unknown", info.Status)
+	}
+	for _, check := range info.Checks {
+		if check.Err == nil {
+			t.Errorf("%s check should fail without the issuer", check.Source)
+		}
+	}
+}
+
+func TestStapledOCSP(t *testing.T) {
+	ca := newRevocationAuthority(t)
+	now := time.Now()
+
+	tests := []struct {
+		name     string
+		serial   int64
+		staple   func(serial *big.Int) []byte
+		expected RevocationStatus
+		valid    bool
+	}{
+		{"good", 10, func(serial *big.Int) []byte {
+			return ca.ocspResponse(t, serial, now.Add(-time.Minute), now.Add(time.Hour))
+		}, RevocationGood, true},
+		{"revoked", revokedSerial, func(serial *big.Int) []byte {
+			return ca.ocspResponse(t, serial, now.Add(-time.Minute), now.Add(time.Hour))
+		}, RevocationRevoked, false},
+		{"stale", 11, func(serial *big.Int) []byte {
+			return ca.ocspResponse(t, serial, now.Add(-2*time.Hour), now.Add(-time.Hour))
+		}, RevocationUnknown, true},
+		{"garbage", 12, func(serial *big.Int) []byte {
+			return []byte("not an OCSP response")
+		}, RevocationUnknown, true},
+	}
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.ca.cert)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			leaf := ca.issue(t, test.serial)
+			port := tlsServer(t, &tls.Config{Certificates: []tls.Certificate{{
+				Certificate: [][]byte{leaf.cert.Raw, ca.ca.cert.Raw},
+				PrivateKey:  leaf.key,
+				OCSPStaple:  test.staple(leaf.cert.SerialNumber),
+			}}})
+
+			info, err := GetTLSInfo(context.Background(), "localhost", port, StartTLSNone, Options{RootCAs: roots})
+			if err != nil {
+				t.Fatalf("GetTLSInfo failed: %v", err)
+			}
+			if !info.Revocation.Stapled || info.Revocation.Status != test.expected {
+				t.Errorf("Revocation = %+v, want stapled and %q", info.Revocation, test.expected)
+			}
+			if info.Valid != test.valid {
+				t.Errorf("Valid = %v, want %v (%+v)", info.Valid, test.valid, info.Verification)
+			}
+
+			filter := NewFilterOptions()
+			filter.SSLRevocationStatus = RevocationGood
+			if ApplyFilter(&WebsiteInfo{SSLInfo: info}, filter) != (test.expected == RevocationGood) {
+				t.Errorf("Revocation filter did not match status %q", info.Revocation.Status)
+			}
+		})
+	}
+}
+
+func TestFilterRevocationUnchecked(t *testing.T) {
+	info := &WebsiteInfo{SSLInfo: &SSLInfo{Valid: true, Revocation: &RevocationInfo{}}}
+
+	filter := NewFilterOptions()
+	filter.SSLRevocationStatus = RevocationUnknown
+	if !ApplyFilter(info, filter) {
+		t.Error("Expected an unchecked certificate to match unknown")
+	}
+	filter.SSLRevocationStatus = RevocationGood
+	if ApplyFilter(info, filter) {
+		t.Error("Expected an unchecked certificate not to match good")
+	}
+}
@@ -0,0 +1,440 @@
+package gowebspy
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// hstsPreloadMaxAge is the minimum max-age, one year, accepted by the HSTS
+// preload list.
+const hstsPreloadMaxAge = 31536000
+
+// SecurityHeaderReport grades the security headers of a response. Each header
+// lists its own Errors, which leave the protection ineffective, and Warnings
+// for weaker configurations.
+type SecurityHeaderReport struct {
+	Headers []SecurityHeaderResult
+	// HSTS and CSP are the parsed policies, nil when the header is missing.
+	HSTS *HSTSPolicy
+	CSP  *CSPPolicy
+	// Score is out of 100; Grade is A to F.
+	Score int
+	Grade string
+}
+
+type SecurityHeaderResult struct {
+	Name    string
+	Value   string
+	Present bool
+	// Score is this header's share of the total, out of MaxScore.
+	Score    int
+	MaxScore int
+	// Note explains a score that doesn't follow from Value, such as a
+	// missing X-Frame-Options covered by CSP frame-ancestors.
+	Note     string
+	Errors   []string
+	Warnings []string
+}
+
+// Header returns the result for name, or nil when it wasn't audited.
+func (r *SecurityHeaderReport) Header(name string) *SecurityHeaderResult {
+	for i := range r.Headers {
+		if strings.EqualFold(r.Headers[i].Name, name) {
+			return &r.Headers[i]
+		}
+	}
+	return nil
+}
+
+type HSTSPolicy struct {
+	MaxAge            int64
+	IncludeSubDomains bool
+	Preload           bool
+	// PreloadEligible is set when the policy meets the hstspreload.org
+	// requirements: served over HTTPS with a max-age of at least a year,
+	// includeSubDomains and preload.
+	PreloadEligible bool
+}
+
+type CSPPolicy struct {
+	// Directives maps each directive name, lowercased, to its sources.
+	Directives map[string][]string
+	// ReportOnly is set when the policy came from
+	// Content-Security-Policy-Report-Only and so isn't enforced.
+	ReportOnly bool
+}
+
+// Sources returns the sources that apply to directive, falling back to
+// default-src for fetch directives, and whether any were set.
+func (p *CSPPolicy) Sources(directive string) ([]string, bool) {
+	if sources, ok := p.Directives[directive]; ok {
+		return sources, true
+	}
+	if strings.HasSuffix(directive, "-src") {
+		sources, ok := p.Directives["default-src"]
+		return sources, ok
+	}
+	return nil, false
+}
+
+// AuditSecurityHeaders parses and grades Strict-Transport-Security,
+// Content-Security-Policy, X-Frame-Options, X-Content-Type-Options,
+// Referrer-Policy, Permissions-Policy and the cross-origin isolation headers
+// COOP, COEP and CORP. https says whether the response came over TLS, since
+// browsers ignore HSTS on plain HTTP.
+func AuditSecurityHeaders(headers http.Header, https bool) *SecurityHeaderReport {
+	report := &SecurityHeaderReport{}
+
+	hsts, hstsPolicy := auditHSTS(headers, https)
+	csp, cspPolicy := auditCSP(headers)
+	report.HSTS = hstsPolicy
+	report.CSP = cspPolicy
+	report.Headers = []SecurityHeaderResult{
+		hsts,
+		csp,
+		auditFrameOptions(headers, cspPolicy),
+		auditContentTypeOptions(headers),
+		auditReferrerPolicy(headers),
+		auditPermissionsPolicy(headers),
+		auditKeyword(headers, "Cross-Origin-Opener-Policy", 4, map[string]int{
+			"same-origin": 4, "same-origin-allow-popups": 2, "noopener-allow-popups": 2, "unsafe-none": 0,
+		}),
+		auditKeyword(headers, "Cross-Origin-Embedder-Policy", 3, map[string]int{
+			"require-corp": 3, "credentialless": 3, "unsafe-none": 0,
+		}),
+		auditKeyword(headers, "Cross-Origin-Resource-Policy", 3, map[string]int{
+			"same-origin": 3, "same-site": 3, "cross-origin": 1,
+		}),
+	}
+
+	for _, result := range report.Headers {
+		report.Score += result.Score
+	}
+	report.Grade = letterGrade(report.Score)
+	return report
+}
+
+// newHeaderResult reads the header name and marks it missing when unset.
+func newHeaderResult(headers http.Header, name string, maxScore int) SecurityHeaderResult {
+	result := SecurityHeaderResult{Name: name, MaxScore: maxScore}
+	if values := headers.Values(name); len(values) > 0 {
+		result.Value = strings.TrimSpace(strings.Join(values, ", "))
+		result.Present = true
+	}
+	return result
+}
+
+func auditHSTS(headers http.Header, https bool) (SecurityHeaderResult, *HSTSPolicy) {
+	result := newHeaderResult(headers, "Strict-Transport-Security", 25)
+	if !result.Present {
+		if https {
+			result.Errors = append(result.Errors, "not set, so the first visit and any http:// link can be downgraded")
+		}
+		return result, nil
+	}
+
+	// Only the first header counts (RFC 6797 8.1).
+	policy := &HSTSPolicy{MaxAge: -1}
+	for _, directive := range strings.Split(headers.Get("Strict-Transport-Security"), ";") {
+		name, value, _ := strings.Cut(strings.TrimSpace(directive), "=")
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "max-age":
+			maxAge, err := strconv.ParseInt(strings.Trim(strings.TrimSpace(value), `"`), 10, 64)
+			if err != nil || maxAge < 0 {
+				result.Errors = append(result.Errors, fmt.Sprintf("invalid max-age %q", value))
+				continue
+			}
+			policy.MaxAge = maxAge
+		case "includesubdomains":
+			policy.IncludeSubDomains = true
+		case "preload":
+			policy.Preload = true
+		}
+	}
+	policy.PreloadEligible = https && policy.MaxAge >= hstsPreloadMaxAge && policy.IncludeSubDomains && policy.Preload
+
+	switch {
+	case !https:
+		result.Warnings = append(result.Warnings, "sent over plain HTTP, where browsers ignore it")
+	case policy.MaxAge < 0 && len(result.Errors) == 0:
+		result.Errors = append(result.Errors, "max-age is missing, so the header is ignored")
+	case policy.MaxAge == 0:
+		result.Errors = append(result.Errors, "max-age=0 tells browsers to forget the policy")
+	}
+	if !https || len(result.Errors) > 0 {
+		return result, policy
+	}
+
+	result.Score = 15
+	if policy.MaxAge >= hstsPreloadMaxAge {
+		result.Score += 5
+	} else {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("max-age=%d is shorter than a year", policy.MaxAge))
+	}
+	if policy.IncludeSubDomains {
+		result.Score += 5
+	} else {
+		result.Warnings = append(result.Warnings, "includeSubDomains is not set, so subdomains can still be downgraded")
+	}
+	if policy.Preload && !policy.PreloadEligible {
+		result.Warnings = append(result.Warnings, "preload is set but the policy is not eligible for the preload list")
+	}
+	return result, policy
+}
+
+func auditCSP(headers http.Header) (SecurityHeaderResult, *CSPPolicy) {
+	result := newHeaderResult(headers, "Content-Security-Policy", 25)
+	value := result.Value
+	reportOnly := false
+	if !result.Present {
+		value = strings.TrimSpace(headers.Get("Content-Security-Policy-Report-Only"))
+		if value == "" {
+			result.Errors = append(result.Errors, "not set, so injected scripts run unrestricted")
+			return result, nil
+		}
+		reportOnly = true
+		result.Value = value
+		result.Note = "from Content-Security-Policy-Report-Only"
+	}
+
+	// A header may carry several policies separated by commas, each of which
+	// is enforced; only the first is analyzed.
+	policies := strings.Split(value, ",")
+	policy := parseCSP(policies[0])
+	policy.ReportOnly = reportOnly
+	if len(policies) > 1 {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("%d policies are set; only the first is analyzed", len(policies)))
+	}
+
+	scripts, ok := policy.Sources("script-src")
+	if !ok {
+		result.Errors = append(result.Errors, "neither script-src nor default-src is set, so scripts are unrestricted")
+	} else {
+		result.Errors = append(result.Errors, weakScriptSources(scripts)...)
+		if containsSource(scripts, "'unsafe-eval'") {
+			result.Warnings = append(result.Warnings, "script-src allows 'unsafe-eval'")
+		}
+	}
+	if objects, ok := policy.Sources("object-src"); !ok || !containsSource(objects, "'none'") {
+		result.Warnings = append(result.Warnings, "object-src is not 'none', so plugins can load")
+	}
+	if _, ok := policy.Directives["base-uri"]; !ok {
+		result.Warnings = append(result.Warnings, "base-uri is not set, so an injected <base> can redirect relative scripts")
+	}
+	if _, ok := policy.Directives["frame-ancestors"]; !ok {
+		result.Warnings = append(result.Warnings, "frame-ancestors is not set")
+	}
+
+	switch {
+	case reportOnly:
+		result.Warnings = append(result.Warnings, "only Content-Security-Policy-Report-Only is set, so nothing is enforced")
+	case len(result.Errors) > 0:
+		result.Score = 5
+	default:
+		result.Score = max(25-3*len(result.Warnings), 10)
+	}
+	return result, policy
+}
+
+func parseCSP(value string) *CSPPolicy {
+	policy := &CSPPolicy{Directives: map[string][]string{}}
+	for _, directive := range strings.Split(value, ";") {
+		fields := strings.Fields(directive)
+		if len(fields) == 0 {
+			continue
+		}
+		name := strings.ToLower(fields[0])
+		// Repeated directives are ignored after the first.
+		if _, ok := policy.Directives[name]; !ok {
+			policy.Directives[name] = fields[1:]
+		}
+	}
+	return policy
+}
+
+// weakScriptSources explains why sources would let an attacker's script run.
+func weakScriptSources(sources []string) []string {
+	var problems []string
+	// A nonce, hash or 'strict-dynamic' makes CSP2 browsers ignore
+	// 'unsafe-inline', which is kept only for older ones.
+	strict := false
+	for _, source := range sources {
+		lower := strings.ToLower(source)
+		if strings.HasPrefix(lower, "'nonce-") || strings.HasPrefix(lower, "'sha") || lower == "'strict-dynamic'" {
+			strict = true
+		}
+	}
+	for _, source := range sources {
+		switch strings.ToLower(source) {
+		case "'unsafe-inline'":
+			if !strict {
+				problems = append(problems, "script-src allows 'unsafe-inline' without a nonce or hash")
+			}
+		case "*", "http:", "https:", "data:", "blob:":
+			problems = append(problems, fmt.Sprintf("script-src allows any script from %s", source))
+		}
+	}
+	return problems
+}
+
+func containsSource(sources []string, source string) bool {
+	for _, s := range sources {
+		if strings.EqualFold(s, source) {
+			return true
+		}
+	}
+	return false
+}
+
+func auditFrameOptions(headers http.Header, csp *CSPPolicy) SecurityHeaderResult {
+	result := newHeaderResult(headers, "X-Frame-Options", 10)
+	if csp != nil && !csp.ReportOnly {
+		if _, ok := csp.Directives["frame-ancestors"]; ok {
+			// Browsers that support frame-ancestors ignore X-Frame-Options.
+			result.Score = result.MaxScore
+			result.Note = "superseded by CSP frame-ancestors"
+			return result
+		}
+	}
+	if !result.Present {
+		result.Errors = append(result.Errors, "not set, so the page can be framed for clickjacking")
+		return result
+	}
+
+	switch value := strings.ToUpper(result.Value); {
+	case value == "DENY" || value == "SAMEORIGIN":
+		result.Score = 10
+	case strings.HasPrefix(value, "ALLOW-FROM"):
+		result.Score = 5
+		result.Warnings = append(result.Warnings, "ALLOW-FROM is not supported by current browsers; use CSP frame-ancestors")
+	default:
+		result.Errors = append(result.Errors, fmt.Sprintf("invalid value %q; use DENY or SAMEORIGIN", result.Value))
+	}
+	return result
+}
+
+func auditContentTypeOptions(headers http.Header) SecurityHeaderResult {
+	result := newHeaderResult(headers, "X-Content-Type-Options", 10)
+	switch {
+	case !result.Present:
+		result.Errors = append(result.Errors, "not set, so browsers may sniff content types")
+	case strings.EqualFold(result.Value, "nosniff"):
+		result.Score = 10
+	default:
+		result.Errors = append(result.Errors, fmt.Sprintf("invalid value %q; the only value is nosniff", result.Value))
+	}
+	return result
+}
+
+func auditReferrerPolicy(headers http.Header) SecurityHeaderResult {
+	result := newHeaderResult(headers, "Referrer-Policy", 10)
+	if !result.Present {
+		result.Warnings = append(result.Warnings, "not set; browsers default to strict-origin-when-cross-origin")
+		result.Score = 5
+		return result
+	}
+
+	// Browsers use the last policy they recognize, so later values are
+	// fallbacks-in-reverse for older browsers.
+	policy := ""
+	for _, token := range strings.Split(result.Value, ",") {
+		switch token = strings.ToLower(strings.TrimSpace(token)); token {
+		case "no-referrer", "same-origin", "strict-origin", "strict-origin-when-cross-origin",
+			"origin", "origin-when-cross-origin", "no-referrer-when-downgrade", "unsafe-url":
+			policy = token
+		}
+	}
+
+	switch policy {
+	case "no-referrer", "same-origin", "strict-origin", "strict-origin-when-cross-origin":
+		result.Score = 10
+	case "origin", "origin-when-cross-origin", "no-referrer-when-downgrade":
+		result.Score = 5
+		result.Warnings = append(result.Warnings, fmt.Sprintf("%s leaks the origin or URL to other sites or over HTTP", policy))
+	case "unsafe-url":
+		result.Errors = append(result.Errors, "unsafe-url sends the full URL to every site, even over HTTP")
+	default:
+		result.Errors = append(result.Errors, fmt.Sprintf("no recognized policy in %q", result.Value))
+	}
+	return result
+}
+
+// sensitiveFeatures should not be delegated to every origin.
+var sensitiveFeatures = []string{"camera", "microphone", "geolocation", "payment", "usb", "display-capture"}
+
+func auditPermissionsPolicy(headers http.Header) SecurityHeaderResult {
+	result := newHeaderResult(headers, "Permissions-Policy", 10)
+	if !result.Present {
+		if headers.Get("Feature-Policy") != "" {
+			result.Warnings = append(result.Warnings, "only the deprecated Feature-Policy is set")
+			result.Score = 3
+		} else {
+			result.Warnings = append(result.Warnings, "not set, so embedded frames may request powerful features")
+		}
+		return result
+	}
+
+	// The header is a structured-field dictionary: feature=(allowlist).
+	features := map[string]string{}
+	for _, member := range strings.Split(result.Value, ",") {
+		name, allowlist, ok := strings.Cut(strings.TrimSpace(member), "=")
+		name = strings.ToLower(strings.TrimSpace(name))
+		allowlist = strings.TrimSpace(allowlist)
+		if !ok || name == "" || allowlist == "" {
+			result.Errors = append(result.Errors, fmt.Sprintf("cannot parse %q", strings.TrimSpace(member)))
+			continue
+		}
+		features[name] = allowlist
+	}
+	if len(result.Errors) > 0 {
+		return result
+	}
+
+	result.Score = 10
+	for _, feature := range sensitiveFeatures {
+		if allowlist, ok := features[feature]; ok && (allowlist == "*" || strings.Contains(allowlist, "*")) {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("%s is allowed for every origin", feature))
+			result.Score = 7
+		}
+	}
+	return result
+}
+
+// auditKeyword grades a header that takes one keyword, scored by values.
+func auditKeyword(headers http.Header, name string, maxScore int, values map[string]int) SecurityHeaderResult {
+	result := newHeaderResult(headers, name, maxScore)
+	if !result.Present {
+		result.Warnings = append(result.Warnings, "not set")
+		return result
+	}
+
+	// Reporting endpoints may follow the keyword, e.g. same-origin; report-to="x".
+	keyword, _, _ := strings.Cut(strings.ToLower(result.Value), ";")
+	keyword = strings.TrimSpace(keyword)
+	score, ok := values[keyword]
+	switch {
+	case !ok:
+		result.Errors = append(result.Errors, fmt.Sprintf("unknown value %q", result.Value))
+	case score < maxScore:
+		result.Warnings = append(result.Warnings, fmt.Sprintf("%s gives weaker isolation", keyword))
+	}
+	result.Score = score
+	return result
+}
+
+// letterGrade turns a score out of 100 into A to F.
+func letterGrade(score int) string {
+	switch {
+	case score >= 90:
+		return "A"
+	case score >= 75:
+		return "B"
+	case score >= 60:
+		return "C"
+	case score >= 40:
+		return "D"
+	}
+	return "F"
+}
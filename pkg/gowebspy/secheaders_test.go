@@ -0,0 +1,105 @@
+package gowebspy
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestAuditSecurityHeadersStrong(t *testing.T) {
+	headers := http.Header{}
+	headers.Set("Strict-Transport-Security", "max-age=63072000; includeSubDomains; preload")
+	headers.Set("Content-Security-Policy", "default-src 'self'; script-src 'self' 'nonce-abc' 'unsafe-inline'; object-src 'none'; base-uri 'none'; frame-ancestors 'none'")
+	headers.Set("X-Content-Type-Options", "nosniff")
+	headers.Set("Referrer-Policy", "no-referrer, strict-origin-when-cross-origin")
+	headers.Set("Permissions-Policy", "camera=(), microphone=(), geolocation=(self)")
+	headers.Set("Cross-Origin-Opener-Policy", "same-origin")
+	headers.Set("Cross-Origin-Embedder-Policy", "require-corp")
+	headers.Set("Cross-Origin-Resource-Policy", "same-origin")
+
+	report := AuditSecurityHeaders(headers, true)
+	if report.Score != 100 || report.Grade != "A" {
+		for _, result := range report.Headers {
+			t.Logf("%s: %d/%d %v %v", result.Name, result.Score, result.MaxScore, result.Errors, result.Warnings)
+		}
+		t.Fatalf("Score = %d (%s), want 100 (A)", report.Score, report.Grade)
+	}
+	if !report.HSTS.PreloadEligible || report.HSTS.MaxAge != 63072000 {
+		t.Errorf("Unexpected HSTS policy %+v", report.HSTS)
+	}
+	if frame := report.Header("X-Frame-Options"); frame.Present || frame.Note == "" {
+		t.Errorf("Expected X-Frame-Options to be covered by frame-ancestors, got %+v", frame)
+	}
+	if sources, _ := report.CSP.Sources("img-src"); len(sources) != 1 || sources[0] != "'self'" {
+		t.Errorf("Expected img-src to fall back to default-src, got %v", sources)
+	}
+}
+
+func TestAuditSecurityHeadersMissing(t *testing.T) {
+	report := AuditSecurityHeaders(http.Header{}, true)
+	if report.Score != 5 || report.Grade != "F" {
+		t.Errorf("Score = %d (%s), want 5 (F) from the default Referrer-Policy", report.Score, report.Grade)
+	}
+	if report.HSTS != nil || report.CSP != nil {
+		t.Error("Expected no parsed policies")
+	}
+	if hsts := report.Header("Strict-Transport-Security"); hsts.Present || len(hsts.Errors) == 0 {
+		t.Errorf("Expected an error for the missing HSTS header, got %+v", hsts)
+	}
+}
+
+func TestAuditSecurityHeadersFindings(t *testing.T) {
+	tests := []struct {
+		header, value string
+		https         bool
+		want          string
+		wantScore     int
+	}{
+		{"Strict-Transport-Security", "max-age=0", true, "max-age=0", 0},
+		{"Strict-Transport-Security", "includeSubDomains", true, "max-age is missing", 0},
+		{"Strict-Transport-Security", "max-age=86400", true, "shorter than a year", 15},
+		{"Strict-Transport-Security", "max-age=31536000; preload", true, "not eligible", 20},
+		{"Strict-Transport-Security", "max-age=31536000; includeSubDomains", false, "plain HTTP", 0},
+		{"Content-Security-Policy", "script-src 'self' 'unsafe-inline'; object-src 'none'; base-uri 'self'; frame-ancestors 'self'", true, "'unsafe-inline'", 5},
+		{"Content-Security-Policy", "default-src *; object-src 'none'; base-uri 'self'; frame-ancestors 'self'", true, "any script from *", 5},
+		{"Content-Security-Policy", "img-src 'self'", true, "neither script-src nor default-src", 5},
+		{"Content-Security-Policy", "default-src 'self'", true, "object-src", 16},
+		{"X-Frame-Options", "ALLOW-FROM https://example.com", true, "ALLOW-FROM", 5},
+		{"X-Frame-Options", "ALLOWALL", true, "invalid value", 0},
+		{"X-Content-Type-Options", "sniff", true, "nosniff", 0},
+		{"Referrer-Policy", "unsafe-url", true, "unsafe-url", 0},
+		{"Referrer-Policy", "no-referrer-when-downgrade", true, "leaks", 5},
+		{"Permissions-Policy", "camera=*", true, "camera is allowed for every origin", 7},
+		{"Permissions-Policy", "geolocation", true, "cannot parse", 0},
+		{"Cross-Origin-Opener-Policy", "same-origin-allow-popups", true, "weaker isolation", 2},
+		{"Cross-Origin-Resource-Policy", "everyone", true, "unknown value", 0},
+	}
+
+	for _, test := range tests {
+		headers := http.Header{}
+		headers.Set(test.header, test.value)
+		result := AuditSecurityHeaders(headers, test.https).Header(test.header)
+
+		findings := strings.Join(append(append([]string{}, result.Errors...), result.Warnings...), "; ")
+		if !strings.Contains(findings, test.want) {
+			t.Errorf("%s: %s: findings %q don't mention %q", test.header, test.value, findings, test.want)
+		}
+		if result.Score != test.wantScore {
+			t.Errorf("%s: %s: score = %d, want %d", test.header, test.value, result.Score, test.wantScore)
+		}
+	}
+}
+
+func TestAuditSecurityHeadersReportOnlyCSP(t *testing.T) {
+	headers := http.Header{}
+	headers.Set("Content-Security-Policy-Report-Only", "default-src 'self'; frame-ancestors 'none'")
+
+	report := AuditSecurityHeaders(headers, true)
+	csp := report.Header("Content-Security-Policy")
+	if !report.CSP.ReportOnly || csp.Score != 0 {
+		t.Errorf("Expected an unenforced policy scoring 0, got %+v", csp)
+	}
+	if frame := report.Header("X-Frame-Options"); frame.Score != 0 {
+		t.Errorf("Expected a report-only frame-ancestors not to count, got %+v", frame)
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !unix && !windows
+
+package gowebspy
+
+import "errors"
+
+func setSocketTTL(fd uintptr, ipv6 bool, ttl int) error {
+	return errors.New("setting the TTL is not supported on this platform")
+}
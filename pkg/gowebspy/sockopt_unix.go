@@ -0,0 +1,12 @@
+//go:build unix
+
+package gowebspy
+
+import "syscall"
+
+func setSocketTTL(fd uintptr, ipv6 bool, ttl int) error {
+	if ipv6 {
+		return syscall.SetsockoptInt(int(fd), syscall.IPPROTO_IPV6, syscall.IPV6_UNICAST_HOPS, ttl)
+	}
+	return syscall.SetsockoptInt(int(fd), syscall.IPPROTO_IP, syscall.IP_TTL, ttl)
+}
@@ -0,0 +1,12 @@
+//go:build windows
+
+package gowebspy
+
+import "syscall"
+
+func setSocketTTL(fd uintptr, ipv6 bool, ttl int) error {
+	if ipv6 {
+		return syscall.SetsockoptInt(syscall.Handle(fd), syscall.IPPROTO_IPV6, syscall.IPV6_UNICAST_HOPS, ttl)
+	}
+	return syscall.SetsockoptInt(syscall.Handle(fd), syscall.IPPROTO_IP, syscall.IP_TTL, ttl)
+}
@@ -0,0 +1,311 @@
+package gowebspy
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// StartTLSProtocol names the plaintext protocol that is upgraded to TLS
+// before the handshake. The empty value means the port speaks TLS directly.
+type StartTLSProtocol string
+
+const (
+	StartTLSNone     StartTLSProtocol = ""
+	StartTLSSMTP     StartTLSProtocol = "smtp"
+	StartTLSIMAP     StartTLSProtocol = "imap"
+	StartTLSPOP3     StartTLSProtocol = "pop3"
+	StartTLSFTP      StartTLSProtocol = "ftp"
+	StartTLSLDAP     StartTLSProtocol = "ldap"
+	StartTLSPostgres StartTLSProtocol = "postgres"
+)
+
+var startTLSPorts = map[StartTLSProtocol]int{
+	StartTLSSMTP:     25,
+	StartTLSIMAP:     143,
+	StartTLSPOP3:     110,
+	StartTLSFTP:      21,
+	StartTLSLDAP:     389,
+	StartTLSPostgres: 5432,
+}
+
+// ParseStartTLSProtocol accepts the protocol names used by the CLI, plus
+// "postgresql" and "none".
+func ParseStartTLSProtocol(name string) (StartTLSProtocol, error) {
+	switch strings.ToLower(name) {
+	case "", "none":
+		return StartTLSNone, nil
+	case "postgresql":
+		return StartTLSPostgres, nil
+	}
+	protocol := StartTLSProtocol(strings.ToLower(name))
+	if _, ok := startTLSPorts[protocol]; !ok {
+		return StartTLSNone, fmt.Errorf("unknown STARTTLS protocol %q (want smtp, imap, pop3, ftp, ldap or postgres)", name)
+	}
+	return protocol, nil
+}
+
+// DefaultPort returns the standard plaintext port for the protocol, or 443
+// for StartTLSNone.
+func (p StartTLSProtocol) DefaultPort() int {
+	if port, ok := startTLSPorts[p]; ok {
+		return port
+	}
+	return 443
+}
+
+// GetTLSInfo fetches and verifies the certificate served on host:port,
+// negotiating STARTTLS first when starttls is set. Only the SSL related
+// fields of opts are used.
+func GetTLSInfo(ctx context.Context, host string, port int, starttls StartTLSProtocol, opts Options) (*SSLInfo, error) {
+	return getSSLInfo(ctx, host, port, starttls, opts.withDefaults())
+}
+
+// dialStartTLS connects to addr and, for a STARTTLS protocol, runs the
+// plaintext exchange that asks the server to switch to TLS. The returned
+// connection is ready for a ClientHello.
+func dialStartTLS(ctx context.Context, dialer *net.Dialer, addr string, starttls StartTLSProtocol) (net.Conn, error) {
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	if starttls == StartTLSNone {
+		return conn, nil
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	if err := upgradeStartTLS(conn, starttls); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("%s STARTTLS failed: %w", starttls, err)
+	}
+	conn.SetDeadline(time.Time{})
+	return conn, nil
+}
+
+func upgradeStartTLS(conn net.Conn, starttls StartTLSProtocol) error {
+	// The server must not send anything after agreeing to upgrade, so a
+	// buffered reader cannot swallow handshake bytes.
+	reader := bufio.NewReader(conn)
+
+	switch starttls {
+	case StartTLSSMTP:
+		if _, err := expectReply(reader, "220"); err != nil {
+			return err
+		}
+		if _, err := io.WriteString(conn, "EHLO gowebspy\r\n"); err != nil {
+			return err
+		}
+		capabilities, err := expectReply(reader, "250")
+		if err != nil {
+			return err
+		}
+		if !strings.Contains(strings.ToUpper(capabilities), "STARTTLS") {
+			return fmt.Errorf("server does not advertise STARTTLS")
+		}
+		return command(conn, reader, "STARTTLS\r\n", "220")
+	case StartTLSIMAP:
+		if _, err := expectReply(reader, "* OK"); err != nil {
+			return err
+		}
+		return command(conn, reader, "a001 STARTTLS\r\n", "a001 OK")
+	case StartTLSPOP3:
+		if _, err := expectReply(reader, "+OK"); err != nil {
+			return err
+		}
+		return command(conn, reader, "STLS\r\n", "+OK")
+	case StartTLSFTP:
+		if _, err := expectReply(reader, "220"); err != nil {
+			return err
+		}
+		return command(conn, reader, "AUTH TLS\r\n", "234")
+	case StartTLSLDAP:
+		return ldapStartTLS(conn, reader)
+	case StartTLSPostgres:
+		return postgresStartTLS(conn)
+	}
+	return fmt.Errorf("unsupported STARTTLS protocol %q", starttls)
+}
+
+func command(conn net.Conn, reader *bufio.Reader, line, expected string) error {
+	if _, err := io.WriteString(conn, line); err != nil {
+		return err
+	}
+	_, err := expectReply(reader, expected)
+	return err
+}
+
+// expectReply reads one reply, following SMTP/FTP "250-" continuation lines
+// and skipping IMAP untagged "* " lines when a tagged reply is expected, and
+// checks it starts with prefix. It returns the full reply text.
+func expectReply(reader *bufio.Reader, prefix string) (string, error) {
+	var reply strings.Builder
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return reply.String(), err
+		}
+		reply.WriteString(line)
+		line = strings.TrimRight(line, "\r\n")
+
+		if len(line) >= 4 && line[3] == '-' && isDigits(line[:3]) {
+			continue
+		}
+		if strings.HasPrefix(line, "* ") && !strings.HasPrefix(prefix, "*") {
+			continue
+		}
+		if !strings.HasPrefix(line, prefix) {
+			return reply.String(), fmt.Errorf("unexpected reply %q", sanitizeBanner([]byte(line)))
+		}
+		return reply.String(), nil
+	}
+}
+
+func isDigits(s string) bool {
+	_, err := strconv.Atoi(s)
+	return err == nil
+}
+
+// ldapStartTLS sends the StartTLS extended operation (RFC 4511 section 4.14)
+// and checks the result code of the extended response.
+func ldapStartTLS(conn net.Conn, reader *bufio.Reader) error {
+	const oid = "1.3.6.1.4.1.1466.20037"
+
+	request := []byte{0x77, byte(2 + len(oid)), 0x80, byte(len(oid))}
+	request = append(request, oid...)
+	message := append([]byte{0x02, 0x01, 0x01}, request...)
+	message = append([]byte{0x30, byte(len(message))}, message...)
+	if _, err := conn.Write(message); err != nil {
+		return err
+	}
+
+	response, err := readBERElement(reader)
+	if err != nil {
+		return err
+	}
+
+	// LDAPMessage ::= SEQUENCE { messageID INTEGER, extendedResp [APPLICATION 24] { resultCode ENUMERATED, ... } }
+	body, ok := berChildren(response)
+	if !ok || len(body) < 2 || body[1][0] != 0x78 {
+		return fmt.Errorf("unexpected LDAP response")
+	}
+	fields, ok := berChildren(body[1])
+	if !ok || len(fields) == 0 || fields[0][0] != 0x0a || len(fields[0]) < 3 {
+		return fmt.Errorf("malformed LDAP extended response")
+	}
+	if code := fields[0][len(fields[0])-1]; code != 0 {
+		return fmt.Errorf("LDAP server refused StartTLS (result code %d)", code)
+	}
+	return nil
+}
+
+// maxBERElementSize bounds the LDAP response read by readBERElement. An
+// extended response is a few dozen bytes, so anything near this is not one.
+const maxBERElementSize = 64 << 10
+
+// readBERElement reads one complete tag-length-value element.
+func readBERElement(reader *bufio.Reader) ([]byte, error) {
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		return nil, err
+	}
+
+	// The length is built as a uint64 so it can't overflow int on 32-bit
+	// platforms.
+	length := uint64(header[1])
+	if length&0x80 != 0 {
+		n := int(length & 0x7f)
+		if n == 0 || n > 4 {
+			return nil, fmt.Errorf("unsupported BER length")
+		}
+		lengthBytes := make([]byte, n)
+		if _, err := io.ReadFull(reader, lengthBytes); err != nil {
+			return nil, err
+		}
+		header = append(header, lengthBytes...)
+		length = 0
+		for _, b := range lengthBytes {
+			length = length<<8 | uint64(b)
+		}
+	}
+	if length > maxBERElementSize {
+		return nil, fmt.Errorf("BER element of %d bytes is too large", length)
+	}
+
+	value := make([]byte, length)
+	if _, err := io.ReadFull(reader, value); err != nil {
+		return nil, err
+	}
+	return append(header, value...), nil
+}
+
+// berChildren splits the value of a constructed element into its children,
+// each returned with its own tag and length.
+func berChildren(element []byte) ([][]byte, bool) {
+	_, value, ok := berSplit(element)
+	if !ok {
+		return nil, false
+	}
+
+	var children [][]byte
+	for len(value) > 0 {
+		headerLen, child, ok := berSplit(value)
+		if !ok {
+			return nil, false
+		}
+		size := headerLen + len(child)
+		children = append(children, value[:size])
+		value = value[size:]
+	}
+	return children, true
+}
+
+func berSplit(data []byte) (int, []byte, bool) {
+	if len(data) < 2 {
+		return 0, nil, false
+	}
+	headerLen, length := 2, uint64(data[1])
+	if length&0x80 != 0 {
+		n := int(length & 0x7f)
+		if n == 0 || n > 4 || len(data) < 2+n {
+			return 0, nil, false
+		}
+		length = 0
+		for _, b := range data[2 : 2+n] {
+			length = length<<8 | uint64(b)
+		}
+		headerLen += n
+	}
+	// Compared as uint64, a hostile length can't wrap around and pass.
+	if length > uint64(len(data)-headerLen) {
+		return 0, nil, false
+	}
+	return headerLen, data[headerLen : headerLen+int(length)], true
+}
+
+// postgresStartTLS sends an SSLRequest; the server answers 'S' and then
+// expects a ClientHello.
+func postgresStartTLS(conn net.Conn) error {
+	request := make([]byte, 8)
+	binary.BigEndian.PutUint32(request[0:4], 8)
+	binary.BigEndian.PutUint32(request[4:8], 80877103)
+	if _, err := conn.Write(request); err != nil {
+		return err
+	}
+
+	reply := make([]byte, 1)
+	if _, err := io.ReadFull(conn, reply); err != nil {
+		return err
+	}
+	if reply[0] != 'S' {
+		return fmt.Errorf("server does not support SSL")
+	}
+	return nil
+}
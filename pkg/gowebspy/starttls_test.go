@@ -0,0 +1,185 @@
+package gowebspy
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// startTLSServer emulates the plaintext part of protocol, then hands the
+// connection to a TLS server using cert.
+func startTLSServer(t *testing.T, protocol StartTLSProtocol, cert tls.Certificate) int {
+	config := &tls.Config{Certificates: []tls.Certificate{cert}}
+
+	return serveOnce(t, func(conn net.Conn) {
+		reader := bufio.NewReader(conn)
+		readLine := func() string {
+			line, _ := reader.ReadString('\n')
+			return strings.TrimSpace(line)
+		}
+
+		switch protocol {
+		case StartTLSSMTP:
+			io.WriteString(conn, "220-mail.example.test ESMTP\r\n220 ready\r\n")
+			if !strings.HasPrefix(readLine(), "EHLO") {
+				return
+			}
+			io.WriteString(conn, "250-mail.example.test\r\n250-PIPELINING\r\n250 STARTTLS\r\n")
+			if readLine() != "STARTTLS" {
+				return
+			}
+			io.WriteString(conn, "220 go ahead\r\n")
+		case StartTLSIMAP:
+			io.WriteString(conn, "* OK IMAP4rev1 ready\r\n")
+			if readLine() != "a001 STARTTLS" {
+				return
+			}
+			io.WriteString(conn, "a001 OK Begin TLS negotiation now\r\n")
+		case StartTLSPOP3:
+			io.WriteString(conn, "+OK POP3 ready\r\n")
+			if readLine() != "STLS" {
+				return
+			}
+			io.WriteString(conn, "+OK Begin TLS\r\n")
+		case StartTLSFTP:
+			io.WriteString(conn, "220 FTP ready\r\n")
+			if readLine() != "AUTH TLS" {
+				return
+			}
+			io.WriteString(conn, "234 AUTH TLS successful\r\n")
+		case StartTLSLDAP:
+			if _, err := readBERElement(reader); err != nil {
+				return
+			}
+			// messageID 1, extendedResp { resultCode success, matchedDN "", diagnosticMessage "" }
+			conn.Write([]byte{0x30, 0x0c, 0x02, 0x01, 0x01, 0x78, 0x07, 0x0a, 0x01, 0x00, 0x04, 0x00, 0x04, 0x00})
+		case StartTLSPostgres:
+			request := make([]byte, 8)
+			if _, err := io.ReadFull(reader, request); err != nil {
+				return
+			}
+			conn.Write([]byte("S"))
+		}
+
+		tls.Server(conn, config).Handshake()
+	})
+}
+
+func TestGetTLSInfoStartTLS(t *testing.T) {
+	cert := ecdsaServerCertificate(t)
+
+	for _, protocol := range []StartTLSProtocol{StartTLSSMTP, StartTLSIMAP, StartTLSPOP3, StartTLSFTP, StartTLSLDAP, StartTLSPostgres} {
+		t.Run(string(protocol), func(t *testing.T) {
+			port := startTLSServer(t, protocol, cert)
+
+			info, err := GetTLSInfo(context.Background(), "127.0.0.1", port, protocol, Options{SSLTimeout: 2 * time.Second})
+			if err != nil {
+				t.Fatalf("GetTLSInfo failed: %v", err)
+			}
+			if info.CommonName != "localhost" || len(info.Chain) != 1 {
+				t.Errorf("Unexpected certificate: CN=%q chain=%d", info.CommonName, len(info.Chain))
+			}
+			if info.Port != port || info.StartTLS != protocol {
+				t.Errorf("Port = %d, StartTLS = %q", info.Port, info.StartTLS)
+			}
+		})
+	}
+}
+
+func TestGetTLSInfoDirect(t *testing.T) {
+	port := tlsServer(t, &tls.Config{Certificates: []tls.Certificate{ecdsaServerCertificate(t)}})
+
+	info, err := GetTLSInfo(context.Background(), "127.0.0.1", port, StartTLSNone, Options{})
+	if err != nil {
+		t.Fatalf("GetTLSInfo failed: %v", err)
+	}
+	if info.CommonName != "localhost" {
+		t.Errorf("CommonName = %q, want localhost", info.CommonName)
+	}
+	if info.Valid || info.Verification.Reason != VerifyUntrustedRoot {
+		t.Errorf("Self-signed certificate: Valid = %v, Reason = %q", info.Valid, info.Verification.Reason)
+	}
+}
+
+func TestStartTLSNotAdvertised(t *testing.T) {
+	port := serveOnce(t, func(conn net.Conn) {
+		io.WriteString(conn, "220 ready\r\n")
+		bufio.NewReader(conn).ReadString('\n')
+		io.WriteString(conn, "250-mail.example.test\r\n250 SIZE 10240000\r\n")
+		io.Copy(io.Discard, conn)
+	})
+
+	_, err := GetTLSInfo(context.Background(), "127.0.0.1", port, StartTLSSMTP, Options{SSLTimeout: time.Second})
+	if err == nil || !strings.Contains(err.Error(), "does not advertise STARTTLS") {
+		t.Errorf("Expected a STARTTLS error, got %v", err)
+	}
+}
+
+func TestAuditTLSStartTLS(t *testing.T) {
+	port := startTLSServer(t, StartTLSSMTP, ecdsaServerCertificate(t))
+
+	audit, err := AuditTLS(context.Background(), "127.0.0.1", port, TLSAuditOptions{Timeout: time.Second, StartTLS: StartTLSSMTP})
+	if err != nil {
+		t.Fatalf("AuditTLS failed: %v", err)
+	}
+	if !audit.Supports(tls.VersionTLS13) {
+		t.Errorf("Expected TLS 1.3 over STARTTLS, got %+v", audit.Versions)
+	}
+}
+
+func TestReadBERElement(t *testing.T) {
+	element, err := readBERElement(bufio.NewReader(strings.NewReader("\x30\x82\x00\x03abcrest")))
+	if err != nil || string(element) != "\x30\x82\x00\x03abc" {
+		t.Errorf("readBERElement = %q, %v", element, err)
+	}
+
+	// A 4 GiB length must be refused before anything is allocated.
+	_, err = readBERElement(bufio.NewReader(strings.NewReader("\x30\x84\xff\xff\xff\xff")))
+	if err == nil || !strings.Contains(err.Error(), "too large") {
+		t.Errorf("Expected an error for a huge length, got %v", err)
+	}
+}
+
+func TestBERChildrenHugeLength(t *testing.T) {
+	// The child claims 0x80000000 bytes, which is negative as a 32-bit int.
+	element := []byte("\x30\x06\x04\x84\x80\x00\x00\x00")
+	if _, ok := berChildren(element); ok {
+		t.Error("Expected a child longer than its parent to be rejected")
+	}
+	if children, ok := berChildren([]byte("\x30\x05\x02\x01\x01\x0a\x00")); !ok || len(children) != 2 {
+		t.Errorf("berChildren = %q, %v, want two children", children, ok)
+	}
+}
+
+func TestParseStartTLSProtocol(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected StartTLSProtocol
+		port     int
+		wantErr  bool
+	}{
+		{"", StartTLSNone, 443, false},
+		{"SMTP", StartTLSSMTP, 25, false},
+		{"postgresql", StartTLSPostgres, 5432, false},
+		{"ldap", StartTLSLDAP, 389, false},
+		{"xmpp", StartTLSNone, 0, true},
+	}
+
+	for _, test := range tests {
+		protocol, err := ParseStartTLSProtocol(test.name)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("ParseStartTLSProtocol(%q) expected an error", test.name)
+			}
+			continue
+		}
+		if err != nil || protocol != test.expected || protocol.DefaultPort() != test.port {
+			t.Errorf("ParseStartTLSProtocol(%q) = %q (port %d), %v", test.name, protocol, protocol.DefaultPort(), err)
+		}
+	}
+}
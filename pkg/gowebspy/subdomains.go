@@ -0,0 +1,321 @@
+package gowebspy
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+const (
+	defaultSubdomainConcurrency = 50
+	defaultZoneTransferTimeout  = 10 * time.Second
+	// wildcardProbes is how many random names are looked up to detect a
+	// wildcard record.
+	wildcardProbes = 3
+)
+
+const (
+	SubdomainSourceWordlist = "wordlist"
+	SubdomainSourceAXFR     = "axfr"
+)
+
+// DefaultSubdomainWords is a short list of common host names, used when no
+// wordlist is given.
+var DefaultSubdomainWords = []string{
+	"www", "mail", "smtp", "imap", "pop", "webmail", "mx", "ns1", "ns2", "dns",
+	"api", "app", "admin", "portal", "dev", "test", "staging", "stage", "qa", "uat",
+	"beta", "demo", "cdn", "static", "assets", "img", "media", "files", "download", "docs",
+	"blog", "shop", "store", "status", "support", "help", "auth", "login", "sso", "id",
+	"vpn", "remote", "gateway", "git", "gitlab", "jenkins", "ci", "grafana", "monitor", "internal",
+}
+
+type SubdomainOptions struct {
+	// Words are the labels to try under the domain. Defaults to
+	// DefaultSubdomainWords.
+	Words []string
+	// DNS is used for the lookups and to find the name servers.
+	DNS DNSQueryOptions
+	// Concurrency is how many names are looked up at once.
+	Concurrency int
+	// SkipZoneTransfer disables the AXFR attempts.
+	SkipZoneTransfer bool
+}
+
+func (o SubdomainOptions) withDefaults() SubdomainOptions {
+	if len(o.Words) == 0 {
+		o.Words = DefaultSubdomainWords
+	}
+	if o.Concurrency <= 0 {
+		o.Concurrency = defaultSubdomainConcurrency
+	}
+	return o
+}
+
+// SubdomainReport is the result of EnumerateSubdomains.
+type SubdomainReport struct {
+	Domain string
+	// Subdomains are sorted by name.
+	Subdomains []Subdomain
+	// Wildcard holds the addresses names that don't exist resolve to, when
+	// the zone has a wildcard record. Names resolving only to these are
+	// left out.
+	Wildcard      []string
+	ZoneTransfers []ZoneTransfer
+}
+
+type Subdomain struct {
+	Name      string
+	Addresses []string
+	// CNAME is the name the subdomain is an alias of, if it is one.
+	CNAME string
+	// Sources are SubdomainSourceWordlist and SubdomainSourceAXFR.
+	Sources []string
+}
+
+// ZoneTransfer is an AXFR attempt against one of the zone's name servers.
+type ZoneTransfer struct {
+	Server string
+	// Allowed is set when the server handed out the zone.
+	Allowed bool
+	Records int
+	Error   string
+}
+
+// Names returns the subdomain names, for passing to ScanBatch.
+func (r *SubdomainReport) Names() []string {
+	names := make([]string, len(r.Subdomains))
+	for i, subdomain := range r.Subdomains {
+		names[i] = subdomain.Name
+	}
+	return names
+}
+
+// EnumerateSubdomains finds subdomains of domain by looking up each word of
+// opts.Words under it and by asking each of the zone's name servers for a
+// zone transfer. Only use it on zones you are allowed to probe.
+//
+// The error is set when no lookups could be made at all.
+func EnumerateSubdomains(ctx context.Context, domain string, opts SubdomainOptions) (*SubdomainReport, error) {
+	opts = opts.withDefaults()
+
+	domain, err := NormalizeHost(domain)
+	if err != nil {
+		return nil, fmt.Errorf("subdomain enumeration failed: %w", err)
+	}
+
+	wildcard, err := detectWildcard(ctx, domain, opts.DNS)
+	if err != nil {
+		return nil, fmt.Errorf("subdomain enumeration of %s failed: %w", domain, err)
+	}
+
+	e := &subdomainEnumerator{report: &SubdomainReport{Domain: domain}, found: map[string]*Subdomain{}}
+	for address := range wildcard {
+		e.report.Wildcard = append(e.report.Wildcard, address)
+	}
+	sort.Strings(e.report.Wildcard)
+
+	if !opts.SkipZoneTransfer {
+		e.transferZone(ctx, domain, opts.DNS)
+	}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, opts.Concurrency)
+	for _, word := range opts.Words {
+		name := strings.ToLower(strings.Trim(strings.TrimSpace(word), ".")) + "." + domain
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			addresses, cname := resolveSubdomain(ctx, name, opts.DNS)
+			if len(addresses) == 0 || onlyWildcard(addresses, wildcard) {
+				return
+			}
+			e.add(name, SubdomainSourceWordlist, addresses, cname)
+		}(name)
+	}
+	wg.Wait()
+
+	for _, subdomain := range e.found {
+		sort.Strings(subdomain.Addresses)
+		e.report.Subdomains = append(e.report.Subdomains, *subdomain)
+	}
+	sort.Slice(e.report.Subdomains, func(i, j int) bool {
+		return e.report.Subdomains[i].Name < e.report.Subdomains[j].Name
+	})
+	return e.report, nil
+}
+
+type subdomainEnumerator struct {
+	mu     sync.Mutex
+	report *SubdomainReport
+	found  map[string]*Subdomain
+}
+
+func (e *subdomainEnumerator) add(name, source string, addresses []string, cname string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	subdomain := e.found[name]
+	if subdomain == nil {
+		subdomain = &Subdomain{Name: name}
+		e.found[name] = subdomain
+	}
+	for _, address := range addresses {
+		if !containsString(subdomain.Addresses, address) {
+			subdomain.Addresses = append(subdomain.Addresses, address)
+		}
+	}
+	if cname != "" {
+		subdomain.CNAME = cname
+	}
+	if !containsString(subdomain.Sources, source) {
+		subdomain.Sources = append(subdomain.Sources, source)
+	}
+}
+
+// transferZone tries AXFR against each name server of domain and adds the
+// host names in the zones it gets.
+func (e *subdomainEnumerator) transferZone(ctx context.Context, domain string, opts DNSQueryOptions) {
+	servers, err := AuthoritativeServers(ctx, domain, opts)
+	if err != nil {
+		e.report.ZoneTransfers = append(e.report.ZoneTransfers, ZoneTransfer{Error: err.Error()})
+		return
+	}
+
+	for _, server := range servers {
+		transfer := ZoneTransfer{Server: server}
+		records, err := zoneTransfer(ctx, domain, server, opts)
+		if err != nil {
+			transfer.Error = err.Error()
+			e.report.ZoneTransfers = append(e.report.ZoneTransfers, transfer)
+			continue
+		}
+		transfer.Allowed = true
+		transfer.Records = len(records)
+		e.report.ZoneTransfers = append(e.report.ZoneTransfers, transfer)
+
+		for _, rr := range records {
+			name := strings.ToLower(strings.TrimSuffix(rr.Header().Name, "."))
+			if name == domain || !strings.HasSuffix(name, "."+domain) || strings.HasPrefix(name, "*.") {
+				continue
+			}
+			switch record := rr.(type) {
+			case *dns.A:
+				e.add(name, SubdomainSourceAXFR, []string{record.A.String()}, "")
+			case *dns.AAAA:
+				e.add(name, SubdomainSourceAXFR, []string{record.AAAA.String()}, "")
+			case *dns.CNAME:
+				e.add(name, SubdomainSourceAXFR, nil, strings.TrimSuffix(record.Target, "."))
+			}
+		}
+	}
+}
+
+func zoneTransfer(ctx context.Context, domain, server string, opts DNSQueryOptions) ([]dns.RR, error) {
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = defaultZoneTransferTimeout
+	}
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < timeout {
+		timeout = time.Until(deadline)
+	}
+
+	msg := new(dns.Msg)
+	msg.SetAxfr(dns.Fqdn(domain))
+	transfer := &dns.Transfer{DialTimeout: timeout, ReadTimeout: timeout}
+	envelopes, err := transfer.In(msg, server)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []dns.RR
+	for envelope := range envelopes {
+		if envelope.Error != nil {
+			// Drain the channel so the transfer goroutine can finish.
+			for range envelopes {
+			}
+			return nil, envelope.Error
+		}
+		records = append(records, envelope.RR...)
+	}
+	if len(records) == 0 {
+		return nil, errors.New("empty zone transfer")
+	}
+	return records, nil
+}
+
+// resolveSubdomain returns the A and AAAA addresses of name and the target
+// of its CNAME, if any.
+func resolveSubdomain(ctx context.Context, name string, opts DNSQueryOptions) ([]string, string) {
+	var addresses []string
+	var cname string
+	for _, rrType := range []string{"A", "AAAA"} {
+		response, err := QueryDNS(ctx, name, rrType, opts)
+		if err != nil {
+			continue
+		}
+		if response.RCode == dns.RcodeToString[dns.RcodeNameError] {
+			break
+		}
+		addresses = append(addresses, response.Values(rrType)...)
+		if values := response.Values("CNAME"); len(values) > 0 && cname == "" {
+			cname = strings.TrimSuffix(values[0], ".")
+		}
+	}
+	return addresses, cname
+}
+
+// detectWildcard looks up random names under domain and returns the
+// addresses they resolve to, which are empty when there is no wildcard.
+func detectWildcard(ctx context.Context, domain string, opts DNSQueryOptions) (map[string]bool, error) {
+	wildcard := map[string]bool{}
+	for i := 0; i < wildcardProbes; i++ {
+		label := make([]byte, 8)
+		if _, err := rand.Read(label); err != nil {
+			return nil, err
+		}
+		name := "wc-" + hex.EncodeToString(label) + "." + domain
+		if i == 0 {
+			// Fail early when the resolver can't be reached at all.
+			if _, err := QueryDNS(ctx, name, "A", opts); err != nil {
+				return nil, err
+			}
+		}
+		addresses, _ := resolveSubdomain(ctx, name, opts)
+		for _, address := range addresses {
+			wildcard[address] = true
+		}
+	}
+	return wildcard, nil
+}
+
+func onlyWildcard(addresses []string, wildcard map[string]bool) bool {
+	if len(wildcard) == 0 {
+		return false
+	}
+	for _, address := range addresses {
+		if !wildcard[address] {
+			return false
+		}
+	}
+	return true
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,141 @@
+package gowebspy
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/miekg/dns"
+)
+
+// subdomainZone serves corp.test, which allows zone transfers, and
+// wild.test, which has a wildcard A record and refuses them. Both are their
+// own name servers at 127.0.0.1.
+func subdomainZone(w dns.ResponseWriter, req *dns.Msg) {
+	question := req.Question[0]
+	name := strings.ToLower(question.Name)
+
+	zone := map[string][]string{
+		"corp.test. NS":       {"corp.test. 3600 IN NS ns1.corp.test."},
+		"ns1.corp.test. A":    {"ns1.corp.test. 3600 IN A 127.0.0.1"},
+		"www.corp.test. A":    {"www.corp.test. 300 IN A 192.0.2.1"},
+		"api.corp.test. A":    {"api.corp.test. 300 IN A 192.0.2.2"},
+		"api.corp.test. AAAA": {"api.corp.test. 300 IN AAAA 2001:db8::2"},
+		"mail.corp.test. A":   {"mail.corp.test. 300 IN CNAME www.corp.test.", "www.corp.test. 300 IN A 192.0.2.1"},
+		"wild.test. NS":       {"wild.test. 3600 IN NS ns1.wild.test."},
+		"ns1.wild.test. A":    {"ns1.wild.test. 3600 IN A 127.0.0.1"},
+		"www.wild.test. A":    {"www.wild.test. 300 IN A 192.0.2.1"},
+	}
+
+	reply := new(dns.Msg)
+	reply.SetReply(req)
+
+	if question.Qtype == dns.TypeAXFR {
+		if name != "corp.test." {
+			reply.Rcode = dns.RcodeRefused
+			w.WriteMsg(reply)
+			return
+		}
+		soa, _ := dns.NewRR("corp.test. 3600 IN SOA ns1.corp.test. hostmaster.corp.test. 1 7200 900 1209600 300")
+		var records []dns.RR
+		for _, record := range []string{
+			"corp.test. 3600 IN NS ns1.corp.test.",
+			"www.corp.test. 300 IN A 192.0.2.1",
+			"secret.corp.test. 300 IN A 192.0.2.9",
+			"alias.corp.test. 300 IN CNAME www.corp.test.",
+		} {
+			rr, _ := dns.NewRR(record)
+			records = append(records, rr)
+		}
+		ch := make(chan *dns.Envelope)
+		transfer := new(dns.Transfer)
+		go func() {
+			ch <- &dns.Envelope{RR: append(append([]dns.RR{soa}, records...), soa)}
+			close(ch)
+		}()
+		transfer.Out(w, req, ch)
+		return
+	}
+
+	key := name + " " + dns.TypeToString[question.Qtype]
+	records, ok := zone[key]
+	if !ok && question.Qtype == dns.TypeA && strings.HasSuffix(name, ".wild.test.") {
+		records, ok = []string{question.Name + " 300 IN A 192.0.2.200"}, true
+	}
+	if !ok && !strings.HasSuffix(name, ".wild.test.") {
+		if _, exists := zone[name+" A"]; !exists {
+			reply.Rcode = dns.RcodeNameError
+		}
+	}
+	for _, record := range records {
+		rr, _ := dns.NewRR(record)
+		reply.Answer = append(reply.Answer, rr)
+	}
+	w.WriteMsg(reply)
+}
+
+func TestEnumerateSubdomains(t *testing.T) {
+	server := dnsServer(t, subdomainZone)
+	_, port, _ := net.SplitHostPort(server)
+
+	report, err := EnumerateSubdomains(context.Background(), "corp.test", SubdomainOptions{
+		Words: []string{"www", "api", "mail", "missing"},
+		DNS:   DNSQueryOptions{Servers: []string{server}, AuthoritativePort: port},
+	})
+	if err != nil {
+		t.Fatalf("EnumerateSubdomains failed: %v", err)
+	}
+	if len(report.Wildcard) != 0 {
+		t.Errorf("Unexpected wildcard addresses: %v", report.Wildcard)
+	}
+	if len(report.ZoneTransfers) != 1 || !report.ZoneTransfers[0].Allowed || report.ZoneTransfers[0].Records != 6 {
+		t.Errorf("Expected one allowed zone transfer, got %+v", report.ZoneTransfers)
+	}
+
+	want := []Subdomain{
+		{Name: "alias.corp.test", CNAME: "www.corp.test", Sources: []string{SubdomainSourceAXFR}},
+		{Name: "api.corp.test", Addresses: []string{"192.0.2.2", "2001:db8::2"}, Sources: []string{SubdomainSourceWordlist}},
+		{Name: "mail.corp.test", Addresses: []string{"192.0.2.1"}, CNAME: "www.corp.test", Sources: []string{SubdomainSourceWordlist}},
+		{Name: "secret.corp.test", Addresses: []string{"192.0.2.9"}, Sources: []string{SubdomainSourceAXFR}},
+		{Name: "www.corp.test", Addresses: []string{"192.0.2.1"}, Sources: []string{SubdomainSourceAXFR, SubdomainSourceWordlist}},
+	}
+	if !reflect.DeepEqual(report.Subdomains, want) {
+		t.Errorf("Subdomains = %+v\nwant %+v", report.Subdomains, want)
+	}
+	if names := report.Names(); len(names) != 5 || names[0] != "alias.corp.test" {
+		t.Errorf("Names = %v", names)
+	}
+}
+
+func TestEnumerateSubdomainsWildcard(t *testing.T) {
+	server := dnsServer(t, subdomainZone)
+	_, port, _ := net.SplitHostPort(server)
+
+	report, err := EnumerateSubdomains(context.Background(), "wild.test", SubdomainOptions{
+		Words: []string{"www", "anything", "else"},
+		DNS:   DNSQueryOptions{Servers: []string{server}, AuthoritativePort: port},
+	})
+	if err != nil {
+		t.Fatalf("EnumerateSubdomains failed: %v", err)
+	}
+	if len(report.Wildcard) != 1 || report.Wildcard[0] != "192.0.2.200" {
+		t.Errorf("Wildcard = %v, want [192.0.2.200]", report.Wildcard)
+	}
+	if len(report.Subdomains) != 1 || report.Subdomains[0].Name != "www.wild.test" {
+		t.Errorf("Expected only www to be found, got %+v", report.Subdomains)
+	}
+	if len(report.ZoneTransfers) != 1 || report.ZoneTransfers[0].Allowed || report.ZoneTransfers[0].Error == "" {
+		t.Errorf("Expected a refused zone transfer, got %+v", report.ZoneTransfers)
+	}
+}
+
+func TestEnumerateSubdomainsUnreachable(t *testing.T) {
+	_, err := EnumerateSubdomains(context.Background(), "corp.test", SubdomainOptions{
+		DNS: DNSQueryOptions{Servers: []string{"127.0.0.1:1"}},
+	})
+	if err == nil {
+		t.Error("Expected an error when the resolver is unreachable")
+	}
+}
@@ -0,0 +1,230 @@
+package gowebspy
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TimingPhase names one phase of an HTTPTiming, for FilterOptions.
+type TimingPhase string
+
+const (
+	TimingDNS      TimingPhase = "dns"
+	TimingConnect  TimingPhase = "connect"
+	TimingTLS      TimingPhase = "tls"
+	TimingTTFB     TimingPhase = "ttfb"
+	TimingTransfer TimingPhase = "transfer"
+	TimingTotal    TimingPhase = "total"
+)
+
+// TimingPhases lists every phase in the order they happen.
+var TimingPhases = []TimingPhase{TimingDNS, TimingConnect, TimingTLS, TimingTTFB, TimingTransfer, TimingTotal}
+
+// HTTPTiming breaks the main HTTP request down into its phases. Phases that
+// didn't happen are zero: DNS for an IP address, TLS for plain HTTP, and
+// DNS, connect and TLS when a kept-alive connection was reused.
+type HTTPTiming struct {
+	DNSLookup    time.Duration
+	TCPConnect   time.Duration
+	TLSHandshake time.Duration
+	// TimeToFirstByte is the wait between the request being sent and the
+	// first byte of the response, i.e. the server's processing time.
+	TimeToFirstByte time.Duration
+	// ContentTransfer is the time taken to read the body after its first
+	// byte arrived.
+	ContentTransfer time.Duration
+	// Total runs from the start of the request until the body was read.
+	Total time.Duration
+	// ReusedConnection is set when no new connection was made.
+	ReusedConnection bool
+	// BodySize is the number of body bytes read.
+	BodySize int64
+	// Truncated is set when reading stopped after maxTimedBody bytes, so
+	// ContentTransfer and Total don't cover the whole body.
+	Truncated bool
+}
+
+// Phase returns the duration of phase, and false if phase is unknown.
+func (t *HTTPTiming) Phase(phase TimingPhase) (time.Duration, bool) {
+	switch phase {
+	case TimingDNS:
+		return t.DNSLookup, true
+	case TimingConnect:
+		return t.TCPConnect, true
+	case TimingTLS:
+		return t.TLSHandshake, true
+	case TimingTTFB:
+		return t.TimeToFirstByte, true
+	case TimingTransfer:
+		return t.ContentTransfer, true
+	case TimingTotal:
+		return t.Total, true
+	}
+	return 0, false
+}
+
+// ParseTimingPhase accepts the phase names of TimingPhases in any case.
+func ParseTimingPhase(name string) (TimingPhase, error) {
+	phase := TimingPhase(strings.ToLower(strings.TrimSpace(name)))
+	if _, ok := (&HTTPTiming{}).Phase(phase); !ok {
+		return "", fmt.Errorf("unknown timing phase %q (want dns, connect, tls, ttfb, transfer or total)", name)
+	}
+	return phase, nil
+}
+
+// httpTimer records the httptrace events of one request. The hooks can be
+// called from the transport's goroutines, hence the mutex.
+type httpTimer struct {
+	mu sync.Mutex
+
+	start, dnsStart, dnsDone         time.Time
+	connectStart, connectDone        time.Time
+	tlsStart, tlsDone                time.Time
+	wroteRequest, firstByte, bodyEnd time.Time
+	reused                           bool
+}
+
+func newHTTPTimer() *httpTimer {
+	return &httpTimer{start: time.Now()}
+}
+
+// trace returns ctx with the hooks that fill in t.
+func (t *httpTimer) trace(ctx context.Context) context.Context {
+	// Happy Eyeballs may look up and dial more than once; keep the first
+	// start and the last finish.
+	first := func(at *time.Time) {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		if at.IsZero() {
+			*at = time.Now()
+		}
+	}
+	last := func(at *time.Time) {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		*at = time.Now()
+	}
+	return httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		DNSStart:     func(httptrace.DNSStartInfo) { first(&t.dnsStart) },
+		DNSDone:      func(httptrace.DNSDoneInfo) { last(&t.dnsDone) },
+		ConnectStart: func(string, string) { first(&t.connectStart) },
+		ConnectDone: func(_, _ string, err error) {
+			if err == nil {
+				last(&t.connectDone)
+			}
+		},
+		TLSHandshakeStart: func() { first(&t.tlsStart) },
+		TLSHandshakeDone:  func(tls.ConnectionState, error) { last(&t.tlsDone) },
+		GotConn: func(info httptrace.GotConnInfo) {
+			t.mu.Lock()
+			t.reused = info.Reused
+			t.mu.Unlock()
+		},
+		WroteRequest:         func(httptrace.WroteRequestInfo) { last(&t.wroteRequest) },
+		GotFirstResponseByte: func() { first(&t.firstByte) },
+	})
+}
+
+// done marks body as read and returns the timing.
+func (t *httpTimer) done(body *timedBody) *HTTPTiming {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.bodyEnd = time.Now()
+	size := body.n
+	if size > maxTimedBody {
+		size = maxTimedBody
+	}
+	return &HTTPTiming{
+		DNSLookup:        between(t.dnsStart, t.dnsDone),
+		TCPConnect:       between(t.connectStart, t.connectDone),
+		TLSHandshake:     between(t.tlsStart, t.tlsDone),
+		TimeToFirstByte:  between(t.wroteRequest, t.firstByte),
+		ContentTransfer:  between(t.firstByte, t.bodyEnd),
+		Total:            between(t.start, t.bodyEnd),
+		ReusedConnection: t.reused,
+		BodySize:         size,
+		Truncated:        body.n > maxTimedBody,
+	}
+}
+
+// between returns the time from start to end, or zero if either didn't
+// happen.
+func between(start, end time.Time) time.Duration {
+	if start.IsZero() || end.IsZero() || end.Before(start) {
+		return 0
+	}
+	return end.Sub(start)
+}
+
+// timedResponse is a response to a timed request whose body is left for the
+// caller to read.
+type timedResponse struct {
+	*http.Response
+	timer *httpTimer
+	body  *timedBody
+	// elapsed is how long the response headers took to arrive.
+	elapsed time.Duration
+}
+
+// timedGet requests target with every phase traced.
+func timedGet(ctx context.Context, client *http.Client, target string) (*timedResponse, error) {
+	timer := newHTTPTimer()
+	req, err := http.NewRequestWithContext(timer.trace(ctx), http.MethodGet, target, nil)
+	if err != nil {
+		return nil, err
+	}
+	startTime := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	return &timedResponse{
+		Response: resp,
+		timer:    timer,
+		body:     newTimedBody(resp.Body),
+		elapsed:  time.Since(startTime),
+	}, nil
+}
+
+// finish reads the rest of the body, up to maxTimedBody, closes it and
+// returns the timing.
+func (r *timedResponse) finish() (*HTTPTiming, error) {
+	defer r.Body.Close()
+	err := r.body.drain()
+	return r.timer.done(r.body), err
+}
+
+// maxTimedBody is how much of a response body is read to time its
+// transfer, so that a large download doesn't run until the HTTP timeout.
+const maxTimedBody int64 = 10 << 20
+
+// timedBody reads a response body up to maxTimedBody bytes, counting them.
+// One byte more is allowed through to tell when the body was cut short.
+type timedBody struct {
+	r io.Reader
+	n int64
+}
+
+func newTimedBody(body io.Reader) *timedBody {
+	return &timedBody{r: io.LimitReader(body, maxTimedBody+1)}
+}
+
+func (b *timedBody) Read(p []byte) (int, error) {
+	n, err := b.r.Read(p)
+	b.n += int64(n)
+	return n, err
+}
+
+// drain reads whatever is left of the body, up to the limit.
+func (b *timedBody) drain() error {
+	_, err := io.Copy(io.Discard, b)
+	return err
+}
@@ -0,0 +1,106 @@
+package gowebspy
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHTTPTiming(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(50 * time.Millisecond)
+		w.Write([]byte("first half,"))
+		w.(http.Flusher).Flush()
+		time.Sleep(30 * time.Millisecond)
+		w.Write([]byte("second half"))
+	}))
+	defer server.Close()
+
+	info, err := GetWebsiteInfoWithOptions(context.Background(), server.URL, Options{
+		SkipSSL:    true,
+		SkipWhois:  true,
+		HTTPClient: server.Client(),
+	})
+	if err != nil {
+		t.Fatalf("GetWebsiteInfoWithOptions failed: %v", err)
+	}
+
+	timing := info.Timing
+	if timing == nil {
+		t.Fatal("Expected Timing to be set")
+	}
+	if timing.DNSLookup != 0 {
+		t.Errorf("DNSLookup = %s, want 0 for an IP address", timing.DNSLookup)
+	}
+	if timing.TCPConnect <= 0 || timing.TLSHandshake <= 0 {
+		t.Errorf("Expected connect and TLS times, got %+v", timing)
+	}
+	if timing.TimeToFirstByte < 50*time.Millisecond {
+		t.Errorf("TimeToFirstByte = %s, want at least 50ms", timing.TimeToFirstByte)
+	}
+	if timing.ContentTransfer < 30*time.Millisecond {
+		t.Errorf("ContentTransfer = %s, want at least 30ms", timing.ContentTransfer)
+	}
+	sum := timing.DNSLookup + timing.TCPConnect + timing.TLSHandshake + timing.TimeToFirstByte + timing.ContentTransfer
+	if timing.Total < sum || timing.Total < info.ResponseTime {
+		t.Errorf("Total = %s, want at least the phases (%s) and ResponseTime (%s)", timing.Total, sum, info.ResponseTime)
+	}
+	if timing.BodySize != int64(len("first half,second half")) {
+		t.Errorf("BodySize = %d", timing.BodySize)
+	}
+	if timing.ReusedConnection || timing.Truncated {
+		t.Error("Expected a new connection and the whole body")
+	}
+}
+
+func TestHTTPTimingTruncated(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Write(make([]byte, maxTimedBody+4096))
+	}))
+	defer server.Close()
+
+	info, err := GetWebsiteInfoWithOptions(context.Background(), server.URL, Options{SkipWhois: true})
+	if err != nil {
+		t.Fatalf("GetWebsiteInfoWithOptions failed: %v", err)
+	}
+	if !info.Timing.Truncated || info.Timing.BodySize != maxTimedBody {
+		t.Errorf("Expected the body to be cut at %d bytes, got %d (truncated=%v)", maxTimedBody, info.Timing.BodySize, info.Timing.Truncated)
+	}
+}
+
+func TestFilterPhaseTime(t *testing.T) {
+	info := &WebsiteInfo{
+		StatusCode: 200,
+		Timing:     &HTTPTiming{TLSHandshake: 40 * time.Millisecond, TimeToFirstByte: 300 * time.Millisecond},
+	}
+
+	opts := NewFilterOptions()
+	opts.MaxPhaseTime = map[TimingPhase]time.Duration{TimingTLS: 100 * time.Millisecond}
+	if !ApplyFilter(info, opts) {
+		t.Error("Expected a 40ms handshake to pass tls<100ms")
+	}
+
+	opts.MaxPhaseTime[TimingTTFB] = 200 * time.Millisecond
+	if ApplyFilter(info, opts) {
+		t.Error("Expected a 300ms wait to fail ttfb<200ms")
+	}
+
+	opts = NewFilterOptions()
+	opts.MinPhaseTime = map[TimingPhase]time.Duration{TimingTTFB: 200 * time.Millisecond}
+	if !ApplyFilter(info, opts) {
+		t.Error("Expected a 300ms wait to pass ttfb>200ms")
+	}
+	if ApplyFilter(&WebsiteInfo{StatusCode: 200}, opts) {
+		t.Error("Expected a site without timing to fail a phase filter")
+	}
+
+	if _, err := ParseTimingPhase("TTFB"); err != nil {
+		t.Errorf("ParseTimingPhase(TTFB) failed: %v", err)
+	}
+	if _, err := ParseTimingPhase("render"); err == nil {
+		t.Error("Expected an error for an unknown phase")
+	}
+}
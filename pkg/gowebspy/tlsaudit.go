@@ -0,0 +1,613 @@
+package gowebspy
+
+import (
+	"context"
+	"crypto/ecdh"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const defaultTLSAuditTimeout = 5 * time.Second
+
+// TLSWeakness is a configuration problem found by AuditTLS.
+type TLSWeakness string
+
+const (
+	WeaknessLegacyProtocol   TLSWeakness = "legacy-protocol"
+	WeaknessRC4              TLSWeakness = "rc4"
+	Weakness3DES             TLSWeakness = "3des"
+	WeaknessInsecureCipher   TLSWeakness = "insecure-cipher"
+	WeaknessCBCOnly          TLSWeakness = "cbc-only"
+	WeaknessNoForwardSecrecy TLSWeakness = "no-forward-secrecy"
+)
+
+type TLSAuditOptions struct {
+	// Timeout applies to each handshake attempt.
+	Timeout time.Duration
+	// ServerName is sent as SNI. It defaults to host unless host is an IP.
+	ServerName string
+	// StartTLS upgrades a plaintext protocol before every probe.
+	StartTLS StartTLSProtocol
+	Dialer   *net.Dialer
+}
+
+func (o TLSAuditOptions) withDefaults() TLSAuditOptions {
+	if o.Timeout <= 0 {
+		o.Timeout = defaultTLSAuditTimeout
+	}
+	if o.Dialer == nil {
+		o.Dialer = &net.Dialer{}
+	}
+	return o
+}
+
+type CipherSuiteInfo struct {
+	ID             uint16
+	Name           string
+	ForwardSecrecy bool
+	// Weak is set for RC4, 3DES, DES, NULL, EXPORT and anonymous suites.
+	Weak bool
+}
+
+type TLSVersionSupport struct {
+	Version   uint16
+	Name      string
+	Supported bool
+	// Ciphers lists the accepted suites in the order the server picked them
+	// when offered everything that was left.
+	Ciphers []CipherSuiteInfo
+	// ServerPreference is true when the server picks by its own order rather
+	// than the client's. It is only meaningful with two or more ciphers.
+	ServerPreference bool
+	Curves           []string
+}
+
+// TLSAudit describes how a server negotiates TLS.
+type TLSAudit struct {
+	Host       string
+	Port       int
+	Versions   []TLSVersionSupport
+	ALPN       []string
+	Weaknesses []TLSWeakness
+}
+
+// Supports reports whether the server accepted the given TLS version.
+func (a *TLSAudit) Supports(version uint16) bool {
+	for _, v := range a.Versions {
+		if v.Version == version {
+			return v.Supported
+		}
+	}
+	return false
+}
+
+type cipherSuite struct {
+	id   uint16
+	name string
+}
+
+var tls13CipherSuites = []cipherSuite{
+	{0x1301, "TLS_AES_128_GCM_SHA256"},
+	{0x1302, "TLS_AES_256_GCM_SHA384"},
+	{0x1303, "TLS_CHACHA20_POLY1305_SHA256"},
+	{0x1304, "TLS_AES_128_CCM_SHA256"},
+	{0x1305, "TLS_AES_128_CCM_8_SHA256"},
+}
+
+// legacyCipherSuites covers what is still found in the wild for TLS 1.0-1.2,
+// including suites crypto/tls does not implement, since the probes are
+// hand-built ClientHellos and never complete a handshake.
+var legacyCipherSuites = []cipherSuite{
+	{0xc02b, "TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256"},
+	{0xc02c, "TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384"},
+	{0xc02f, "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"},
+	{0xc030, "TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384"},
+	{0xcca9, "TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256"},
+	{0xcca8, "TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256"},
+	{0xccaa, "TLS_DHE_RSA_WITH_CHACHA20_POLY1305_SHA256"},
+	{0x009e, "TLS_DHE_RSA_WITH_AES_128_GCM_SHA256"},
+	{0x009f, "TLS_DHE_RSA_WITH_AES_256_GCM_SHA384"},
+	{0xc023, "TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA256"},
+	{0xc024, "TLS_ECDHE_ECDSA_WITH_AES_256_CBC_SHA384"},
+	{0xc027, "TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA256"},
+	{0xc028, "TLS_ECDHE_RSA_WITH_AES_256_CBC_SHA384"},
+	{0xc009, "TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA"},
+	{0xc00a, "TLS_ECDHE_ECDSA_WITH_AES_256_CBC_SHA"},
+	{0xc013, "TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA"},
+	{0xc014, "TLS_ECDHE_RSA_WITH_AES_256_CBC_SHA"},
+	{0x0067, "TLS_DHE_RSA_WITH_AES_128_CBC_SHA256"},
+	{0x006b, "TLS_DHE_RSA_WITH_AES_256_CBC_SHA256"},
+	{0x0033, "TLS_DHE_RSA_WITH_AES_128_CBC_SHA"},
+	{0x0039, "TLS_DHE_RSA_WITH_AES_256_CBC_SHA"},
+	{0x009c, "TLS_RSA_WITH_AES_128_GCM_SHA256"},
+	{0x009d, "TLS_RSA_WITH_AES_256_GCM_SHA384"},
+	{0x003c, "TLS_RSA_WITH_AES_128_CBC_SHA256"},
+	{0x003d, "TLS_RSA_WITH_AES_256_CBC_SHA256"},
+	{0x002f, "TLS_RSA_WITH_AES_128_CBC_SHA"},
+	{0x0035, "TLS_RSA_WITH_AES_256_CBC_SHA"},
+	{0x0041, "TLS_RSA_WITH_CAMELLIA_128_CBC_SHA"},
+	{0x0084, "TLS_RSA_WITH_CAMELLIA_256_CBC_SHA"},
+	{0xc012, "TLS_ECDHE_RSA_WITH_3DES_EDE_CBC_SHA"},
+	{0xc008, "TLS_ECDHE_ECDSA_WITH_3DES_EDE_CBC_SHA"},
+	{0x0016, "TLS_DHE_RSA_WITH_3DES_EDE_CBC_SHA"},
+	{0x000a, "TLS_RSA_WITH_3DES_EDE_CBC_SHA"},
+	{0xc011, "TLS_ECDHE_RSA_WITH_RC4_128_SHA"},
+	{0xc007, "TLS_ECDHE_ECDSA_WITH_RC4_128_SHA"},
+	{0x0005, "TLS_RSA_WITH_RC4_128_SHA"},
+	{0x0004, "TLS_RSA_WITH_RC4_128_MD5"},
+	{0x0009, "TLS_RSA_WITH_DES_CBC_SHA"},
+	{0x0003, "TLS_RSA_EXPORT_WITH_RC4_40_MD5"},
+	{0x0008, "TLS_RSA_EXPORT_WITH_DES40_CBC_SHA"},
+	{0x0034, "TLS_DH_anon_WITH_AES_128_CBC_SHA"},
+	{0x0018, "TLS_DH_anon_WITH_RC4_128_MD5"},
+	{0x003b, "TLS_RSA_WITH_NULL_SHA256"},
+	{0x0002, "TLS_RSA_WITH_NULL_SHA"},
+	{0x0001, "TLS_RSA_WITH_NULL_MD5"},
+}
+
+type namedGroup struct {
+	id   uint16
+	name string
+}
+
+var auditGroups = []namedGroup{
+	{0x001d, "x25519"},
+	{0x0017, "secp256r1"},
+	{0x0018, "secp384r1"},
+	{0x0019, "secp521r1"},
+	{0x001e, "x448"},
+	{0x11ec, "X25519MLKEM768"},
+}
+
+func cipherSuiteInfo(suite cipherSuite) CipherSuiteInfo {
+	name := suite.name
+	return CipherSuiteInfo{
+		ID:   suite.id,
+		Name: name,
+		// TLS 1.3 suites have no key exchange in the name and are always
+		// ephemeral.
+		ForwardSecrecy: !strings.Contains(name, "_WITH_") || strings.HasPrefix(name, "TLS_ECDHE_") || strings.HasPrefix(name, "TLS_DHE_"),
+		Weak: strings.Contains(name, "_RC4_") || strings.Contains(name, "_3DES_") || strings.Contains(name, "_DES_") ||
+			strings.Contains(name, "_DES40_") || strings.Contains(name, "_NULL_") || strings.Contains(name, "_EXPORT_") ||
+			strings.Contains(name, "_anon_"),
+	}
+}
+
+// AuditTLS connects to host:port repeatedly to find which TLS versions,
+// cipher suites, key exchange groups and ALPN protocols the server accepts,
+// and flags weak configurations. Versions and ciphers are probed with raw
+// ClientHellos so that suites crypto/tls no longer offers can be detected.
+func AuditTLS(ctx context.Context, host string, port int, opts TLSAuditOptions) (*TLSAudit, error) {
+	opts = opts.withDefaults()
+	if opts.ServerName == "" && net.ParseIP(host) == nil {
+		opts.ServerName = host
+	}
+
+	prober := &helloProber{
+		addr: net.JoinHostPort(host, strconv.Itoa(port)),
+		opts: opts,
+	}
+
+	audit := &TLSAudit{Host: host, Port: port}
+	reachable := false
+	for _, version := range []uint16{tls.VersionTLS10, tls.VersionTLS11, tls.VersionTLS12, tls.VersionTLS13} {
+		support, err := prober.auditVersion(ctx, version)
+		if ctx.Err() != nil {
+			return audit, ctx.Err()
+		}
+		if err == nil || errors.Is(err, errHelloRejected) {
+			reachable = true
+		}
+		audit.Versions = append(audit.Versions, support)
+	}
+	if !reachable {
+		return audit, fmt.Errorf("TLS audit of %s failed: no handshake completed", prober.addr)
+	}
+
+	audit.ALPN = prober.enumerateALPN(ctx)
+	audit.Weaknesses = tlsWeaknesses(audit)
+	return audit, nil
+}
+
+var errHelloRejected = errors.New("server rejected the ClientHello")
+
+type helloProber struct {
+	addr string
+	opts TLSAuditOptions
+}
+
+func (p *helloProber) auditVersion(ctx context.Context, version uint16) (TLSVersionSupport, error) {
+	support := TLSVersionSupport{Version: version, Name: tls.VersionName(version)}
+
+	suites := legacyCipherSuites
+	if version == tls.VersionTLS13 {
+		suites = tls13CipherSuites
+	}
+
+	remaining := append([]cipherSuite(nil), suites...)
+	var accepted []cipherSuite
+	for len(remaining) > 0 {
+		hello, err := p.send(ctx, clientHelloSpec{version: version, suites: remaining, groups: auditGroups})
+		if err != nil {
+			if len(accepted) == 0 {
+				return support, err
+			}
+			break
+		}
+		idx := suiteIndex(remaining, hello.cipherSuite)
+		if idx < 0 {
+			break
+		}
+		accepted = append(accepted, remaining[idx])
+		remaining = append(remaining[:idx], remaining[idx+1:]...)
+	}
+
+	support.Supported = true
+	for _, suite := range accepted {
+		support.Ciphers = append(support.Ciphers, cipherSuiteInfo(suite))
+	}
+
+	// Offer the accepted suites in reverse: a server that enforces its own
+	// order still picks its favourite.
+	if len(accepted) > 1 {
+		reversed := make([]cipherSuite, len(accepted))
+		for i, suite := range accepted {
+			reversed[len(accepted)-1-i] = suite
+		}
+		if hello, err := p.send(ctx, clientHelloSpec{version: version, suites: reversed, groups: auditGroups}); err == nil {
+			support.ServerPreference = hello.cipherSuite == accepted[0].id
+		}
+	}
+
+	for _, group := range auditGroups {
+		spec := clientHelloSpec{version: version, suites: accepted, groups: []namedGroup{group}}
+		if version != tls.VersionTLS13 {
+			spec.suites = ecdheSuites(accepted)
+			if len(spec.suites) == 0 || group.id == 0x11ec {
+				continue
+			}
+		}
+		if _, err := p.send(ctx, spec); err == nil {
+			support.Curves = append(support.Curves, group.name)
+		}
+	}
+
+	return support, nil
+}
+
+func suiteIndex(suites []cipherSuite, id uint16) int {
+	for i, suite := range suites {
+		if suite.id == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func ecdheSuites(suites []cipherSuite) []cipherSuite {
+	var ecdhe []cipherSuite
+	for _, suite := range suites {
+		if strings.HasPrefix(suite.name, "TLS_ECDHE_") {
+			ecdhe = append(ecdhe, suite)
+		}
+	}
+	return ecdhe
+}
+
+func (p *helloProber) enumerateALPN(ctx context.Context) []string {
+	candidates := []string{"h2", "http/1.1", "http/1.0", "spdy/3.1"}
+	var offered []string
+
+	for len(candidates) > 0 {
+		protocol, err := p.negotiateALPN(ctx, candidates)
+		if err != nil {
+			break
+		}
+
+		idx := -1
+		for i, candidate := range candidates {
+			if candidate == protocol {
+				idx = i
+			}
+		}
+		if idx < 0 {
+			break
+		}
+		offered = append(offered, protocol)
+		candidates = append(candidates[:idx], candidates[idx+1:]...)
+	}
+
+	return offered
+}
+
+func (p *helloProber) negotiateALPN(ctx context.Context, protocols []string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.opts.Timeout)
+	defer cancel()
+
+	plainConn, err := dialStartTLS(ctx, p.opts.Dialer, p.addr, p.opts.StartTLS)
+	if err != nil {
+		return "", err
+	}
+	conn := tls.Client(plainConn, &tls.Config{
+		InsecureSkipVerify: true,
+		ServerName:         p.opts.ServerName,
+		MinVersion:         tls.VersionTLS10,
+		NextProtos:         protocols,
+	})
+	defer conn.Close()
+
+	if err := conn.HandshakeContext(ctx); err != nil {
+		return "", err
+	}
+	return conn.ConnectionState().NegotiatedProtocol, nil
+}
+
+func tlsWeaknesses(audit *TLSAudit) []TLSWeakness {
+	var weaknesses []TLSWeakness
+	add := func(w TLSWeakness) {
+		for _, existing := range weaknesses {
+			if existing == w {
+				return
+			}
+		}
+		weaknesses = append(weaknesses, w)
+	}
+
+	if audit.Supports(tls.VersionTLS10) || audit.Supports(tls.VersionTLS11) {
+		add(WeaknessLegacyProtocol)
+	}
+
+	// TLS 1.3 only has AEAD suites, so whether the older versions offer one
+	// is judged on their own suites: clients that can't do 1.3 only get those.
+	forwardSecrecy := audit.Supports(tls.VersionTLS13)
+	aead, legacyCiphers := false, false
+	for _, version := range audit.Versions {
+		for _, cipher := range version.Ciphers {
+			switch {
+			case strings.Contains(cipher.Name, "_RC4_"):
+				add(WeaknessRC4)
+			case strings.Contains(cipher.Name, "_3DES_"):
+				add(Weakness3DES)
+			case cipher.Weak:
+				add(WeaknessInsecureCipher)
+			}
+			forwardSecrecy = forwardSecrecy || cipher.ForwardSecrecy
+			if version.Version != tls.VersionTLS13 {
+				legacyCiphers = true
+				aead = aead || !strings.Contains(cipher.Name, "_CBC_") && !strings.Contains(cipher.Name, "_RC4_")
+			}
+		}
+	}
+
+	if legacyCiphers && !aead {
+		add(WeaknessCBCOnly)
+	}
+	if !forwardSecrecy {
+		add(WeaknessNoForwardSecrecy)
+	}
+	return weaknesses
+}
+
+type clientHelloSpec struct {
+	version uint16
+	suites  []cipherSuite
+	groups  []namedGroup
+}
+
+type serverHello struct {
+	version     uint16
+	cipherSuite uint16
+}
+
+// send writes one ClientHello and reads the ServerHello (or
+// HelloRetryRequest) that answers it. The connection is closed right after,
+// so no handshake is ever completed.
+func (p *helloProber) send(ctx context.Context, spec clientHelloSpec) (*serverHello, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.opts.Timeout)
+	defer cancel()
+
+	conn, err := dialStartTLS(ctx, p.opts.Dialer, p.addr, p.opts.StartTLS)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	record, err := buildClientHello(spec, p.opts.ServerName)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := conn.Write(record); err != nil {
+		return nil, err
+	}
+
+	hello, err := readServerHello(conn)
+	if err != nil {
+		return nil, err
+	}
+	if hello.version != spec.version {
+		return nil, errHelloRejected
+	}
+	return hello, nil
+}
+
+func buildClientHello(spec clientHelloSpec, serverName string) ([]byte, error) {
+	random := make([]byte, 32)
+	sessionID := make([]byte, 32)
+	if _, err := rand.Read(random); err != nil {
+		return nil, err
+	}
+	if _, err := rand.Read(sessionID); err != nil {
+		return nil, err
+	}
+
+	legacyVersion := spec.version
+	if spec.version == tls.VersionTLS13 {
+		legacyVersion = tls.VersionTLS12
+	}
+
+	var body []byte
+	body = binary.BigEndian.AppendUint16(body, legacyVersion)
+	body = append(body, random...)
+	body = append(body, byte(len(sessionID)))
+	body = append(body, sessionID...)
+	body = binary.BigEndian.AppendUint16(body, uint16(2*len(spec.suites)))
+	for _, suite := range spec.suites {
+		body = binary.BigEndian.AppendUint16(body, suite.id)
+	}
+	body = append(body, 1, 0) // null compression only
+
+	var extensions []byte
+	if serverName != "" {
+		var list []byte
+		list = append(list, 0) // host_name
+		list = appendVector16(list, []byte(serverName))
+		extensions = appendExtension(extensions, 0, appendVector16(nil, list))
+	}
+
+	var groups []byte
+	for _, group := range spec.groups {
+		groups = binary.BigEndian.AppendUint16(groups, group.id)
+	}
+	extensions = appendExtension(extensions, 10, appendVector16(nil, groups))
+	extensions = appendExtension(extensions, 11, []byte{1, 0})
+
+	var sigAlgs []byte
+	for _, alg := range []uint16{0x0403, 0x0503, 0x0603, 0x0804, 0x0805, 0x0806, 0x0807, 0x0401, 0x0501, 0x0601, 0x0203, 0x0201} {
+		sigAlgs = binary.BigEndian.AppendUint16(sigAlgs, alg)
+	}
+	extensions = appendExtension(extensions, 13, appendVector16(nil, sigAlgs))
+	extensions = appendExtension(extensions, 23, nil)
+	extensions = appendExtension(extensions, 0xff01, []byte{0})
+
+	if spec.version == tls.VersionTLS13 {
+		extensions = appendExtension(extensions, 43, []byte{2, 0x03, 0x04})
+		extensions = appendExtension(extensions, 45, []byte{1, 1})
+
+		// Offer an X25519 share when it is in the list; otherwise send none
+		// and let the server answer with a HelloRetryRequest, which already
+		// names the cipher and group it picked.
+		var shares []byte
+		for _, group := range spec.groups {
+			if group.id == 0x001d {
+				key, err := ecdh.X25519().GenerateKey(rand.Reader)
+				if err != nil {
+					return nil, err
+				}
+				shares = binary.BigEndian.AppendUint16(shares, group.id)
+				shares = appendVector16(shares, key.PublicKey().Bytes())
+			}
+		}
+		extensions = appendExtension(extensions, 51, appendVector16(nil, shares))
+	}
+	body = appendVector16(body, extensions)
+
+	handshake := []byte{1, byte(len(body) >> 16), byte(len(body) >> 8), byte(len(body))}
+	handshake = append(handshake, body...)
+
+	record := []byte{22, 0x03, 0x01}
+	return appendVector16(record, handshake), nil
+}
+
+func appendVector16(b, data []byte) []byte {
+	b = binary.BigEndian.AppendUint16(b, uint16(len(data)))
+	return append(b, data...)
+}
+
+func appendExtension(b []byte, extType uint16, data []byte) []byte {
+	b = binary.BigEndian.AppendUint16(b, extType)
+	return appendVector16(b, data)
+}
+
+// readServerHello reads handshake records until the first handshake message
+// is complete and parses it as a ServerHello. An alert means the server
+// refused what was offered.
+func readServerHello(r io.Reader) (*serverHello, error) {
+	var handshake []byte
+	header := make([]byte, 5)
+
+	for {
+		if _, err := io.ReadFull(r, header); err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				return nil, errHelloRejected
+			}
+			return nil, err
+		}
+		length := int(binary.BigEndian.Uint16(header[3:5]))
+		payload := make([]byte, length)
+		if _, err := io.ReadFull(r, payload); err != nil {
+			return nil, err
+		}
+
+		switch header[0] {
+		case 21:
+			return nil, errHelloRejected
+		case 22:
+			handshake = append(handshake, payload...)
+		default:
+			return nil, fmt.Errorf("unexpected TLS record type %d", header[0])
+		}
+
+		if len(handshake) < 4 {
+			continue
+		}
+		if handshake[0] != 2 {
+			return nil, fmt.Errorf("unexpected handshake message type %d", handshake[0])
+		}
+		msgLen := int(handshake[1])<<16 | int(handshake[2])<<8 | int(handshake[3])
+		if len(handshake) >= 4+msgLen {
+			return parseServerHello(handshake[4 : 4+msgLen])
+		}
+	}
+}
+
+func parseServerHello(msg []byte) (*serverHello, error) {
+	errMalformed := errors.New("malformed ServerHello")
+
+	if len(msg) < 2+32+1 {
+		return nil, errMalformed
+	}
+	hello := &serverHello{version: binary.BigEndian.Uint16(msg[0:2])}
+	msg = msg[34:]
+
+	sidLen := int(msg[0])
+	if len(msg) < 1+sidLen+3 {
+		return nil, errMalformed
+	}
+	msg = msg[1+sidLen:]
+	hello.cipherSuite = binary.BigEndian.Uint16(msg[0:2])
+	msg = msg[3:]
+
+	if len(msg) < 2 {
+		return hello, nil
+	}
+	extLen := int(binary.BigEndian.Uint16(msg[0:2]))
+	msg = msg[2:]
+	if len(msg) < extLen {
+		return nil, errMalformed
+	}
+	msg = msg[:extLen]
+
+	for len(msg) >= 4 {
+		extType := binary.BigEndian.Uint16(msg[0:2])
+		length := int(binary.BigEndian.Uint16(msg[2:4]))
+		if len(msg) < 4+length {
+			return nil, errMalformed
+		}
+		if extType == 43 && length == 2 {
+			hello.version = binary.BigEndian.Uint16(msg[4:6])
+		}
+		msg = msg[4+length:]
+	}
+	return hello, nil
+}
@@ -0,0 +1,186 @@
+package gowebspy
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// tlsServer serves handshakes with config on a local port until the test ends.
+func tlsServer(t *testing.T, config *tls.Config) int {
+	t.Helper()
+
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", config)
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				conn.SetDeadline(time.Now().Add(2 * time.Second))
+				conn.(*tls.Conn).Handshake()
+			}()
+		}
+	}()
+
+	return listener.Addr().(*net.TCPAddr).Port
+}
+
+func ecdsaServerCertificate(t *testing.T) tls.Certificate {
+	now := time.Now()
+	leaf := issueCert(t, leafTemplate("localhost", 1, now.Add(-time.Hour), now.Add(time.Hour)), nil)
+	return tls.Certificate{Certificate: [][]byte{leaf.cert.Raw}, PrivateKey: leaf.key}
+}
+
+func rsaServerCertificate(t *testing.T) tls.Certificate {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func TestAuditTLSModern(t *testing.T) {
+	port := tlsServer(t, &tls.Config{
+		Certificates:     []tls.Certificate{ecdsaServerCertificate(t)},
+		MinVersion:       tls.VersionTLS12,
+		CurvePreferences: []tls.CurveID{tls.X25519, tls.CurveP256},
+		NextProtos:       []string{"h2", "http/1.1"},
+	})
+
+	audit, err := AuditTLS(context.Background(), "127.0.0.1", port, TLSAuditOptions{Timeout: time.Second})
+	if err != nil {
+		t.Fatalf("AuditTLS failed: %v", err)
+	}
+
+	for version, expected := range map[uint16]bool{
+		tls.VersionTLS10: false,
+		tls.VersionTLS11: false,
+		tls.VersionTLS12: true,
+		tls.VersionTLS13: true,
+	} {
+		if audit.Supports(version) != expected {
+			t.Errorf("Supports(%s) = %v, want %v", tls.VersionName(version), !expected, expected)
+		}
+	}
+
+	for _, version := range audit.Versions {
+		if !version.Supported {
+			continue
+		}
+		if len(version.Ciphers) == 0 {
+			t.Errorf("%s: no ciphers enumerated", version.Name)
+		}
+		if !reflect.DeepEqual(version.Curves, []string{"x25519", "secp256r1"}) {
+			t.Errorf("%s: Curves = %v, want [x25519 secp256r1]", version.Name, version.Curves)
+		}
+		for _, cipher := range version.Ciphers {
+			if !cipher.ForwardSecrecy || cipher.Weak {
+				t.Errorf("%s: unexpected cipher %+v", version.Name, cipher)
+			}
+		}
+	}
+
+	if !reflect.DeepEqual(audit.ALPN, []string{"h2", "http/1.1"}) {
+		t.Errorf("ALPN = %v, want [h2 http/1.1]", audit.ALPN)
+	}
+	if len(audit.Weaknesses) != 0 {
+		t.Errorf("Weaknesses = %v, want none", audit.Weaknesses)
+	}
+}
+
+func TestAuditTLSWeak(t *testing.T) {
+	port := tlsServer(t, &tls.Config{
+		Certificates: []tls.Certificate{rsaServerCertificate(t)},
+		MinVersion:   tls.VersionTLS10,
+		MaxVersion:   tls.VersionTLS12,
+		CipherSuites: []uint16{
+			tls.TLS_RSA_WITH_AES_128_CBC_SHA,
+			tls.TLS_RSA_WITH_3DES_EDE_CBC_SHA,
+			tls.TLS_RSA_WITH_RC4_128_SHA,
+		},
+	})
+
+	audit, err := AuditTLS(context.Background(), "127.0.0.1", port, TLSAuditOptions{Timeout: time.Second})
+	if err != nil {
+		t.Fatalf("AuditTLS failed: %v", err)
+	}
+
+	if !audit.Supports(tls.VersionTLS10) || audit.Supports(tls.VersionTLS13) {
+		t.Errorf("Unexpected versions: %+v", audit.Versions)
+	}
+
+	for _, version := range audit.Versions {
+		if version.Version == tls.VersionTLS12 && len(version.Ciphers) != 3 {
+			t.Errorf("TLS 1.2 ciphers = %+v, want 3", version.Ciphers)
+		}
+	}
+
+	expected := []TLSWeakness{WeaknessLegacyProtocol, WeaknessRC4, Weakness3DES, WeaknessCBCOnly, WeaknessNoForwardSecrecy}
+	for _, weakness := range expected {
+		found := false
+		for _, w := range audit.Weaknesses {
+			found = found || w == weakness
+		}
+		if !found {
+			t.Errorf("Weaknesses = %v, missing %s", audit.Weaknesses, weakness)
+		}
+	}
+}
+
+func TestAuditTLSCBCOnlyWithTLS13(t *testing.T) {
+	port := tlsServer(t, &tls.Config{
+		Certificates: []tls.Certificate{ecdsaServerCertificate(t)},
+		MinVersion:   tls.VersionTLS12,
+		CipherSuites: []uint16{
+			tls.TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA,
+			tls.TLS_ECDHE_ECDSA_WITH_AES_256_CBC_SHA,
+		},
+	})
+
+	audit, err := AuditTLS(context.Background(), "127.0.0.1", port, TLSAuditOptions{Timeout: time.Second})
+	if err != nil {
+		t.Fatalf("AuditTLS failed: %v", err)
+	}
+	if !audit.Supports(tls.VersionTLS12) || !audit.Supports(tls.VersionTLS13) {
+		t.Fatalf("Unexpected versions: %+v", audit.Versions)
+	}
+	if !reflect.DeepEqual(audit.Weaknesses, []TLSWeakness{WeaknessCBCOnly}) {
+		t.Errorf("Weaknesses = %v, want [%s]", audit.Weaknesses, WeaknessCBCOnly)
+	}
+}
+
+func TestAuditTLSUnreachable(t *testing.T) {
+	if _, err := AuditTLS(context.Background(), "127.0.0.1", freePort(t), TLSAuditOptions{Timeout: time.Second}); err == nil {
+		t.Errorf("Expected an error for a closed port")
+	}
+}
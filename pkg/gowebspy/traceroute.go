@@ -0,0 +1,539 @@
+package gowebspy
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"syscall"
+	"time"
+
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+)
+
+const (
+	defaultTracerouteMaxHops = 30
+	defaultTracerouteProbes  = 3
+	defaultTracerouteTimeout = time.Second
+	defaultTraceroutePort    = 33434
+
+	protocolICMP   = 1
+	protocolICMPv6 = 58
+)
+
+// ErrTraceroutePermission matches (via errors.Is) the TracerouteError returned
+// when the raw ICMP socket needed to receive time-exceeded replies cannot be
+// opened.
+var ErrTraceroutePermission = errors.New("traceroute needs raw socket privileges (run as root or grant CAP_NET_RAW)")
+
+// TracerouteErrorReason is a short, stable classification of a trace failure
+// suitable for display and for the JSON report.
+type TracerouteErrorReason string
+
+const (
+	TracerouteReasonPermission TracerouteErrorReason = "permission denied"
+	TracerouteReasonResolve    TracerouteErrorReason = "resolve failed"
+	TracerouteReasonTimeout    TracerouteErrorReason = "timeout"
+	TracerouteReasonNetwork    TracerouteErrorReason = "network error"
+)
+
+// TracerouteError explains why a trace could not be completed. Hops gathered
+// before the failure are still returned alongside it.
+type TracerouteError struct {
+	Host   string
+	Reason TracerouteErrorReason
+	Err    error
+}
+
+func (e *TracerouteError) Error() string {
+	return fmt.Sprintf("traceroute to %s failed (%s): %v", e.Host, e.Reason, e.Err)
+}
+
+func (e *TracerouteError) Unwrap() error {
+	return e.Err
+}
+
+func (e *TracerouteError) Is(target error) bool {
+	return target == ErrTraceroutePermission && e.Reason == TracerouteReasonPermission
+}
+
+func tracerouteError(host string, err error) error {
+	reason := TracerouteReasonNetwork
+	switch {
+	case errors.Is(err, ErrTraceroutePermission), errors.Is(err, os.ErrPermission):
+		reason = TracerouteReasonPermission
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, os.ErrDeadlineExceeded):
+		reason = TracerouteReasonTimeout
+	}
+	return &TracerouteError{Host: host, Reason: reason, Err: err}
+}
+
+var errNoReplies = errors.New("no replies received from any hop")
+
+type TracerouteOptions struct {
+	MaxHops int
+	// Probes is the number of probes sent per hop.
+	Probes int
+	// Timeout is how long to wait for the replies to one hop's probes.
+	Timeout time.Duration
+	// UDP sends UDP datagrams to high ports instead of ICMP echo requests.
+	UDP bool
+	// Port is the first destination port used for UDP probes.
+	Port     int
+	IPv6     bool
+	Resolver *net.Resolver
+}
+
+func (o TracerouteOptions) withDefaults() TracerouteOptions {
+	if o.MaxHops <= 0 {
+		o.MaxHops = defaultTracerouteMaxHops
+	}
+	if o.Probes <= 0 {
+		o.Probes = defaultTracerouteProbes
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = defaultTracerouteTimeout
+	}
+	if o.Port <= 0 {
+		o.Port = defaultTraceroutePort
+	}
+	if o.Resolver == nil {
+		o.Resolver = net.DefaultResolver
+	}
+	return o
+}
+
+// Traceroute traces the path to host by sending probes with increasing TTL
+// and listening for ICMP time-exceeded replies. It stops at the first hop
+// that is the destination itself.
+func Traceroute(ctx context.Context, host string, opts TracerouteOptions) ([]TracerouteHop, error) {
+	opts = opts.withDefaults()
+
+	dst, err := resolveTracerouteTarget(ctx, host, opts)
+	if err != nil {
+		return nil, &TracerouteError{Host: host, Reason: TracerouteReasonResolve, Err: err}
+	}
+
+	tracer, err := newTracer(dst, opts)
+	if err != nil {
+		return nil, tracerouteError(host, err)
+	}
+	defer tracer.close()
+
+	var hops []TracerouteHop
+	replies := 0
+	for ttl := 1; ttl <= opts.MaxHops; ttl++ {
+		hop, reached, err := tracer.probeHop(ctx, ttl)
+		if err != nil {
+			return hops, tracerouteError(host, err)
+		}
+		hops = append(hops, hop)
+		replies += hop.Received
+		if reached {
+			return hops, nil
+		}
+	}
+
+	if replies == 0 {
+		return hops, &TracerouteError{Host: host, Reason: TracerouteReasonTimeout, Err: errNoReplies}
+	}
+	return hops, nil
+}
+
+func resolveTracerouteTarget(ctx context.Context, host string, opts TracerouteOptions) (net.IP, error) {
+	network := "ip4"
+	if opts.IPv6 {
+		network = "ip6"
+	}
+
+	if ip := net.ParseIP(host); ip != nil {
+		if (ip.To4() != nil) == opts.IPv6 {
+			return nil, fmt.Errorf("%s is not an %s address", host, network)
+		}
+		return ip, nil
+	}
+
+	ips, err := opts.Resolver.LookupIP(ctx, network, host)
+	if err != nil {
+		return nil, fmt.Errorf("IP lookup failed: %w", err)
+	}
+	if len(ips) == 0 {
+		return nil, fmt.Errorf("IP lookup failed: no %s address for %s", network, host)
+	}
+	return ips[0], nil
+}
+
+type tracer struct {
+	opts   TracerouteOptions
+	dst    net.IP
+	icmp   *icmp.PacketConn
+	udp    net.PacketConn
+	id     int
+	seq    int
+	sentAt map[int]probeInfo
+}
+
+type probeInfo struct {
+	ttl  int
+	sent time.Time
+}
+
+func newTracer(dst net.IP, opts TracerouteOptions) (*tracer, error) {
+	network, address := "ip4:icmp", "0.0.0.0"
+	if opts.IPv6 {
+		network, address = "ip6:ipv6-icmp", "::"
+	}
+
+	conn, err := icmp.ListenPacket(network, address)
+	if err != nil {
+		if errors.Is(err, os.ErrPermission) {
+			return nil, ErrTraceroutePermission
+		}
+		return nil, fmt.Errorf("failed to open ICMP socket: %w", err)
+	}
+
+	t := &tracer{
+		opts:   opts,
+		dst:    dst,
+		icmp:   conn,
+		id:     os.Getpid() & 0xffff,
+		sentAt: make(map[int]probeInfo),
+	}
+
+	if opts.UDP {
+		udpNetwork := "udp4"
+		if opts.IPv6 {
+			udpNetwork = "udp6"
+		}
+		t.udp, err = net.ListenPacket(udpNetwork, "")
+		if err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed to open UDP socket: %w", err)
+		}
+	}
+
+	return t, nil
+}
+
+func (t *tracer) close() {
+	t.icmp.Close()
+	if t.udp != nil {
+		t.udp.Close()
+	}
+}
+
+func (t *tracer) setTTL(ttl int) error {
+	if t.udp != nil {
+		if t.opts.IPv6 {
+			return ipv6.NewPacketConn(t.udp).SetHopLimit(ttl)
+		}
+		return ipv4.NewPacketConn(t.udp).SetTTL(ttl)
+	}
+	if t.opts.IPv6 {
+		return t.icmp.IPv6PacketConn().SetHopLimit(ttl)
+	}
+	return t.icmp.IPv4PacketConn().SetTTL(ttl)
+}
+
+func (t *tracer) send(seq int) error {
+	if t.udp != nil {
+		dst := &net.UDPAddr{IP: t.dst, Port: t.opts.Port + seq}
+		_, err := t.udp.WriteTo([]byte("gowebspy"), dst)
+		return err
+	}
+
+	var msgType icmp.Type = ipv4.ICMPTypeEcho
+	if t.opts.IPv6 {
+		msgType = ipv6.ICMPTypeEchoRequest
+	}
+	msg := icmp.Message{
+		Type: msgType,
+		Body: &icmp.Echo{ID: t.id, Seq: seq, Data: []byte("gowebspy")},
+	}
+	data, err := msg.Marshal(nil)
+	if err != nil {
+		return err
+	}
+	_, err = t.icmp.WriteTo(data, &net.IPAddr{IP: t.dst})
+	return err
+}
+
+// probeHop sends all probes for one TTL at once and collects replies until
+// every probe is answered or the timeout expires.
+func (t *tracer) probeHop(ctx context.Context, ttl int) (TracerouteHop, bool, error) {
+	hop := TracerouteHop{Number: ttl, IP: "*"}
+
+	if err := t.setTTL(ttl); err != nil {
+		return hop, false, fmt.Errorf("failed to set TTL: %w", err)
+	}
+
+	pending := make(map[int]bool)
+	for i := 0; i < t.opts.Probes; i++ {
+		t.seq = (t.seq + 1) & 0xffff
+		t.sentAt[t.seq] = probeInfo{ttl: ttl, sent: time.Now()}
+		if err := t.send(t.seq); err != nil {
+			return hop, false, fmt.Errorf("failed to send probe: %w", err)
+		}
+		pending[t.seq] = true
+		hop.Sent++
+	}
+
+	deadline := time.Now().Add(t.opts.Timeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	t.icmp.SetReadDeadline(deadline)
+
+	var rtts []time.Duration
+	reached := false
+	buf := make([]byte, 1500)
+
+	for len(pending) > 0 {
+		n, peer, err := t.icmp.ReadFrom(buf)
+		if err != nil {
+			if errors.Is(err, os.ErrDeadlineExceeded) {
+				break
+			}
+			return hop, false, fmt.Errorf("failed to read ICMP reply: %w", err)
+		}
+		received := time.Now()
+
+		seq, fromDestination, ok := t.matchReply(buf[:n])
+		if !ok || !pending[seq] {
+			continue
+		}
+		delete(pending, seq)
+
+		rtts = append(rtts, received.Sub(t.sentAt[seq].sent))
+		delete(t.sentAt, seq)
+		if hop.IP == "*" {
+			hop.IP = peerIP(peer)
+		}
+		if fromDestination {
+			reached = true
+		}
+	}
+
+	for seq := range pending {
+		delete(t.sentAt, seq)
+	}
+
+	hop.setRTTs(rtts)
+
+	if ctx.Err() != nil {
+		return hop, false, ctx.Err()
+	}
+	return hop, reached, nil
+}
+
+// matchReply decides whether an ICMP message answers one of our probes and
+// returns the probe's sequence number and whether it came from the target.
+func (t *tracer) matchReply(data []byte) (int, bool, bool) {
+	proto := protocolICMP
+	if t.opts.IPv6 {
+		proto = protocolICMPv6
+	}
+
+	msg, err := icmp.ParseMessage(proto, data)
+	if err != nil {
+		return 0, false, false
+	}
+
+	switch body := msg.Body.(type) {
+	case *icmp.Echo:
+		if t.udp != nil || body.ID != t.id {
+			return 0, false, false
+		}
+		if msg.Type != ipv4.ICMPTypeEchoReply && msg.Type != ipv6.ICMPTypeEchoReply {
+			return 0, false, false
+		}
+		return body.Seq, true, true
+	case *icmp.TimeExceeded:
+		seq, ok := t.matchQuoted(body.Data)
+		return seq, false, ok
+	case *icmp.DstUnreach:
+		seq, ok := t.matchQuoted(body.Data)
+		return seq, true, ok
+	}
+
+	return 0, false, false
+}
+
+// matchQuoted inspects the original datagram quoted in an ICMP error: its IP
+// header followed by at least the first eight bytes of our probe.
+func (t *tracer) matchQuoted(data []byte) (int, bool) {
+	var headerLen, proto int
+	if t.opts.IPv6 {
+		if len(data) < ipv6.HeaderLen {
+			return 0, false
+		}
+		headerLen, proto = ipv6.HeaderLen, int(data[6])
+	} else {
+		if len(data) < ipv4.HeaderLen {
+			return 0, false
+		}
+		headerLen, proto = int(data[0]&0x0f)*4, int(data[9])
+	}
+	if len(data) < headerLen+8 {
+		return 0, false
+	}
+	payload := data[headerLen:]
+
+	if t.udp != nil {
+		if proto != 17 {
+			return 0, false
+		}
+		srcPort := int(binary.BigEndian.Uint16(payload[0:2]))
+		dstPort := int(binary.BigEndian.Uint16(payload[2:4]))
+		if srcPort != t.udp.LocalAddr().(*net.UDPAddr).Port {
+			return 0, false
+		}
+		return dstPort - t.opts.Port, true
+	}
+
+	if proto != protocolICMP && proto != protocolICMPv6 {
+		return 0, false
+	}
+	id := int(binary.BigEndian.Uint16(payload[4:6]))
+	if id != t.id {
+		return 0, false
+	}
+	return int(binary.BigEndian.Uint16(payload[6:8])), true
+}
+
+func peerIP(addr net.Addr) string {
+	switch a := addr.(type) {
+	case *net.IPAddr:
+		return a.IP.String()
+	case *net.UDPAddr:
+		return a.IP.String()
+	}
+	return addr.String()
+}
+
+func (hop *TracerouteHop) setRTTs(rtts []time.Duration) {
+	hop.Received = len(rtts)
+	if hop.Sent > 0 {
+		hop.Loss = float64(hop.Sent-hop.Received) / float64(hop.Sent)
+	}
+	if len(rtts) == 0 {
+		return
+	}
+
+	var total time.Duration
+	hop.MinRTT, hop.MaxRTT = rtts[0], rtts[0]
+	for _, rtt := range rtts {
+		total += rtt
+		if rtt < hop.MinRTT {
+			hop.MinRTT = rtt
+		}
+		if rtt > hop.MaxRTT {
+			hop.MaxRTT = rtt
+		}
+	}
+	hop.AvgRTT = total / time.Duration(len(rtts))
+	hop.RTT = hop.AvgRTT
+}
+
+// EstimatePathTCP approximates a traceroute without raw sockets by opening
+// TCP connections to host:port with increasing TTL. Routers that drop an
+// expired SYN usually make the connect fail with "host unreachable", which
+// gives the round-trip time to that hop but not its address, so intermediate
+// hops have an empty IP. The hop where the connect is accepted or refused is
+// the destination. Every hop is marked Estimated.
+func EstimatePathTCP(ctx context.Context, host string, port int, opts TracerouteOptions) ([]TracerouteHop, error) {
+	opts = opts.withDefaults()
+
+	dst, err := resolveTracerouteTarget(ctx, host, opts)
+	if err != nil {
+		return nil, &TracerouteError{Host: host, Reason: TracerouteReasonResolve, Err: err}
+	}
+	addr := net.JoinHostPort(dst.String(), fmt.Sprint(port))
+
+	var hops []TracerouteHop
+	replies := 0
+	for ttl := 1; ttl <= opts.MaxHops; ttl++ {
+		hop := TracerouteHop{Number: ttl, IP: "*", Estimated: true}
+		reached := false
+		var rtts []time.Duration
+
+		for i := 0; i < opts.Probes; i++ {
+			rtt, outcome := tcpProbe(ctx, addr, opts.IPv6, ttl, opts.Timeout)
+			if ctx.Err() != nil {
+				return hops, tracerouteError(host, ctx.Err())
+			}
+			hop.Sent++
+			switch outcome {
+			case tcpProbeReached:
+				reached = true
+				hop.IP = dst.String()
+				rtts = append(rtts, rtt)
+			case tcpProbeRouter:
+				if hop.IP == "*" {
+					hop.IP = ""
+				}
+				rtts = append(rtts, rtt)
+			}
+		}
+
+		hop.setRTTs(rtts)
+		hops = append(hops, hop)
+		replies += hop.Received
+		if reached {
+			return hops, nil
+		}
+	}
+
+	if replies == 0 {
+		return hops, &TracerouteError{Host: host, Reason: TracerouteReasonTimeout, Err: errNoReplies}
+	}
+	return hops, nil
+}
+
+type tcpProbeOutcome int
+
+const (
+	tcpProbeLost tcpProbeOutcome = iota
+	tcpProbeRouter
+	tcpProbeReached
+)
+
+func tcpProbe(ctx context.Context, addr string, ipv6 bool, ttl int, timeout time.Duration) (time.Duration, tcpProbeOutcome) {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, c syscall.RawConn) error {
+			var sockErr error
+			err := c.Control(func(fd uintptr) {
+				sockErr = setSocketTTL(fd, ipv6, ttl)
+			})
+			if err != nil {
+				return err
+			}
+			return sockErr
+		},
+	}
+
+	network := "tcp4"
+	if ipv6 {
+		network = "tcp6"
+	}
+
+	start := time.Now()
+	conn, err := dialer.DialContext(ctx, network, addr)
+	rtt := time.Since(start)
+
+	switch {
+	case err == nil:
+		conn.Close()
+		return rtt, tcpProbeReached
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return rtt, tcpProbeReached
+	case errors.Is(err, syscall.EHOSTUNREACH), errors.Is(err, syscall.ENETUNREACH):
+		return rtt, tcpProbeRouter
+	}
+	return rtt, tcpProbeLost
+}
@@ -0,0 +1,142 @@
+package gowebspy
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+)
+
+func quotedIPv4(proto byte, payload []byte) []byte {
+	header := make([]byte, ipv4.HeaderLen)
+	header[0] = 0x45
+	header[9] = proto
+	return append(header, payload...)
+}
+
+func TestTracerMatchReply(t *testing.T) {
+	tr := &tracer{opts: TracerouteOptions{Port: defaultTraceroutePort}, id: 0x1234}
+
+	echoHeader := make([]byte, 8)
+	echoHeader[0] = byte(ipv4.ICMPTypeEcho)
+	binary.BigEndian.PutUint16(echoHeader[4:6], 0x1234)
+	binary.BigEndian.PutUint16(echoHeader[6:8], 7)
+
+	timeExceeded, _ := (&icmp.Message{
+		Type: ipv4.ICMPTypeTimeExceeded,
+		Body: &icmp.TimeExceeded{Data: quotedIPv4(protocolICMP, echoHeader)},
+	}).Marshal(nil)
+
+	seq, fromDestination, ok := tr.matchReply(timeExceeded)
+	if !ok || seq != 7 || fromDestination {
+		t.Errorf("time exceeded: got seq=%d fromDestination=%v ok=%v", seq, fromDestination, ok)
+	}
+
+	echoReply, _ := (&icmp.Message{
+		Type: ipv4.ICMPTypeEchoReply,
+		Body: &icmp.Echo{ID: 0x1234, Seq: 9},
+	}).Marshal(nil)
+
+	seq, fromDestination, ok = tr.matchReply(echoReply)
+	if !ok || seq != 9 || !fromDestination {
+		t.Errorf("echo reply: got seq=%d fromDestination=%v ok=%v", seq, fromDestination, ok)
+	}
+
+	otherProcess, _ := (&icmp.Message{
+		Type: ipv4.ICMPTypeEchoReply,
+		Body: &icmp.Echo{ID: 0x4321, Seq: 9},
+	}).Marshal(nil)
+
+	if _, _, ok := tr.matchReply(otherProcess); ok {
+		t.Errorf("echo reply for another process should not match")
+	}
+}
+
+func TestHopSetRTTs(t *testing.T) {
+	hop := TracerouteHop{Sent: 4}
+	hop.setRTTs([]time.Duration{10 * time.Millisecond, 30 * time.Millisecond, 20 * time.Millisecond})
+
+	if hop.Received != 3 || hop.Loss != 0.25 {
+		t.Errorf("Received = %d, Loss = %v", hop.Received, hop.Loss)
+	}
+	if hop.MinRTT != 10*time.Millisecond || hop.MaxRTT != 30*time.Millisecond || hop.AvgRTT != 20*time.Millisecond {
+		t.Errorf("Unexpected RTTs: min=%v avg=%v max=%v", hop.MinRTT, hop.AvgRTT, hop.MaxRTT)
+	}
+	if hop.RTT != hop.AvgRTT {
+		t.Errorf("RTT = %v, want the average %v", hop.RTT, hop.AvgRTT)
+	}
+}
+
+func TestTracerouteLoopback(t *testing.T) {
+	for _, udp := range []bool{false, true} {
+		hops, err := Traceroute(context.Background(), "127.0.0.1", TracerouteOptions{UDP: udp, MaxHops: 3})
+		if errors.Is(err, ErrTraceroutePermission) {
+			t.Skip("raw sockets not permitted")
+		}
+		if err != nil {
+			t.Fatalf("Traceroute(udp=%v) failed: %v", udp, err)
+		}
+		if len(hops) != 1 || hops[0].IP != "127.0.0.1" || hops[0].Received == 0 {
+			t.Errorf("Traceroute(udp=%v) = %+v, want a single loopback hop", udp, hops)
+		}
+	}
+}
+
+func TestTracerouteErrorClassification(t *testing.T) {
+	tests := []struct {
+		err      error
+		expected TracerouteErrorReason
+	}{
+		{ErrTraceroutePermission, TracerouteReasonPermission},
+		{&os.SyscallError{Syscall: "socket", Err: syscall.EPERM}, TracerouteReasonPermission},
+		{context.DeadlineExceeded, TracerouteReasonTimeout},
+		{errors.New("network is unreachable"), TracerouteReasonNetwork},
+	}
+
+	for _, test := range tests {
+		err := tracerouteError("example.com", test.err)
+		var traceErr *TracerouteError
+		if !errors.As(err, &traceErr) {
+			t.Fatalf("tracerouteError(%v) is not a *TracerouteError", test.err)
+		}
+		if traceErr.Reason != test.expected {
+			t.Errorf("tracerouteError(%v).Reason = %q, want %q", test.err, traceErr.Reason, test.expected)
+		}
+		if got := errors.Is(err, ErrTraceroutePermission); got != (test.expected == TracerouteReasonPermission) {
+			t.Errorf("errors.Is(%v, ErrTraceroutePermission) = %v", err, got)
+		}
+	}
+}
+
+func TestEstimatePathTCPLoopback(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+
+	port := listener.Addr().(*net.TCPAddr).Port
+	hops, err := EstimatePathTCP(context.Background(), "127.0.0.1", port, TracerouteOptions{MaxHops: 3, Probes: 1})
+	if err != nil {
+		t.Fatalf("EstimatePathTCP failed: %v", err)
+	}
+	if len(hops) != 1 || hops[0].IP != "127.0.0.1" || !hops[0].Estimated {
+		t.Errorf("EstimatePathTCP = %+v, want a single estimated loopback hop", hops)
+	}
+}